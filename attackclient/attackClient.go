@@ -15,6 +15,7 @@ type Client struct {
 	uuid   string
 	valIdx int
 	info   atomic.Value
+	exit   atomic.Value // ExitFunc run for CMD_EXIT
 }
 
 // Dial connects a client to the given URL.
@@ -22,19 +23,29 @@ func Dial(rawurl string, valIdx int) (*Client, error) {
 	return DialContext(context.Background(), rawurl, valIdx)
 }
 
-// DialContext connects a client to the given URL with context.
+// DialContext connects a client to the given URL with context, the client
+// info is sent in the header of every call.
 func DialContext(ctx context.Context, rawurl string, valIdx int) (*Client, error) {
-	c, err := rpc.DialContext(ctx, rawurl)
+	client := newClient(valIdx)
+	c, err := rpc.DialOptions(ctx, rawurl, rpc.WithHeader(types.ClientInfoHeader, client.clientInfo()))
 	if err != nil {
 		return nil, err
 	}
-	return NewClient(c, valIdx), nil
+	client.c = c
+	return client, nil
 }
 
-// NewClient creates a client that uses the given RPC client.
+// NewClient creates a client that uses the given RPC client, the client info
+// is only sent over http.
 func NewClient(c *rpc.Client, valIdx int) *Client {
+	client := newClient(valIdx)
+	client.c = c
+	c.SetHeader(types.ClientInfoHeader, client.clientInfo())
+	return client
+}
+
+func newClient(valIdx int) *Client {
 	client := &Client{
-		c:      c,
 		uuid:   uuid.NewString(),
 		valIdx: valIdx,
 	}
@@ -0,0 +1,63 @@
+package attackclient
+
+import (
+	"context"
+	"time"
+
+	"github.com/tsinghua-cel/attacker-service/types"
+)
+
+var delayModule = "delay"
+
+// DelayWait returns when the delay is released or cancelled, or after a few
+// seconds with Released false.
+func (ec *Client) DelayWait(ctx context.Context, id string) (types.DelayInfo, error) {
+	var result types.DelayInfo
+	err := ec.c.CallContext(ctx, &result, delayModule+"_wait", id)
+	if err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// delayRetry is the pause before asking again for a delay the service
+// couldn't answer for.
+const delayRetry = time.Second
+
+// WaitDelay blocks until the delay of res is released, it returns at once if
+// res has no delay. It asks the service with delay_wait until the delay is
+// released, so a cancelled delay returns early. While the service can't be
+// reached it retries until the release time it was given.
+func (ec *Client) WaitDelay(ctx context.Context, res types.AttackerResponse) error {
+	if res.Delay == nil {
+		return nil
+	}
+	release := time.UnixMilli(res.Delay.Release)
+	for {
+		info, err := ec.DelayWait(ctx, res.Delay.ID)
+		// a cancelled delay has its release time moved to the cancellation.
+		if err == nil && (info.Released || !time.Now().Before(time.UnixMilli(info.Release))) {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err == nil {
+			continue
+		}
+		left := time.Until(release)
+		if left <= 0 {
+			return nil
+		}
+		if left > delayRetry {
+			left = delayRetry
+		}
+		timer := time.NewTimer(left)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package attackclient
+
+import (
+	"context"
+	"errors"
+
+	"github.com/tsinghua-cel/attacker-service/types"
+)
+
+var ErrNoExitHandler = errors.New("CMD_EXIT without an exit handler")
+
+// ExitFunc signs and submits a voluntary exit of the validator with pubkey,
+// the service has no validator keys so the validator client provides it, eg.
+// with the exit path of its accounts command.
+type ExitFunc func(ctx context.Context, pubkey string) error
+
+// SetExitHandler sets the function run when a hook of the validator returns
+// CMD_EXIT.
+func (ec *Client) SetExitHandler(exit ExitFunc) {
+	ec.exit.Store(exit)
+}
+
+// handleExit submits the voluntary exit asked by a CMD_EXIT response, the
+// response becomes CMD_NULL so the duty goes on. The error is
+// ErrNoExitHandler if no exit handler is set, or the error of the exit.
+func (ec *Client) handleExit(ctx context.Context, pubkey string, res types.AttackerResponse) (types.AttackerResponse, error) {
+	if res.Cmd != types.CMD_EXIT {
+		return res, nil
+	}
+	res.Cmd = types.CMD_NULL
+	exit, _ := ec.exit.Load().(ExitFunc)
+	if exit == nil {
+		return res, ErrNoExitHandler
+	}
+	return res, exit(ctx, pubkey)
+}
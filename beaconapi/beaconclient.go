@@ -1,11 +1,18 @@
 package beaconapi
 
 import (
+	"bytes"
+	"context"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"github.com/astaxie/beego/httplib"
-	log "github.com/sirupsen/logrus"
+	"io"
+	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
 )
 
 const (
@@ -13,203 +20,488 @@ const (
 	SECONDS_PER_SLOT = "SECONDS_PER_SLOT"
 )
 
+// DefaultTimeout bounds every request without a deadline of its own.
+const DefaultTimeout = 10 * time.Second
+
+// BeaconGwClient is a client of the Beacon REST API. The methods fail with an
+// *APIError for the error responses of the beacon node. With several beacon
+// nodes, a request goes to the preferred node and fails over to the others,
+// see nodes.go.
 type BeaconGwClient struct {
-	endpoint string
-	config   map[string]string
+	nodes   *nodeSet // shared with the copies of WithContext and View
+	pinned  int      // index of the only node asked, -1 to fail over
+	client  *http.Client
+	timeout time.Duration
+	ctx     context.Context
+	spec    *specCache // shared with the copies of WithContext and View
+}
+
+type specCache struct {
+	values map[string]string
+	lock   sync.Mutex
 }
 
-func NewBeaconGwClient(endpoint string) *BeaconGwClient {
-	return &BeaconGwClient{
-		endpoint: endpoint,
-		config:   make(map[string]string),
+// Option configures a BeaconGwClient.
+type Option func(*BeaconGwClient)
+
+// WithTimeout sets the timeout of each request, 0 disables it.
+func WithTimeout(d time.Duration) Option {
+	return func(b *BeaconGwClient) {
+		b.timeout = d
 	}
 }
 
-func (b *BeaconGwClient) GetIntConfig(key string) (int, error) {
-	config := b.GetBeaconConfig()
-	if v, exist := config[key]; !exist {
-		return 0, nil
-	} else {
-		return strconv.Atoi(v)
+// WithHTTPClient sets the http client, eg. with custom TLS settings.
+func WithHTTPClient(c *http.Client) Option {
+	return func(b *BeaconGwClient) {
+		b.client = c
+	}
+}
+
+// NewBeaconGwClient creates a client of endpoint, which is a host:port
+// (http) or a http:// or https:// url, or a comma separated list of them.
+func NewBeaconGwClient(endpoint string, opts ...Option) *BeaconGwClient {
+	var urls []string
+	for _, ep := range strings.Split(endpoint, ",") {
+		if ep = strings.TrimSpace(ep); ep != "" {
+			urls = append(urls, baseURL(ep))
+		}
 	}
+	b := &BeaconGwClient{
+		nodes:   newNodeSet(urls),
+		pinned:  -1,
+		client:  http.DefaultClient,
+		timeout: DefaultTimeout,
+		ctx:     context.Background(),
+		spec:    &specCache{},
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+func baseURL(endpoint string) string {
+	endpoint = strings.TrimSuffix(endpoint, "/")
+	if strings.HasPrefix(endpoint, "http://") || strings.HasPrefix(endpoint, "https://") {
+		return endpoint
+	}
+	return "http://" + endpoint
 }
 
-func (b *BeaconGwClient) doGet(url string) (BeaconResponse, error) {
-	resp, err := httplib.Get(url).Response()
+// Endpoint returns the base url of the beacon node the requests go to first.
+func (b *BeaconGwClient) Endpoint() string {
+	if b.pinned >= 0 {
+		return b.nodes.url(b.pinned)
+	}
+	return b.nodes.url(b.nodes.preferredIndex())
+}
+
+// WithContext returns a copy of the client whose requests are bound to ctx.
+func (b *BeaconGwClient) WithContext(ctx context.Context) *BeaconGwClient {
+	c := *b
+	c.ctx = ctx
+	return &c
+}
+
+// do sends the request to the beacon nodes in order of preference until one
+// answers, a node is skipped for transport errors and 5xx responses.
+func (b *BeaconGwClient) do(method string, path string, body interface{}) (BeaconResponse, error) {
+	return b.request(method, path, body, false)
+}
+
+func (b *BeaconGwClient) request(method string, path string, body interface{}, ssz bool) (BeaconResponse, error) {
+	var lastErr error
+	for _, i := range b.nodes.order(b.pinned) {
+		response, err := b.doNode(b.nodes.url(i), method, path, body, ssz)
+		if err == nil {
+			b.nodes.succeeded(i)
+			return response, nil
+		}
+		if !shouldFailover(err) || b.ctx.Err() != nil {
+			return response, err
+		}
+		b.nodes.failed(i, err)
+		lastErr = err
+	}
+	if lastErr == nil {
+		lastErr = ErrNoNode
+	}
+	return BeaconResponse{}, lastErr
+}
+
+// sszBody is a request body sent in ssz, the fork goes in the
+// Eth-Consensus-Version header.
+type sszBody struct {
+	fork string
+	data []byte
+}
+
+// doNode sends the request to the node at baseURL and decodes the response
+// envelope, body is encoded to json if not nil and not an sszBody. With ssz the
+// response is asked in ssz, it is left in Raw.
+func (b *BeaconGwClient) doNode(baseURL string, method string, path string, body interface{}, ssz bool) (BeaconResponse, error) {
+	ctx := b.ctx
+	if b.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, b.timeout)
+		defer cancel()
+	}
+	var reader io.Reader
+	contentType, fork := "application/json", ""
+	switch body := body.(type) {
+	case nil:
+	case sszBody:
+		reader = bytes.NewReader(body.data)
+		contentType, fork = "application/octet-stream", body.fork
+	default:
+		data, err := json.Marshal(body)
+		if err != nil {
+			return BeaconResponse{}, err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, baseURL+path, reader)
+	if err != nil {
+		return BeaconResponse{}, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if ssz {
+		req.Header.Set("Accept", "application/octet-stream")
+	}
+	if reader != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if fork != "" {
+		req.Header.Set("Eth-Consensus-Version", fork)
+	}
+	resp, err := b.client.Do(req)
 	if err != nil {
 		return BeaconResponse{}, err
 	}
 	defer resp.Body.Close()
 
-	var response BeaconResponse
-	err = json.NewDecoder(resp.Body).Decode(&response)
+	data, err := io.ReadAll(resp.Body)
 	if err != nil {
-		log.WithError(err).Error("Error decoding response")
+		return BeaconResponse{}, err
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return BeaconResponse{}, newAPIError(method, path, resp.StatusCode, data)
+	}
+	response := BeaconResponse{Raw: data, Version: resp.Header.Get("Eth-Consensus-Version")}
+	if len(data) == 0 {
+		return response, nil
+	}
+	if ssz && strings.HasPrefix(resp.Header.Get("Content-Type"), "application/octet-stream") {
+		return response, nil
+	}
+	if err := json.Unmarshal(data, &response); err != nil {
+		return BeaconResponse{}, fmt.Errorf("%s %s: decode response: %w", method, path, err)
 	}
 	return response, nil
 }
 
-func (b *BeaconGwClient) doPost(url string, data []byte) (BeaconResponse, error) {
-	resp, err := httplib.Post(url).Body(data).Response()
+// get fetches path and decodes the data of the response into out.
+func (b *BeaconGwClient) get(path string, out interface{}) (BeaconResponse, error) {
+	response, err := b.do(http.MethodGet, path, nil)
 	if err != nil {
-		return BeaconResponse{}, err
+		return response, err
 	}
-	defer resp.Body.Close()
+	return b.decodeInto(response, path, out)
+}
 
-	var response BeaconResponse
-	err = json.NewDecoder(resp.Body).Decode(&response)
-	if err != nil {
-		log.WithError(err).Error("Error decoding response")
+func (b *BeaconGwClient) post(path string, body interface{}, out interface{}) (BeaconResponse, error) {
+	response, err := b.do(http.MethodPost, path, body)
+	if err != nil || out == nil {
+		return response, err
 	}
-	return response, nil
+	return b.decodeInto(response, path, out)
 }
 
-func (b *BeaconGwClient) getBeaconConfig() (map[string]interface{}, error) {
-	response, err := b.doGet(fmt.Sprintf("http://%s/eth/v1/config/spec", b.endpoint))
+func (b *BeaconGwClient) decodeInto(response BeaconResponse, path string, out interface{}) (BeaconResponse, error) {
+	if len(response.Data) == 0 {
+		return response, fmt.Errorf("%s: no data in response", path)
+	}
+	if err := json.Unmarshal(response.Data, out); err != nil {
+		return response, fmt.Errorf("%s: decode data: %w", path, err)
+	}
+	return response, nil
+}
 
-	config := make(map[string]interface{})
-	err = json.Unmarshal(response.Data, &config)
+func (b *BeaconGwClient) GetIntConfig(key string) (int, error) {
+	config, err := b.GetBeaconConfig()
 	if err != nil {
-		log.WithError(err).Error("unmarshal config data failed")
+		return 0, err
+	}
+	v, exist := config[key]
+	if !exist {
+		return 0, fmt.Errorf("%w %s", ErrMissingConfig, key)
 	}
-	return config, nil
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("%w %s=%q", ErrInvalidConfig, key, v)
+	}
+	return n, nil
 }
 
-func (b *BeaconGwClient) GetBeaconConfig() map[string]string {
-	if len(b.config) == 0 {
-		config, err := b.getBeaconConfig()
-		if err != nil {
-			// todo: add log
-			return nil
-		}
-		b.config = make(map[string]string)
-		for key, v := range config {
-			b.config[key] = v.(string)
+// GetBeaconConfig returns the spec of the beacon node, it is fetched once.
+// Values that are not strings, like lists, are left out.
+func (b *BeaconGwClient) GetBeaconConfig() (map[string]string, error) {
+	b.spec.lock.Lock()
+	defer b.spec.lock.Unlock()
+	if len(b.spec.values) > 0 {
+		return b.spec.values, nil
+	}
+	raw := make(map[string]interface{})
+	if _, err := b.get("/eth/v1/config/spec", &raw); err != nil {
+		return nil, err
+	}
+	values := make(map[string]string, len(raw))
+	for key, v := range raw {
+		if str, ok := v.(string); ok {
+			values[key] = str
 		}
 	}
-	return b.config
+	b.spec.values = values
+	return values, nil
 }
 
-func (b *BeaconGwClient) GetLatestBeaconHeader() (BeaconHeaderInfo, error) {
-	response, err := b.doGet(fmt.Sprintf("http://%s/eth/v1/beacon/headers", b.endpoint))
-	var headers = make([]BeaconHeaderInfo, 0)
-	err = json.Unmarshal(response.Data, &headers)
+// slotsPerEpoch returns SLOTS_PER_EPOCH, an error if it isn't positive.
+func (b *BeaconGwClient) slotsPerEpoch() (int, error) {
+	n, err := b.GetIntConfig(SLOTS_PER_EPOCH)
 	if err != nil {
-		// todo: add log.
+		return 0, err
+	}
+	if n <= 0 {
+		return 0, fmt.Errorf("%w %s=%d", ErrInvalidConfig, SLOTS_PER_EPOCH, n)
+	}
+	return n, nil
+}
+
+// GetLatestBeaconHeader returns the header of the head block.
+func (b *BeaconGwClient) GetLatestBeaconHeader() (BeaconHeaderInfo, error) {
+	return b.GetBeaconHeader("head")
+}
+
+func (b *BeaconGwClient) GetGenesis() (GenesisInfo, error) {
+	var genesis GenesisInfo
+	if _, err := b.get("/eth/v1/beacon/genesis", &genesis); err != nil {
+		return GenesisInfo{}, err
+	}
+	return genesis, nil
+}
+
+// GetBeaconHeader returns the header of the block identified by blockId, which
+// is a slot, a hex root, or one of "head", "genesis" and "finalized".
+func (b *BeaconGwClient) GetBeaconHeader(blockId string) (BeaconHeaderInfo, error) {
+	var header BeaconHeaderInfo
+	if _, err := b.get("/eth/v1/beacon/headers/"+blockId, &header); err != nil {
 		return BeaconHeaderInfo{}, err
 	}
+	if header.Root == "" {
+		return BeaconHeaderInfo{}, fmt.Errorf("%w %s", ErrNoHeader, blockId)
+	}
+	return header, nil
+}
 
-	return headers[0], nil
+// GetStateValidators returns the full validator registry of the state
+// identified by stateId, like "head".
+func (b *BeaconGwClient) GetStateValidators(stateId string) ([]ValidatorState, error) {
+	var validators = make([]ValidatorState, 0)
+	if _, err := b.get(fmt.Sprintf("/eth/v1/beacon/states/%s/validators", stateId), &validators); err != nil {
+		return nil, err
+	}
+	return validators, nil
 }
 
 // default grpc-gateway port is 3500
-func (b *BeaconGwClient) GetAllValReward(epoch int) ([]TotalReward, error) {
-	url := fmt.Sprintf("http://%s/eth/v1/beacon/rewards/attestations/%d", b.endpoint, epoch)
-	response, err := b.doPost(url, []byte("[]"))
-	var rewardInfo RewardInfo
-	err = json.Unmarshal(response.Data, &rewardInfo)
+// GetFinalityCheckpoints returns the justified and finalized checkpoints of
+// the state identified by stateId, like "head".
+func (b *BeaconGwClient) GetFinalityCheckpoints(stateId string) (FinalityCheckpoints, error) {
+	var checkpoints FinalityCheckpoints
+	if _, err := b.get(fmt.Sprintf("/eth/v1/beacon/states/%s/finality_checkpoints", stateId), &checkpoints); err != nil {
+		return FinalityCheckpoints{}, err
+	}
+	return checkpoints, nil
+}
+
+// ProduceBlockSSZ asks the beacon node for an unsigned block for slot with
+// the randao reveal and the graffiti, it returns the fork of the block and
+// its ssz encoding, the block contents since deneb.
+func (b *BeaconGwClient) ProduceBlockSSZ(slot uint64, randaoReveal []byte, graffiti []byte) (string, []byte, error) {
+	query := url.Values{}
+	query.Set("randao_reveal", "0x"+hex.EncodeToString(randaoReveal))
+	if len(graffiti) > 0 {
+		var padded [32]byte
+		copy(padded[:], graffiti)
+		query.Set("graffiti", "0x"+hex.EncodeToString(padded[:]))
+	}
+	path := fmt.Sprintf("/eth/v2/validator/blocks/%d?%s", slot, query.Encode())
+	response, err := b.request(http.MethodGet, path, nil, true)
 	if err != nil {
-		log.WithError(err).Error("unmarshal reward data failed")
+		return "", nil, err
+	}
+	if response.Version == "" || len(response.Data) > 0 {
+		return "", nil, fmt.Errorf("%s: %w", path, ErrNoSSZ)
+	}
+	return response.Version, response.Raw, nil
+}
+
+// PublishBlockSSZ publishes a signed block of fork, like "capella", in ssz to
+// the beacon node, which imports it and gossips it.
+func (b *BeaconGwClient) PublishBlockSSZ(fork string, data []byte) error {
+	_, err := b.do(http.MethodPost, "/eth/v2/beacon/blocks", sszBody{fork: fork, data: data})
+	return err
+}
+
+// SubmitAttestations publishes signed attestations to the pool of the beacon
+// node, the failures of single attestations are in the APIError.
+func (b *BeaconGwClient) SubmitAttestations(atts []Attestation) error {
+	_, err := b.post("/eth/v1/beacon/pool/attestations", atts, nil)
+	return err
+}
+
+// GetBeaconHeadersBySlot returns the headers of the blocks at slot known to
+// the beacon node, canonical or not.
+func (b *BeaconGwClient) GetBeaconHeadersBySlot(slot uint64) ([]BeaconHeaderInfo, error) {
+	var headers = make([]BeaconHeaderInfo, 0)
+	if _, err := b.get(fmt.Sprintf("/eth/v1/beacon/headers?slot=%d", slot), &headers); err != nil {
 		return nil, err
 	}
-	return rewardInfo.TotalRewards, err
+	return headers, nil
 }
 
-func (b *BeaconGwClient) GetValReward(epoch int, valIdxs []int) (BeaconResponse, error) {
-	url := fmt.Sprintf("http://%s/eth/v1/beacon/rewards/attestations/%d", b.endpoint, epoch)
-	vals := make([]string, len(valIdxs))
-	for i := 0; i < len(valIdxs); i++ {
-		vals[i] = strconv.FormatInt(int64(valIdxs[i]), 10)
+// GetCommittees returns the committees of the state identified by stateId,
+// filtered by the fields set in filter.
+func (b *BeaconGwClient) GetCommittees(stateId string, filter CommitteeFilter) ([]Committee, error) {
+	query := url.Values{}
+	if filter.Epoch != nil {
+		query.Set("epoch", strconv.FormatUint(*filter.Epoch, 10))
+	}
+	if filter.Index != nil {
+		query.Set("index", strconv.FormatUint(*filter.Index, 10))
+	}
+	if filter.Slot != nil {
+		query.Set("slot", strconv.FormatUint(*filter.Slot, 10))
+	}
+	path := fmt.Sprintf("/eth/v1/beacon/states/%s/committees", stateId)
+	if len(query) > 0 {
+		path += "?" + query.Encode()
 	}
-	d, err := json.Marshal(vals)
+	var committees = make([]Committee, 0)
+	if _, err := b.get(path, &committees); err != nil {
+		return nil, err
+	}
+	return committees, nil
+}
+
+// GetForkChoice returns the fork choice store of the beacon node, the
+// response has no data envelope.
+func (b *BeaconGwClient) GetForkChoice() (ForkChoice, error) {
+	path := "/eth/v1/debug/fork_choice"
+	response, err := b.do(http.MethodGet, path, nil)
 	if err != nil {
-		log.WithError(err).Error("get reward failed when marshal vals")
-		return BeaconResponse{}, err
+		return ForkChoice{}, err
+	}
+	var forkChoice ForkChoice
+	if err := json.Unmarshal(response.Raw, &forkChoice); err != nil {
+		return ForkChoice{}, fmt.Errorf("%s: decode fork choice: %w", path, err)
 	}
-	response, err := b.doPost(url, d)
-	return response, err
+	return forkChoice, nil
+}
+
+func (b *BeaconGwClient) GetAllValReward(epoch int) ([]TotalReward, error) {
+	var rewardInfo RewardInfo
+	if _, err := b.post(fmt.Sprintf("/eth/v1/beacon/rewards/attestations/%d", epoch), ValidatorIndices{}, &rewardInfo); err != nil {
+		return nil, err
+	}
+	return rewardInfo.TotalRewards, nil
+}
+
+func (b *BeaconGwClient) GetValReward(epoch int, valIdxs []int) (BeaconResponse, error) {
+	return b.post(fmt.Sprintf("/eth/v1/beacon/rewards/attestations/%d", epoch), NewValidatorIndices(valIdxs), nil)
 }
 
 // /eth/v1/validator/duties/proposer/:epoch
 func (b *BeaconGwClient) GetProposerDuties(epoch int) ([]ProposerDuty, error) {
-	url := fmt.Sprintf("http://%s/eth/v1/validator/duties/proposer/%d", b.endpoint, epoch)
-	var duties = make([]ProposerDuty, 0)
-
-	response, err := b.doGet(url)
-	err = json.Unmarshal(response.Data, &duties)
+	duties, err := b.GetEpochProposerDuties(epoch)
 	if err != nil {
 		return []ProposerDuty{}, err
 	}
+	return duties.Duties, nil
+}
 
-	return duties, err
+// GetEpochProposerDuties returns the proposer duties of epoch with their
+// dependent root.
+func (b *BeaconGwClient) GetEpochProposerDuties(epoch int) (ProposerDuties, error) {
+	var duties ProposerDuties
+	response, err := b.get(fmt.Sprintf("/eth/v1/validator/duties/proposer/%d", epoch), &duties.Duties)
+	if err != nil {
+		return ProposerDuties{}, err
+	}
+	duties.DependentRoot = response.DependentRoot
+	return duties, nil
 }
 
 // POST /eth/v1/validator/duties/attester/:epoch
 func (b *BeaconGwClient) GetAttesterDuties(epoch int, vals []int) ([]AttestDuty, error) {
-	url := fmt.Sprintf("http://%s/eth/v1/validator/duties/attester/%d", b.endpoint, epoch)
-	param := make([]string, len(vals))
-	for i := 0; i < len(vals); i++ {
-		param[i] = strconv.FormatInt(int64(vals[i]), 10)
-	}
-	paramData, _ := json.Marshal(param)
-	var duties = make([]AttestDuty, 0)
-
-	response, err := b.doPost(url, paramData)
-	err = json.Unmarshal(response.Data, &duties)
+	duties, err := b.GetEpochAttesterDuties(epoch, vals)
 	if err != nil {
 		return []AttestDuty{}, err
 	}
-	return duties, err
+	return duties.Duties, nil
 }
 
-func (b *BeaconGwClient) GetNextEpochProposerDuties() ([]ProposerDuty, error) {
-	latestHeader, err := b.GetLatestBeaconHeader()
+// GetEpochAttesterDuties returns the attester duties of vals in epoch with
+// their dependent root.
+func (b *BeaconGwClient) GetEpochAttesterDuties(epoch int, vals []int) (AttesterDuties, error) {
+	var duties AttesterDuties
+	response, err := b.post(fmt.Sprintf("/eth/v1/validator/duties/attester/%d", epoch), NewValidatorIndices(vals), &duties.Duties)
 	if err != nil {
-		return nil, err
+		return AttesterDuties{}, err
 	}
-	slotPerEpoch, _ := b.GetIntConfig(SLOTS_PER_EPOCH)
-	curSlot, _ := strconv.Atoi(latestHeader.Header.Message.Slot)
-	epoch := curSlot / slotPerEpoch
-	return b.GetProposerDuties(epoch + 1)
+	duties.DependentRoot = response.DependentRoot
+	return duties, nil
 }
 
-func (b *BeaconGwClient) GetCurrentEpochProposerDuties() ([]ProposerDuty, error) {
-	latestHeader, err := b.GetLatestBeaconHeader()
-	if err != nil {
+// POST /eth/v1/validator/duties/sync/:epoch
+func (b *BeaconGwClient) GetSyncCommitteeDuties(epoch int, vals []int) ([]SyncCommitteeDuty, error) {
+	var duties = make([]SyncCommitteeDuty, 0)
+	if _, err := b.post(fmt.Sprintf("/eth/v1/validator/duties/sync/%d", epoch), NewValidatorIndices(vals), &duties); err != nil {
 		return nil, err
 	}
-	slotPerEpoch, _ := b.GetIntConfig(SLOTS_PER_EPOCH)
-	curSlot, _ := strconv.Atoi(latestHeader.Header.Message.Slot)
-	epoch := curSlot / slotPerEpoch
-	return b.GetProposerDuties(epoch)
+	return duties, nil
 }
 
-func (b *BeaconGwClient) GetCurrentEpochAttestDuties() ([]AttestDuty, error) {
+// headEpoch returns the epoch of the head block.
+func (b *BeaconGwClient) headEpoch() (int, error) {
 	latestHeader, err := b.GetLatestBeaconHeader()
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
-	slotPerEpoch, _ := b.GetIntConfig(SLOTS_PER_EPOCH)
-	curSlot, _ := strconv.Atoi(latestHeader.Header.Message.Slot)
-	epoch := curSlot / slotPerEpoch
-	vals := make([]int, 64)
-	for i := 0; i < len(vals); i++ {
-		vals[i] = i
+	slotPerEpoch, err := b.slotsPerEpoch()
+	if err != nil {
+		return 0, err
+	}
+	curSlot, err := strconv.Atoi(latestHeader.Header.Message.Slot)
+	if err != nil {
+		return 0, fmt.Errorf("invalid head slot %q", latestHeader.Header.Message.Slot)
 	}
-	return b.GetAttesterDuties(epoch, vals)
+	return curSlot / slotPerEpoch, nil
 }
 
-func (b *BeaconGwClient) GetNextEpochAttestDuties() ([]AttestDuty, error) {
-	latestHeader, err := b.GetLatestBeaconHeader()
+func (b *BeaconGwClient) GetNextEpochProposerDuties() ([]ProposerDuty, error) {
+	epoch, err := b.headEpoch()
 	if err != nil {
 		return nil, err
 	}
-	slotPerEpoch, _ := b.GetIntConfig(SLOTS_PER_EPOCH)
-	curSlot, _ := strconv.Atoi(latestHeader.Header.Message.Slot)
-	epoch := curSlot / slotPerEpoch
-	vals := make([]int, 64)
-	for i := 0; i < len(vals); i++ {
-		vals[i] = i
+	return b.GetProposerDuties(epoch + 1)
+}
+
+func (b *BeaconGwClient) GetCurrentEpochProposerDuties() ([]ProposerDuty, error) {
+	epoch, err := b.headEpoch()
+	if err != nil {
+		return nil, err
 	}
-	return b.GetAttesterDuties(epoch+1, vals)
+	return b.GetProposerDuties(epoch)
 }
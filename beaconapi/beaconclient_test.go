@@ -1,10 +1,18 @@
 package beaconapi
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"strconv"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	ethpb "github.com/prysmaticlabs/prysm/v4/proto/prysm/v1alpha1"
 )
 
 // test GetValidators
@@ -25,7 +33,7 @@ func TestGetReward(t *testing.T) {
 	if err != nil {
 		t.Fatalf("get reward failed err:%s", err)
 	}
-	fmt.Printf("get specific reward res:%s\n", res)
+	fmt.Printf("get specific reward res:%s\n", string(res.Data))
 }
 
 func TestGetAllReward(t *testing.T) {
@@ -86,3 +94,265 @@ func TestGetAllAttestDuties(t *testing.T) {
 		fmt.Printf("get attest duty :%s\n", string(d))
 	}
 }
+
+func fakeBeacon(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/eth/v1/config/spec", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":{"SLOTS_PER_EPOCH":"4","SECONDS_PER_SLOT":"x","FORKS":[1]}}`))
+	})
+	mux.HandleFunc("/eth/v1/beacon/headers/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"code":404,"message":"block not found"}`))
+	})
+	mux.HandleFunc("/eth/v1/validator/duties/proposer/1", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"dependent_root":"0xaa","data":[{"pubkey":"0x01","validator_index":"3","slot":"5"}]}`))
+	})
+	mux.HandleFunc("/eth/v1/validator/duties/attester/1", func(w http.ResponseWriter, r *http.Request) {
+		var vals ValidatorIndices
+		if err := json.NewDecoder(r.Body).Decode(&vals); err != nil || len(vals) != 2 || vals[1] != "7" {
+			t.Errorf("attester duties body: got %v, %v", vals, err)
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte("syncing"))
+	})
+	mux.HandleFunc("/eth/v1/beacon/genesis", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	})
+	return httptest.NewTLSServer(mux)
+}
+
+func TestClientErrors(t *testing.T) {
+	srv := fakeBeacon(t)
+	defer srv.Close()
+	client := NewBeaconGwClient(srv.URL+"/", WithHTTPClient(srv.Client()), WithTimeout(50*time.Millisecond))
+
+	if n, err := client.GetIntConfig(SLOTS_PER_EPOCH); err != nil || n != 4 {
+		t.Fatalf("slots per epoch: got %d, %v", n, err)
+	}
+	if _, err := client.GetIntConfig("SHARD_COUNT"); !errors.Is(err, ErrMissingConfig) {
+		t.Fatalf("missing key: got %v", err)
+	}
+	if _, err := client.GetIntConfig(SECONDS_PER_SLOT); !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("invalid value: got %v", err)
+	}
+
+	_, err := client.GetLatestBeaconHeader()
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || !IsNotFound(err) || apiErr.Message != "block not found" || apiErr.Path != "/eth/v1/beacon/headers/head" {
+		t.Fatalf("unknown header: got %v", err)
+	}
+
+	duties, err := client.GetEpochProposerDuties(1)
+	if err != nil || duties.DependentRoot != "0xaa" || len(duties.Duties) != 1 || duties.Duties[0].Slot != "5" {
+		t.Fatalf("proposer duties: got %+v, %v", duties, err)
+	}
+	if _, err := client.GetAttesterDuties(1, []int{2, 7}); !IsUnavailable(err) || !errors.As(err, &apiErr) || apiErr.Message != "syncing" {
+		t.Fatalf("attester duties: got %v", err)
+	}
+
+	if _, err := client.GetGenesis(); err == nil || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("genesis timeout: got %v", err)
+	}
+}
+
+func TestEventStream(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("topics") != "head,chain_reorg" {
+			t.Errorf("topics: got %q", r.URL.RawQuery)
+		}
+		// the first connection fails, the stream reconnects.
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte(": keep-alive\n\n"))
+		w.Write([]byte("event: head\ndata: {\"slot\":\"9\",\"epoch_transition\":true,\"current_duty_dependent_root\":\"0xaa\"}\n\n"))
+		w.Write([]byte("event: chain_reorg\ndata: {\"slot\":\"9\",\"depth\":\"2\"}\n\n"))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	stream := NewEventStream(NewBeaconGwClient(srv.URL), TopicHead, TopicChainReorg)
+	stream.minBackoff = 10 * time.Millisecond
+	heads := stream.Subscribe(4, TopicHead)
+	all := stream.Subscribe(4)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go stream.Run(ctx)
+
+	next := func(sub *Subscription) Event {
+		select {
+		case ev := <-sub.C:
+			return ev
+		case <-time.After(2 * time.Second):
+			t.Fatal("no event")
+			return Event{}
+		}
+	}
+	if ev := next(heads); ev.Topic != TopicHead || ev.Data.(*HeadEvent).CurrentDutyDependentRoot != "0xaa" {
+		t.Fatalf("head event: got %+v", ev)
+	}
+	if ev := next(all); ev.Topic != TopicHead {
+		t.Fatalf("first event: got %+v", ev)
+	}
+	if ev := next(all); ev.Topic != TopicChainReorg || ev.Data.(*ChainReorgEvent).Depth != "2" {
+		t.Fatalf("reorg event: got %+v", ev)
+	}
+	if len(heads.C) != 0 || !stream.Connected() {
+		t.Fatalf("head subscriber got %d more events, connected %v", len(heads.C), stream.Connected())
+	}
+}
+
+func fakeNode(slot string, root string, finalized string, down *atomic.Bool) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/eth/v1/beacon/headers/head", func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprintf(w, `{"data":{"root":"%s","header":{"message":{"slot":"%s"}}}}`, root, slot)
+	})
+	mux.HandleFunc("/eth/v1/beacon/states/head/finality_checkpoints", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"data":{"finalized":{"epoch":"%s","root":"0x00"}}}`, finalized)
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestFailover(t *testing.T) {
+	var downA, downB, downC atomic.Bool
+	a := fakeNode("10", "0xaa", "1", &downA)
+	defer a.Close()
+	b := fakeNode("20", "0xbb", "2", &downB)
+	defer b.Close()
+	c := fakeNode("20", "0xcc", "2", &downC)
+	defer c.Close()
+	client := NewBeaconGwClient(a.URL + ", " + b.URL + "," + c.URL)
+
+	downA.Store(true)
+	header, err := client.GetLatestBeaconHeader()
+	if err != nil || header.Root != "0xbb" {
+		t.Fatalf("failover: got %+v, %v", header, err)
+	}
+	if nodes := client.Nodes(); nodes[0].Failures != 1 || !nodes[1].Preferred {
+		t.Fatalf("nodes after failover: %+v", nodes)
+	}
+	view, err := client.View(a.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := view.GetLatestBeaconHeader(); err == nil {
+		t.Fatalf("pinned view failed over")
+	}
+	if _, err := client.View("http://127.0.0.1:1"); !errors.Is(err, ErrUnknownNode) {
+		t.Fatalf("unknown node: got %v", err)
+	}
+
+	// node a is back but 10 slots behind, b and c see different heads.
+	downA.Store(false)
+	nodes := client.CheckNodes()
+	if !nodes[0].Behind || nodes[1].Behind || nodes[0].HeadSlot != 10 {
+		t.Fatalf("check nodes: %+v", nodes)
+	}
+	if reasons := Divergence(nodes); len(reasons) != 3 {
+		t.Fatalf("divergence: got %q", reasons)
+	}
+	downB.Store(true)
+	if header, err := client.GetLatestBeaconHeader(); err != nil || header.Root != "0xcc" {
+		t.Fatalf("failover skips the node behind: got %+v, %v", header, err)
+	}
+	if view, _ := client.View("0"); view.Endpoint() != a.URL {
+		t.Fatalf("view by index: got %s", view.Endpoint())
+	}
+}
+
+func TestChainState(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/eth/v1/debug/fork_choice", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"justified_checkpoint":{"epoch":"2","root":"0x02"},"finalized_checkpoint":{"epoch":"1","root":"0x01"},
+			"fork_choice_nodes":[
+				{"slot":"8","block_root":"0xa8","parent_root":"0x02","weight":"100"},
+				{"slot":"9","block_root":"0xa9","parent_root":"0xa8","weight":"40"},
+				{"slot":"10","block_root":"0xb10","parent_root":"0xa8","weight":"60"}],
+			"extra_data":{"proposer_boost_root":"0xb10","previous_proposer_boost_root":"0x0000","head_root":"0xb10"}}`))
+	})
+	mux.HandleFunc("/eth/v1/beacon/states/head/committees", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.RawQuery != "epoch=2&slot=9" {
+			t.Errorf("committees query: got %q", r.URL.RawQuery)
+		}
+		w.Write([]byte(`{"data":[{"index":"0","slot":"9","validators":["3","7"]}]}`))
+	})
+	mux.HandleFunc("/eth/v1/beacon/headers", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":[{"root":"0xa9","canonical":false},{"root":"0xb9","canonical":true}]}`))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	client := NewBeaconGwClient(srv.URL)
+
+	fc, err := client.GetForkChoice()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fc.JustifiedCheckpoint.Epoch != "2" || fc.ProposerBoostRoot() != "0xb10" || fc.HeadRoot() != "0xb10" {
+		t.Fatalf("fork choice: got %+v", fc)
+	}
+	if children := fc.Children("0xa8"); len(children) != 2 || children[1].WeightGwei() != 60 {
+		t.Fatalf("children of 0xa8: got %+v", children)
+	}
+	if n, ok := fc.Node("0xa9"); !ok || n.Slot != "9" {
+		t.Fatalf("node 0xa9: got %+v, %v", n, ok)
+	}
+
+	epoch, slot := uint64(2), uint64(9)
+	committees, err := client.GetCommittees("head", CommitteeFilter{Epoch: &epoch, Slot: &slot})
+	if err != nil || len(committees) != 1 || committees[0].Validators[1] != "7" {
+		t.Fatalf("committees: got %+v, %v", committees, err)
+	}
+	headers, err := client.GetBeaconHeadersBySlot(9)
+	if err != nil || len(headers) != 2 || !headers[1].Canonical {
+		t.Fatalf("headers at slot 9: got %+v, %v", headers, err)
+	}
+}
+
+func TestSubmitAttestations(t *testing.T) {
+	var got []Attestation
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/eth/v1/beacon/pool/attestations" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Error(err)
+		}
+		if len(got) > 1 {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"code":400,"message":"some failed","failures":[{"index":1,"message":"invalid signature"}]}`))
+		}
+	}))
+	defer srv.Close()
+	client := NewBeaconGwClient(srv.URL)
+
+	att := &ethpb.Attestation{
+		AggregationBits: []byte{0x05},
+		Data: &ethpb.AttestationData{
+			Slot:            9,
+			CommitteeIndex:  1,
+			BeaconBlockRoot: []byte{0xaa},
+			Source:          &ethpb.Checkpoint{Epoch: 1, Root: []byte{0x01}},
+			Target:          &ethpb.Checkpoint{Epoch: 2, Root: []byte{0x02}},
+		},
+		Signature: []byte{0xff},
+	}
+	if err := client.SubmitAttestations([]Attestation{NewAttestation(att)}); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].AggregationBits != "0x05" || got[0].Data.Index != "1" || got[0].Data.Target.Root != "0x02" {
+		t.Fatalf("submitted: got %+v", got)
+	}
+	err := client.SubmitAttestations([]Attestation{NewAttestation(att), NewAttestation(att)})
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || len(apiErr.Failures) != 1 || apiErr.Failures[0].Index != 1 {
+		t.Fatalf("failures: got %v", err)
+	}
+}
@@ -0,0 +1,88 @@
+package beaconapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+var (
+	ErrMissingConfig = errors.New("missing spec value")
+	ErrInvalidConfig = errors.New("invalid spec value")
+	ErrNoHeader      = errors.New("no block header")
+	ErrNoNode        = errors.New("no beacon node")
+	ErrUnknownNode   = errors.New("unknown beacon node")
+	ErrNoSSZ         = errors.New("beacon node didn't answer in ssz")
+)
+
+// IndexedError is the failure of one item of a request with a list, like a
+// pool submission.
+type IndexedError struct {
+	Index   int    `json:"index"`
+	Message string `json:"message"`
+}
+
+// APIError is the error envelope of the Beacon API, returned for every
+// response with a status code of 400 or above.
+type APIError struct {
+	StatusCode  int            `json:"-"`
+	Method      string         `json:"-"`
+	Path        string         `json:"-"`
+	Code        int            `json:"code"`
+	Message     string         `json:"message"`
+	Stacktraces []string       `json:"stacktraces,omitempty"`
+	Failures    []IndexedError `json:"failures,omitempty"`
+}
+
+func (e *APIError) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = http.StatusText(e.StatusCode)
+	}
+	if len(e.Failures) > 0 {
+		return fmt.Sprintf("%s %s: %d %s (%d failures, first: %s)", e.Method, e.Path, e.StatusCode, msg, len(e.Failures), e.Failures[0].Message)
+	}
+	return fmt.Sprintf("%s %s: %d %s", e.Method, e.Path, e.StatusCode, msg)
+}
+
+func newAPIError(method string, path string, status int, body []byte) *APIError {
+	apiErr := &APIError{
+		StatusCode: status,
+		Method:     method,
+		Path:       path,
+	}
+	// the envelope is optional, keep the status if it doesn't parse.
+	if json.Unmarshal(body, apiErr) != nil {
+		apiErr.Message = strings.TrimSpace(string(body))
+	}
+	return apiErr
+}
+
+// IsNotFound returns true if err is an APIError with status 404, eg. an
+// unknown block or state.
+func IsNotFound(err error) bool {
+	return hasStatus(err, http.StatusNotFound)
+}
+
+// IsUnavailable returns true if err is an APIError with status 503, the
+// beacon node is syncing.
+func IsUnavailable(err error) bool {
+	return hasStatus(err, http.StatusServiceUnavailable)
+}
+
+// shouldFailover returns true if the request should be sent to another
+// beacon node, the node is unreachable or failed to answer.
+func shouldFailover(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode >= http.StatusInternalServerError
+	}
+	return true
+}
+
+func hasStatus(err error, status int) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == status
+}
@@ -0,0 +1,291 @@
+package beaconapi
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// topics of /eth/v1/events.
+const (
+	TopicHead                = "head"
+	TopicBlock               = "block"
+	TopicAttestation         = "attestation"
+	TopicChainReorg          = "chain_reorg"
+	TopicFinalizedCheckpoint = "finalized_checkpoint"
+	TopicVoluntaryExit       = "voluntary_exit"
+)
+
+// AllTopics are the topics an EventStream subscribes to by default.
+var AllTopics = []string{
+	TopicHead,
+	TopicBlock,
+	TopicAttestation,
+	TopicChainReorg,
+	TopicFinalizedCheckpoint,
+	TopicVoluntaryExit,
+}
+
+type HeadEvent struct {
+	Slot                      string `json:"slot"`
+	Block                     string `json:"block"`
+	State                     string `json:"state"`
+	EpochTransition           bool   `json:"epoch_transition"`
+	PreviousDutyDependentRoot string `json:"previous_duty_dependent_root"`
+	CurrentDutyDependentRoot  string `json:"current_duty_dependent_root"`
+	ExecutionOptimistic       bool   `json:"execution_optimistic"`
+}
+
+type BlockEvent struct {
+	Slot                string `json:"slot"`
+	Block               string `json:"block"`
+	ExecutionOptimistic bool   `json:"execution_optimistic"`
+}
+
+type AttestationEvent Attestation
+
+type ChainReorgEvent struct {
+	Slot                string `json:"slot"`
+	Depth               string `json:"depth"`
+	OldHeadBlock        string `json:"old_head_block"`
+	NewHeadBlock        string `json:"new_head_block"`
+	OldHeadState        string `json:"old_head_state"`
+	NewHeadState        string `json:"new_head_state"`
+	Epoch               string `json:"epoch"`
+	ExecutionOptimistic bool   `json:"execution_optimistic"`
+}
+
+type FinalizedCheckpointEvent struct {
+	Block               string `json:"block"`
+	State               string `json:"state"`
+	Epoch               string `json:"epoch"`
+	ExecutionOptimistic bool   `json:"execution_optimistic"`
+}
+
+type VoluntaryExitEvent struct {
+	Message struct {
+		Epoch          string `json:"epoch"`
+		ValidatorIndex string `json:"validator_index"`
+	} `json:"message"`
+	Signature string `json:"signature"`
+}
+
+// Event is an event of the beacon node, Data is a pointer to the event type
+// of the topic, like *HeadEvent for "head".
+type Event struct {
+	Topic    string
+	Data     interface{}
+	Received time.Time
+}
+
+func decodeEvent(topic string, data []byte) (Event, error) {
+	var v interface{}
+	switch topic {
+	case TopicHead:
+		v = new(HeadEvent)
+	case TopicBlock:
+		v = new(BlockEvent)
+	case TopicAttestation:
+		v = new(AttestationEvent)
+	case TopicChainReorg:
+		v = new(ChainReorgEvent)
+	case TopicFinalizedCheckpoint:
+		v = new(FinalizedCheckpointEvent)
+	case TopicVoluntaryExit:
+		v = new(VoluntaryExitEvent)
+	default:
+		return Event{}, fmt.Errorf("unknown event topic %q", topic)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return Event{}, fmt.Errorf("decode %s event: %w", topic, err)
+	}
+	return Event{Topic: topic, Data: v, Received: time.Now()}, nil
+}
+
+// Subscription receives the events of its topics on C. Events are dropped
+// when C is full, a subscriber must not block for long.
+type Subscription struct {
+	C       <-chan Event
+	ch      chan Event
+	topics  map[string]bool
+	dropped atomic.Uint64
+	stream  *EventStream
+}
+
+// Dropped returns the number of events dropped because C was full.
+func (s *Subscription) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Unsubscribe stops the delivery of the events, C is not closed.
+func (s *Subscription) Unsubscribe() {
+	s.stream.lock.Lock()
+	defer s.stream.lock.Unlock()
+	delete(s.stream.subs, s)
+}
+
+// EventStream consumes /eth/v1/events of the beacon node and fans the events
+// out to the subscriptions. It reconnects with an exponential backoff when
+// the stream breaks.
+type EventStream struct {
+	client     *BeaconGwClient
+	topics     []string
+	minBackoff time.Duration
+	maxBackoff time.Duration
+	connected  atomic.Bool
+
+	subs map[*Subscription]struct{}
+	lock sync.Mutex
+}
+
+func NewEventStream(client *BeaconGwClient, topics ...string) *EventStream {
+	if len(topics) == 0 {
+		topics = AllTopics
+	}
+	return &EventStream{
+		client:     client,
+		topics:     topics,
+		minBackoff: time.Second,
+		maxBackoff: time.Second * 30,
+		subs:       make(map[*Subscription]struct{}),
+	}
+}
+
+// Subscribe returns a subscription to topics, all topics of the stream if
+// none, with a buffer of size events.
+func (e *EventStream) Subscribe(size int, topics ...string) *Subscription {
+	ch := make(chan Event, size)
+	sub := &Subscription{
+		C:      ch,
+		ch:     ch,
+		topics: make(map[string]bool),
+		stream: e,
+	}
+	for _, t := range topics {
+		sub.topics[t] = true
+	}
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.subs[sub] = struct{}{}
+	return sub
+}
+
+// Connected returns true while the stream is connected to the beacon node.
+func (e *EventStream) Connected() bool {
+	return e.connected.Load()
+}
+
+func (e *EventStream) publish(ev Event) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	for sub := range e.subs {
+		if len(sub.topics) > 0 && !sub.topics[ev.Topic] {
+			continue
+		}
+		select {
+		case sub.ch <- ev:
+		default:
+			if sub.dropped.Add(1)%100 == 1 {
+				log.WithFields(log.Fields{
+					"topic":   ev.Topic,
+					"dropped": sub.dropped.Load(),
+				}).Warn("event subscriber is slow, events dropped")
+			}
+		}
+	}
+}
+
+// Run consumes the stream until ctx is done.
+func (e *EventStream) Run(ctx context.Context) {
+	backoff := e.minBackoff
+	for {
+		start := time.Now()
+		err := e.consume(ctx)
+		e.connected.Store(false)
+		if ctx.Err() != nil {
+			return
+		}
+		// a stream that lived for a while was healthy, start over.
+		if time.Since(start) > e.maxBackoff {
+			backoff = e.minBackoff
+		}
+		log.WithError(err).WithField("retry", backoff).Warn("beacon event stream broken")
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > e.maxBackoff {
+			backoff = e.maxBackoff
+		}
+	}
+}
+
+// consume reads the stream until it breaks, it doesn't use the timeout of
+// the client.
+func (e *EventStream) consume(ctx context.Context) error {
+	path := "/eth/v1/events?topics=" + strings.Join(e.topics, ",")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, e.client.Endpoint()+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	resp, err := e.client.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(resp.Body)
+		return newAPIError(http.MethodGet, path, resp.StatusCode, data)
+	}
+	e.connected.Store(true)
+	log.WithField("topics", e.topics).Info("beacon event stream connected")
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	var (
+		topic string
+		data  bytes.Buffer
+	)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			// end of an event.
+			if topic != "" && data.Len() > 0 {
+				if ev, err := decodeEvent(topic, data.Bytes()); err != nil {
+					log.WithError(err).Debug("skip beacon event")
+				} else {
+					e.publish(ev)
+				}
+			}
+			topic = ""
+			data.Reset()
+		case strings.HasPrefix(line, ":"):
+			// comment, used as keep-alive.
+		case strings.HasPrefix(line, "event:"):
+			topic = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return io.EOF
+}
@@ -0,0 +1,230 @@
+package beaconapi
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultMaxLag is the number of slots a beacon node may be behind the best
+// node before the requests avoid it.
+const DefaultMaxLag = 2
+
+// NodeStatus is the view of a beacon node from the last CheckNodes.
+type NodeStatus struct {
+	Endpoint  string     `json:"endpoint"`
+	Preferred bool       `json:"preferred"`
+	Behind    bool       `json:"behind"` // more than the max lag behind the best node
+	HeadSlot  uint64     `json:"head_slot"`
+	HeadRoot  string     `json:"head_root"`
+	Justified Checkpoint `json:"justified"`
+	Finalized Checkpoint `json:"finalized"`
+	Failures  int        `json:"failures"` // failed requests since the last success
+	Error     string     `json:"error,omitempty"`
+}
+
+type nodeSet struct {
+	status    []NodeStatus
+	preferred int
+	maxLag    uint64
+	lock      sync.Mutex
+}
+
+func newNodeSet(urls []string) *nodeSet {
+	ns := &nodeSet{maxLag: DefaultMaxLag}
+	for _, u := range urls {
+		ns.status = append(ns.status, NodeStatus{Endpoint: u})
+	}
+	return ns
+}
+
+// WithMaxLag sets the number of slots a node may be behind the best node.
+func WithMaxLag(slots uint64) Option {
+	return func(b *BeaconGwClient) {
+		b.nodes.maxLag = slots
+	}
+}
+
+func (ns *nodeSet) url(i int) string {
+	ns.lock.Lock()
+	defer ns.lock.Unlock()
+	if i < 0 || i >= len(ns.status) {
+		return ""
+	}
+	return ns.status[i].Endpoint
+}
+
+func (ns *nodeSet) preferredIndex() int {
+	ns.lock.Lock()
+	defer ns.lock.Unlock()
+	return ns.preferred
+}
+
+// order returns the nodes to ask in turn, the preferred node first and the
+// nodes behind last, or only the pinned node.
+func (ns *nodeSet) order(pinned int) []int {
+	ns.lock.Lock()
+	defer ns.lock.Unlock()
+	if pinned >= 0 {
+		return []int{pinned}
+	}
+	order := make([]int, 0, len(ns.status))
+	var behind []int
+	for k := range ns.status {
+		i := (ns.preferred + k) % len(ns.status)
+		if ns.status[i].Behind && i != ns.preferred {
+			behind = append(behind, i)
+		} else {
+			order = append(order, i)
+		}
+	}
+	return append(order, behind...)
+}
+
+func (ns *nodeSet) succeeded(i int) {
+	ns.lock.Lock()
+	defer ns.lock.Unlock()
+	ns.status[i].Failures = 0
+	ns.status[i].Error = ""
+}
+
+// failed records a failed request, the next node becomes the preferred one
+// if node i was.
+func (ns *nodeSet) failed(i int, err error) {
+	ns.lock.Lock()
+	defer ns.lock.Unlock()
+	ns.status[i].Failures++
+	ns.status[i].Error = err.Error()
+	if i != ns.preferred || len(ns.status) == 1 {
+		return
+	}
+	ns.preferred = (i + 1) % len(ns.status)
+	log.WithError(err).WithFields(log.Fields{
+		"failed": ns.status[i].Endpoint,
+		"now":    ns.status[ns.preferred].Endpoint,
+	}).Warn("beacon node failed, switch to the next one")
+}
+
+// Nodes returns the status of the beacon nodes.
+func (b *BeaconGwClient) Nodes() []NodeStatus {
+	b.nodes.lock.Lock()
+	defer b.nodes.lock.Unlock()
+	list := make([]NodeStatus, len(b.nodes.status))
+	copy(list, b.nodes.status)
+	for i := range list {
+		list[i].Preferred = i == b.nodes.preferred
+	}
+	return list
+}
+
+// View returns a copy of the client whose requests only go to the beacon
+// node endpoint, given as in the config or as its index, to query the chain
+// as this node sees it.
+func (b *BeaconGwClient) View(endpoint string) (*BeaconGwClient, error) {
+	b.nodes.lock.Lock()
+	defer b.nodes.lock.Unlock()
+	pinned := -1
+	if i, err := strconv.Atoi(endpoint); err == nil && i >= 0 && i < len(b.nodes.status) {
+		pinned = i
+	}
+	for i, st := range b.nodes.status {
+		if st.Endpoint == baseURL(endpoint) {
+			pinned = i
+		}
+	}
+	if pinned < 0 {
+		return nil, fmt.Errorf("%w %s", ErrUnknownNode, endpoint)
+	}
+	c := *b
+	c.pinned = pinned
+	return &c, nil
+}
+
+// CheckNodes asks every beacon node for its head and finality checkpoints.
+// The nodes more than the max lag behind the best head are asked last, and
+// the best node becomes the preferred one if the preferred node is behind.
+func (b *BeaconGwClient) CheckNodes() []NodeStatus {
+	n := len(b.Nodes())
+	views := make([]NodeStatus, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			c := *b
+			c.pinned = i
+			views[i] = c.nodeView()
+		}(i)
+	}
+	wg.Wait()
+
+	b.nodes.lock.Lock()
+	var best uint64
+	bestNode := -1
+	for i := range views {
+		if views[i].Error == "" && (bestNode < 0 || views[i].HeadSlot > best) {
+			best, bestNode = views[i].HeadSlot, i
+		}
+	}
+	for i := range views {
+		st := &b.nodes.status[i]
+		failures := st.Failures // counted by the requests of nodeView
+		*st = views[i]
+		st.Failures = failures
+		st.Behind = st.Error != "" || st.HeadSlot+b.nodes.maxLag < best
+	}
+	if bestNode >= 0 && b.nodes.status[b.nodes.preferred].Behind {
+		log.WithFields(log.Fields{
+			"behind": b.nodes.status[b.nodes.preferred].Endpoint,
+			"now":    b.nodes.status[bestNode].Endpoint,
+		}).Warn("beacon node is behind, switch to the best one")
+		b.nodes.preferred = bestNode
+	}
+	b.nodes.lock.Unlock()
+	return b.Nodes()
+}
+
+func (b *BeaconGwClient) nodeView() NodeStatus {
+	st := NodeStatus{Endpoint: b.Endpoint()}
+	header, err := b.GetBeaconHeader("head")
+	if err != nil {
+		st.Error = err.Error()
+		return st
+	}
+	st.HeadSlot, _ = strconv.ParseUint(header.Header.Message.Slot, 10, 64)
+	st.HeadRoot = header.Root
+	checkpoints, err := b.GetFinalityCheckpoints("head")
+	if err != nil {
+		st.Error = err.Error()
+		return st
+	}
+	st.Justified = checkpoints.CurrentJustified
+	st.Finalized = checkpoints.Finalized
+	return st
+}
+
+// Divergence describes how the reachable beacon nodes disagree, it is empty
+// if they agree on the head and the finalized checkpoint. Heads are compared
+// between nodes at the same slot only.
+func Divergence(nodes []NodeStatus) []string {
+	var reasons []string
+	for i := range nodes {
+		for j := i + 1; j < len(nodes); j++ {
+			a, b := &nodes[i], &nodes[j]
+			if a.Error != "" || b.Error != "" {
+				continue
+			}
+			if a.HeadSlot == b.HeadSlot && a.HeadRoot != b.HeadRoot {
+				reasons = append(reasons, fmt.Sprintf("head at slot %d: %s has %s, %s has %s",
+					a.HeadSlot, a.Endpoint, a.HeadRoot, b.Endpoint, b.HeadRoot))
+			}
+			if a.Finalized != b.Finalized {
+				reasons = append(reasons, fmt.Sprintf("finalized: %s has %s/%s, %s has %s/%s",
+					a.Endpoint, a.Finalized.Epoch, a.Finalized.Root, b.Endpoint, b.Finalized.Epoch, b.Finalized.Root))
+			}
+		}
+	}
+	return reasons
+}
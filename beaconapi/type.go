@@ -1,6 +1,24 @@
 package beaconapi
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethpb "github.com/prysmaticlabs/prysm/v4/proto/prysm/v1alpha1"
+)
+
+// ValidatorIndices is the body of the requests for a list of validators.
+type ValidatorIndices []string
+
+func NewValidatorIndices(vals []int) ValidatorIndices {
+	list := make(ValidatorIndices, len(vals))
+	for i, v := range vals {
+		list[i] = strconv.Itoa(v)
+	}
+	return list
+}
 
 type TotalReward struct {
 	ValidatorIndex string `json:"validator_index"`
@@ -15,6 +33,58 @@ type RewardInfo struct {
 	TotalRewards []TotalReward `json:"total_rewards"`
 }
 
+type GenesisInfo struct {
+	GenesisTime           string `json:"genesis_time"`
+	GenesisValidatorsRoot string `json:"genesis_validators_root"`
+	GenesisForkVersion    string `json:"genesis_fork_version"`
+}
+
+type Checkpoint struct {
+	Epoch string `json:"epoch"`
+	Root  string `json:"root"`
+}
+
+type AttestationData struct {
+	Slot            string     `json:"slot"`
+	Index           string     `json:"index"`
+	BeaconBlockRoot string     `json:"beacon_block_root"`
+	Source          Checkpoint `json:"source"`
+	Target          Checkpoint `json:"target"`
+}
+
+type Attestation struct {
+	AggregationBits string          `json:"aggregation_bits"`
+	Data            AttestationData `json:"data"`
+	Signature       string          `json:"signature"`
+}
+
+// NewAttestation converts a signed attestation to its Beacon API form.
+func NewAttestation(att *ethpb.Attestation) Attestation {
+	checkpoint := func(c *ethpb.Checkpoint) Checkpoint {
+		return Checkpoint{
+			Epoch: strconv.FormatUint(uint64(c.Epoch), 10),
+			Root:  hexutil.Encode(c.Root),
+		}
+	}
+	return Attestation{
+		AggregationBits: hexutil.Encode(att.AggregationBits),
+		Data: AttestationData{
+			Slot:            strconv.FormatUint(uint64(att.Data.Slot), 10),
+			Index:           strconv.FormatUint(uint64(att.Data.CommitteeIndex), 10),
+			BeaconBlockRoot: hexutil.Encode(att.Data.BeaconBlockRoot),
+			Source:          checkpoint(att.Data.Source),
+			Target:          checkpoint(att.Data.Target),
+		},
+		Signature: hexutil.Encode(att.Signature),
+	}
+}
+
+type FinalityCheckpoints struct {
+	PreviousJustified Checkpoint `json:"previous_justified"`
+	CurrentJustified  Checkpoint `json:"current_justified"`
+	Finalized         Checkpoint `json:"finalized"`
+}
+
 type BeaconHeaderInfo struct {
 	Header struct {
 		Message struct {
@@ -46,6 +116,134 @@ type AttestDuty struct {
 	Slot                    string `json:"slot"`
 }
 
+// SyncCommitteeDuty is the sync committee membership of a validator.
+type SyncCommitteeDuty struct {
+	Pubkey                        string   `json:"pubkey"`
+	ValidatorIndex                string   `json:"validator_index"`
+	ValidatorSyncCommitteeIndices []string `json:"validator_sync_committee_indices"`
+}
+
+// ProposerDuties are the proposer duties of an epoch, they stay valid as long
+// as the block at DependentRoot is canonical.
+type ProposerDuties struct {
+	DependentRoot string         `json:"dependent_root"`
+	Duties        []ProposerDuty `json:"duties"`
+}
+
+// AttesterDuties are the attester duties of an epoch, they stay valid as long
+// as the block at DependentRoot is canonical.
+type AttesterDuties struct {
+	DependentRoot string       `json:"dependent_root"`
+	Duties        []AttestDuty `json:"duties"`
+}
+
+// ValidatorState is a validator of the registry in a beacon state.
+type ValidatorState struct {
+	Index     string `json:"index"`
+	Balance   string `json:"balance"`
+	Status    string `json:"status"`
+	Validator struct {
+		Pubkey                     string `json:"pubkey"`
+		WithdrawalCredentials      string `json:"withdrawal_credentials"`
+		EffectiveBalance           string `json:"effective_balance"`
+		Slashed                    bool   `json:"slashed"`
+		ActivationEligibilityEpoch string `json:"activation_eligibility_epoch"`
+		ActivationEpoch            string `json:"activation_epoch"`
+		ExitEpoch                  string `json:"exit_epoch"`
+		WithdrawableEpoch          string `json:"withdrawable_epoch"`
+	} `json:"validator"`
+}
+
+// BeaconResponse is the envelope of the Beacon API responses.
 type BeaconResponse struct {
-	Data json.RawMessage `json:"data"`
+	Raw                 json.RawMessage `json:"-"`                 // the whole body
+	Version             string          `json:"version,omitempty"` // fork of the data
+	Data                json.RawMessage `json:"data"`
+	DependentRoot       string          `json:"dependent_root,omitempty"` // duty endpoints only
+	ExecutionOptimistic bool            `json:"execution_optimistic,omitempty"`
+	Finalized           bool            `json:"finalized,omitempty"`
+}
+
+// CommitteeFilter selects the committees of GetCommittees, nil fields don't
+// filter.
+type CommitteeFilter struct {
+	Epoch *uint64
+	Index *uint64
+	Slot  *uint64
+}
+
+type Committee struct {
+	Index      string   `json:"index"`
+	Slot       string   `json:"slot"`
+	Validators []string `json:"validators"`
+}
+
+// ForkChoiceNode is a block in the fork choice store, Weight is in gwei.
+type ForkChoiceNode struct {
+	Slot               string                 `json:"slot"`
+	BlockRoot          string                 `json:"block_root"`
+	ParentRoot         string                 `json:"parent_root"`
+	JustifiedEpoch     string                 `json:"justified_epoch"`
+	FinalizedEpoch     string                 `json:"finalized_epoch"`
+	Weight             string                 `json:"weight"`
+	Validity           string                 `json:"validity"`
+	ExecutionBlockHash string                 `json:"execution_block_hash"`
+	ExtraData          map[string]interface{} `json:"extra_data,omitempty"`
+}
+
+// WeightGwei returns the weight of the node, 0 if invalid.
+func (n ForkChoiceNode) WeightGwei() uint64 {
+	w, _ := strconv.ParseUint(n.Weight, 10, 64)
+	return w
+}
+
+// ForkChoice is the fork choice store of /eth/v1/debug/fork_choice. The
+// extra data is client specific, prysm puts the proposer boost and the head
+// root in it.
+type ForkChoice struct {
+	JustifiedCheckpoint Checkpoint             `json:"justified_checkpoint"`
+	FinalizedCheckpoint Checkpoint             `json:"finalized_checkpoint"`
+	Nodes               []ForkChoiceNode       `json:"fork_choice_nodes"`
+	ExtraData           map[string]interface{} `json:"extra_data,omitempty"`
+}
+
+// Node returns the node of the block root.
+func (f ForkChoice) Node(root string) (ForkChoiceNode, bool) {
+	for _, n := range f.Nodes {
+		if n.BlockRoot == root {
+			return n, true
+		}
+	}
+	return ForkChoiceNode{}, false
+}
+
+// Children returns the nodes whose parent is root, the competing branches.
+func (f ForkChoice) Children(root string) []ForkChoiceNode {
+	var children []ForkChoiceNode
+	for _, n := range f.Nodes {
+		if n.ParentRoot == root {
+			children = append(children, n)
+		}
+	}
+	return children
+}
+
+// ProposerBoostRoot returns the block root with the proposer boost, empty if
+// the beacon node doesn't report it or no block has it.
+func (f ForkChoice) ProposerBoostRoot() string {
+	return f.extra("proposer_boost_root")
+}
+
+// HeadRoot returns the head of the fork choice, empty if the beacon node
+// doesn't report it.
+func (f ForkChoice) HeadRoot() string {
+	return f.extra("head_root")
+}
+
+func (f ForkChoice) extra(key string) string {
+	v, _ := f.ExtraData[key].(string)
+	if strings.Trim(v, "0x") == "" {
+		return ""
+	}
+	return v
 }
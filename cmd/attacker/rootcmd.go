@@ -6,6 +6,7 @@ import (
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"github.com/tsinghua-cel/attacker-service/beaconapi"
 	"github.com/tsinghua-cel/attacker-service/config"
 	"github.com/tsinghua-cel/attacker-service/reward"
 	"github.com/tsinghua-cel/attacker-service/server"
@@ -18,6 +19,7 @@ import (
 var logLevel string
 var logPath string
 var configPath string
+var lenientStrategy bool
 
 // RootCmd represents the base command when called without any subcommands
 var RootCmd = &cobra.Command{
@@ -45,6 +47,7 @@ func init() {
 	RootCmd.PersistentFlags().StringVar(&logLevel, "loglevel", "debug", "log level")
 	RootCmd.PersistentFlags().StringVar(&logPath, "logpath", "", "log path")
 	RootCmd.PersistentFlags().StringVar(&configPath, "config", "", "config file path")
+	RootCmd.Flags().BoolVar(&lenientStrategy, "lenient-strategy", false, "start with the default strategy if the strategy file is invalid")
 }
 
 // initConfig reads in config file and ENV variables if set.
@@ -68,7 +71,8 @@ func initConfig() {
 	if err := viper.ReadInConfig(); err == nil {
 		//log.Info("Using config file:", viper.ConfigFileUsed())
 	} else {
-		log.WithField("error", err).Fatal("Read config failed")
+		// sub commands like `strategy check` can work without config.
+		log.WithField("error", err).Warn("Read config failed")
 		return
 	}
 
@@ -79,11 +83,17 @@ func initConfig() {
 }
 
 func runNode() {
+	if config.GetConfig() == nil {
+		log.Fatal("no config file found")
+	}
+	if lenientStrategy {
+		config.GetConfig().LenientStrategy = true
+	}
 
 	rpcServer := server.NewServer()
 	rpcServer.Start()
 
-	go getRewardBackgroud()
+	go getRewardBackgroud(rpcServer.GetEventStream())
 
 	wg := sync.WaitGroup{}
 	wg.Add(1)
@@ -134,20 +144,31 @@ func localFilesystemLogger(logPath string) {
 	log.AddHook(lfHook)
 }
 
-func getRewardBackgroud() {
+// getRewardBackgroud collects the rewards at every epoch transition, the
+// ticker is only used while the event stream is down.
+func getRewardBackgroud(events *beaconapi.EventStream) {
+	sub := events.Subscribe(4, beaconapi.TopicHead)
+	defer sub.Unsubscribe()
 	ticker := time.NewTicker(time.Minute)
 	defer ticker.Stop()
 	for {
 		select {
+		case ev := <-sub.C:
+			if !ev.Data.(*beaconapi.HeadEvent).EpochTransition {
+				continue
+			}
 		case <-ticker.C:
-			log.WithFields(log.Fields{
-				"beacon": config.GetConfig().BeaconRpc,
-				"file":   config.GetConfig().RewardFile,
-			}).Debug("goto get reward")
-			err := reward.GetRewards(config.GetConfig().BeaconRpc, config.GetConfig().RewardFile)
-			if err != nil {
-				log.WithError(err).Error("collect reward failed")
+			if events.Connected() {
+				continue
 			}
 		}
+		log.WithFields(log.Fields{
+			"beacon": config.GetConfig().BeaconRpc,
+			"file":   config.GetConfig().RewardFile,
+		}).Debug("goto get reward")
+		err := reward.GetRewards(config.GetConfig().BeaconRpc, config.GetConfig().RewardFile)
+		if err != nil {
+			log.WithError(err).Error("collect reward failed")
+		}
 	}
 }
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/tsinghua-cel/attacker-service/config"
+	"github.com/tsinghua-cel/attacker-service/strategy"
+)
+
+func init() {
+	strategyCmd.AddCommand(strategyCheckCmd)
+	RootCmd.AddCommand(strategyCmd)
+}
+
+var strategyCmd = &cobra.Command{
+	Use:   "strategy",
+	Short: "Strategy file tools",
+	Long:  ``,
+}
+
+// strategyCheckCmd validates a strategy file strictly and prints every problem.
+var strategyCheckCmd = &cobra.Command{
+	Use:   "check [file]",
+	Short: "Check a strategy file",
+	Long:  `Check a strategy file strictly, the strategy file in the config is checked if file is omitted.`,
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var file string
+		if len(args) > 0 {
+			file = args[0]
+		} else if cfg := config.GetConfig(); cfg != nil {
+			file = cfg.Strategy
+		}
+		if file == "" {
+			fmt.Println("no strategy file given")
+			os.Exit(2)
+		}
+
+		issues, err := strategy.CheckFile(file)
+		if err != nil {
+			fmt.Printf("%s: %v\n", file, err)
+			os.Exit(2)
+		}
+		errCount := 0
+		for _, issue := range issues {
+			msg := issue.Message
+			if issue.Path != "" {
+				msg = issue.Path + ": " + msg
+			}
+			fmt.Printf("%s:%d: %s: %s\n", file, issue.Line, issue.Severity, msg)
+			if issue.Severity == strategy.SeverityError {
+				errCount++
+			}
+		}
+		fmt.Printf("%s: %d error(s), %d warning(s)\n", file, errCount, len(issues)-errCount)
+		if errCount > 0 {
+			os.Exit(1)
+		}
+	},
+}
@@ -7,13 +7,22 @@ import (
 )
 
 type Config struct {
-	HttpPort    int    `json:"http_port" toml:"http_port"`
-	HttpHost    string `json:"http_host" toml:"http_host"`
-	ExecuteRpc  string `json:"execute_rpc" toml:"execute_rpc"`
-	BeaconRpc   string `json:"beacon_rpc" toml:"beacon_rpc"`
-	MetricsPort int    `json:"metrics_port" toml:"metrics_port"`
-	Strategy    string `json:"strategy" toml:"strategy"`
-	RewardFile  string `json:"reward_file" toml:"reward_file"`
+	HttpPort        int    `json:"http_port" toml:"http_port"`
+	HttpHost        string `json:"http_host" toml:"http_host"`
+	ExecuteRpc      string `json:"execute_rpc" toml:"execute_rpc"`
+	BeaconRpc       string `json:"beacon_rpc" toml:"beacon_rpc"`         // host:port, or a http:// or https:// url, comma separated for several nodes
+	BeaconTimeout   int    `json:"beacon_timeout" toml:"beacon_timeout"` // seconds per beacon request, 10 if zero
+	BeaconMaxLag    int    `json:"beacon_max_lag" toml:"beacon_max_lag"` // slots a beacon node may be behind the best one, 2 if zero
+	BeaconCheck     bool   `json:"beacon_check" toml:"beacon_check"`     // report when the beacon nodes disagree on the head or finality
+	MetricsPort     int    `json:"metrics_port" toml:"metrics_port"`
+	Strategy        string `json:"strategy" toml:"strategy"`
+	RewardFile      string `json:"reward_file" toml:"reward_file"`
+	LuaScript       string `json:"lua_script" toml:"lua_script"`
+	LenientStrategy bool   `json:"lenient_strategy" toml:"lenient_strategy"` // ignore unknown fields, use default strategy if invalid
+	RoleFile        string `json:"role_file" toml:"role_file"`               // runtime role overrides, kept in memory if empty
+	RetentionMode   string `json:"retention_mode" toml:"retention_mode"`     // "epochs" (default) or "finalized"
+	RetainEpochs    *int   `json:"retain_epochs" toml:"retain_epochs"`       // epochs of signed data kept, or kept before the finalized epoch, 4 if unset, 0 keeps everything
+	Database        string `json:"database" toml:"database"`                 // on-disk store of the signed data, roles and delays, kept in memory if empty
 }
 
 var _cfg *Config = nil
@@ -0,0 +1,281 @@
+package duties
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/tsinghua-cel/attacker-service/beaconapi"
+)
+
+var (
+	ErrNoValidators = errors.New("no validators to ask duties for")
+	ErrNoProposer   = errors.New("no proposer duty for slot")
+)
+
+// Source is where the duties come from, see beaconapi.BeaconGwClient.
+type Source interface {
+	GetEpochProposerDuties(epoch int) (beaconapi.ProposerDuties, error)
+	GetEpochAttesterDuties(epoch int, vals []int) (beaconapi.AttesterDuties, error)
+	GetSyncCommitteeDuties(epoch int, vals []int) ([]beaconapi.SyncCommitteeDuty, error)
+}
+
+// EpochDuties are the cached duties of an epoch, nil lists are not fetched
+// yet.
+type EpochDuties struct {
+	Epoch         uint64                        `json:"epoch"`
+	ProposerRoot  string                        `json:"proposer_dependent_root"`
+	AttesterRoot  string                        `json:"attester_dependent_root"`
+	Proposers     []beaconapi.ProposerDuty      `json:"proposers"`
+	Attesters     []beaconapi.AttestDuty        `json:"attesters"`
+	SyncCommittee []beaconapi.SyncCommitteeDuty `json:"sync_committee"`
+}
+
+// Cache keeps the duties per epoch so that the hooks answer from memory.
+// Proposer duties of epoch e depend on the last block of epoch e-1 and
+// attester duties on the last block of epoch e-2, which is also the proposer
+// dependent root of epoch e-1. Refresh asks the proposer duties again and
+// drops the duties whose dependent root changed. The sync committee duties
+// come without a dependent root, they are asked again with the other duties
+// of their epoch.
+type Cache struct {
+	src        Source
+	validators func() []int // the validators to ask attester and sync duties for
+	epochs     map[uint64]*EpochDuties
+	lock       sync.Mutex
+}
+
+func New(src Source, validators func() []int) *Cache {
+	return &Cache{
+		src:        src,
+		validators: validators,
+		epochs:     make(map[uint64]*EpochDuties),
+	}
+}
+
+func (c *Cache) epoch(epoch uint64) *EpochDuties {
+	d, exist := c.epochs[epoch]
+	if !exist {
+		d = &EpochDuties{Epoch: epoch}
+		c.epochs[epoch] = d
+	}
+	return d
+}
+
+// Proposers returns the proposer duties of epoch, they are fetched if not
+// cached.
+func (c *Cache) Proposers(epoch uint64) ([]beaconapi.ProposerDuty, error) {
+	c.lock.Lock()
+	if d, exist := c.epochs[epoch]; exist && d.Proposers != nil {
+		c.lock.Unlock()
+		return d.Proposers, nil
+	}
+	c.lock.Unlock()
+	if err := c.fetchProposers(epoch); err != nil {
+		return nil, err
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.epoch(epoch).Proposers, nil
+}
+
+// ProposerAt returns the index of the validator proposing at slot.
+func (c *Cache) ProposerAt(slot uint64, slotsPerEpoch uint64) (int, error) {
+	duties, err := c.Proposers(slot / slotsPerEpoch)
+	if err != nil {
+		return 0, err
+	}
+	for _, duty := range duties {
+		dutySlot, _ := strconv.ParseUint(duty.Slot, 10, 64)
+		if dutySlot == slot {
+			return strconv.Atoi(duty.ValidatorIndex)
+		}
+	}
+	return 0, ErrNoProposer
+}
+
+// Attesters returns the attester duties of epoch, they are fetched if not
+// cached.
+func (c *Cache) Attesters(epoch uint64) ([]beaconapi.AttestDuty, error) {
+	c.lock.Lock()
+	if d, exist := c.epochs[epoch]; exist && d.Attesters != nil {
+		c.lock.Unlock()
+		return d.Attesters, nil
+	}
+	c.lock.Unlock()
+	if err := c.fetchAttesters(epoch); err != nil {
+		return nil, err
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.epoch(epoch).Attesters, nil
+}
+
+// SyncCommittee returns the sync committee duties of epoch, they are fetched
+// if not cached.
+func (c *Cache) SyncCommittee(epoch uint64) ([]beaconapi.SyncCommitteeDuty, error) {
+	c.lock.Lock()
+	if d, exist := c.epochs[epoch]; exist && d.SyncCommittee != nil {
+		c.lock.Unlock()
+		return d.SyncCommittee, nil
+	}
+	c.lock.Unlock()
+	if err := c.fetchSyncCommittee(epoch); err != nil {
+		return nil, err
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.epoch(epoch).SyncCommittee, nil
+}
+
+func (c *Cache) fetchSyncCommittee(epoch uint64) error {
+	vals := c.validators()
+	if len(vals) == 0 {
+		return ErrNoValidators
+	}
+	duties, err := c.src.GetSyncCommitteeDuties(int(epoch), vals)
+	if err != nil {
+		return err
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.epoch(epoch).SyncCommittee = duties
+	return nil
+}
+
+func (c *Cache) fetchProposers(epoch uint64) error {
+	duties, err := c.src.GetEpochProposerDuties(int(epoch))
+	if err != nil {
+		return err
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.setProposers(epoch, duties)
+	return nil
+}
+
+// setProposers caches the proposer duties of epoch, the attester duties of
+// epoch+1 depend on the same root and are dropped if it changed.
+func (c *Cache) setProposers(epoch uint64, duties beaconapi.ProposerDuties) {
+	d := c.epoch(epoch)
+	if d.Proposers != nil && d.ProposerRoot != duties.DependentRoot {
+		log.WithFields(log.Fields{
+			"epoch": epoch,
+			"old":   d.ProposerRoot,
+			"new":   duties.DependentRoot,
+		}).Info("proposer dependent root changed, duties reloaded")
+		d.SyncCommittee = nil
+	}
+	d.ProposerRoot = duties.DependentRoot
+	d.Proposers = duties.Duties
+	if next, exist := c.epochs[epoch+1]; exist && next.Attesters != nil && next.AttesterRoot != duties.DependentRoot {
+		log.WithField("epoch", epoch+1).Info("attester dependent root changed, duties dropped")
+		next.Attesters = nil
+		next.AttesterRoot = ""
+		next.SyncCommittee = nil
+	}
+}
+
+func (c *Cache) fetchAttesters(epoch uint64) error {
+	vals := c.validators()
+	if len(vals) == 0 {
+		return ErrNoValidators
+	}
+	duties, err := c.src.GetEpochAttesterDuties(int(epoch), vals)
+	if err != nil {
+		return err
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	d := c.epoch(epoch)
+	d.AttesterRoot = duties.DependentRoot
+	d.Attesters = duties.Duties
+	return nil
+}
+
+// Refresh asks the proposer duties of epoch again, the cached duties whose
+// dependent root changed are replaced, then fetches the attester and sync
+// committee duties of epoch if they are missing.
+func (c *Cache) Refresh(epoch uint64) error {
+	if err := c.fetchProposers(epoch); err != nil {
+		return err
+	}
+	c.lock.Lock()
+	d := c.epoch(epoch)
+	missingAttesters, missingSync := d.Attesters == nil, d.SyncCommittee == nil
+	c.lock.Unlock()
+	if missingAttesters {
+		if err := c.fetchAttesters(epoch); err != nil {
+			return err
+		}
+	}
+	if missingSync {
+		return c.fetchSyncCommittee(epoch)
+	}
+	return nil
+}
+
+// OnHead drops the cached duties that don't match the dependent roots of a
+// new head in epoch. current is the proposer dependent root of epoch and the
+// attester dependent root of epoch+1, previous is the attester dependent root
+// of epoch. It returns true if duties were dropped.
+func (c *Cache) OnHead(epoch uint64, current string, previous string) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	dropped := false
+	if d, exist := c.epochs[epoch]; exist {
+		if d.Proposers != nil && d.ProposerRoot != current {
+			d.Proposers, d.ProposerRoot = nil, ""
+			d.SyncCommittee = nil
+			dropped = true
+		}
+		if d.Attesters != nil && d.AttesterRoot != previous {
+			d.Attesters, d.AttesterRoot = nil, ""
+			d.SyncCommittee = nil
+			dropped = true
+		}
+	}
+	if next, exist := c.epochs[epoch+1]; exist && next.Attesters != nil && next.AttesterRoot != current {
+		next.Attesters, next.AttesterRoot = nil, ""
+		next.SyncCommittee = nil
+		dropped = true
+	}
+	if dropped {
+		log.WithFields(log.Fields{
+			"epoch":    epoch,
+			"current":  current,
+			"previous": previous,
+		}).Info("dependent root of the head changed, duties dropped")
+	}
+	return dropped
+}
+
+// Invalidate drops the cached duties of epoch.
+func (c *Cache) Invalidate(epoch uint64) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	delete(c.epochs, epoch)
+}
+
+// PruneBefore drops the duties of the epochs before epoch.
+func (c *Cache) PruneBefore(epoch uint64) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for e := range c.epochs {
+		if e < epoch {
+			delete(c.epochs, e)
+		}
+	}
+}
+
+// Get returns a copy of the cached duties of epoch.
+func (c *Cache) Get(epoch uint64) (EpochDuties, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	d, exist := c.epochs[epoch]
+	if !exist {
+		return EpochDuties{}, false
+	}
+	return *d, true
+}
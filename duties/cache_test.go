@@ -0,0 +1,133 @@
+package duties
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/tsinghua-cel/attacker-service/beaconapi"
+)
+
+type fakeSource struct {
+	roots         map[uint64]string // epoch -> proposer dependent root
+	proposerCalls int
+	attesterCalls int
+	syncCalls     int
+}
+
+func (f *fakeSource) GetEpochProposerDuties(epoch int) (beaconapi.ProposerDuties, error) {
+	f.proposerCalls++
+	duties := make([]beaconapi.ProposerDuty, 4)
+	for i := range duties {
+		duties[i] = beaconapi.ProposerDuty{
+			Slot:           strconv.Itoa(epoch*4 + i),
+			ValidatorIndex: strconv.Itoa(i + 10),
+		}
+	}
+	return beaconapi.ProposerDuties{DependentRoot: f.roots[uint64(epoch)], Duties: duties}, nil
+}
+
+func (f *fakeSource) GetEpochAttesterDuties(epoch int, vals []int) (beaconapi.AttesterDuties, error) {
+	f.attesterCalls++
+	duties := make([]beaconapi.AttestDuty, len(vals))
+	for i, v := range vals {
+		duties[i] = beaconapi.AttestDuty{ValidatorIndex: strconv.Itoa(v)}
+	}
+	return beaconapi.AttesterDuties{DependentRoot: f.roots[uint64(epoch-1)], Duties: duties}, nil
+}
+
+func (f *fakeSource) GetSyncCommitteeDuties(epoch int, vals []int) ([]beaconapi.SyncCommitteeDuty, error) {
+	f.syncCalls++
+	return []beaconapi.SyncCommitteeDuty{}, nil
+}
+
+func TestCache(t *testing.T) {
+	src := &fakeSource{roots: map[uint64]string{1: "0xa1", 2: "0xa2"}}
+	c := New(src, func() []int { return []int{1, 2, 3} })
+
+	if err := c.Refresh(2); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if idx, err := c.ProposerAt(9, 4); err != nil || idx != 11 {
+			t.Fatalf("proposer of slot 9: got %d, %v", idx, err)
+		}
+		if duties, err := c.Attesters(2); err != nil || len(duties) != 3 {
+			t.Fatalf("attesters of epoch 2: got %v, %v", duties, err)
+		}
+		if _, err := c.SyncCommittee(2); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if src.proposerCalls != 1 || src.attesterCalls != 1 || src.syncCalls != 1 {
+		t.Fatalf("duties not served from cache: %+v", src)
+	}
+	if _, err := c.Attesters(3); err != nil {
+		t.Fatal(err)
+	}
+
+	// a reorg of the last block of epoch 1 changes the proposers of epoch 2
+	// and drops the attesters of epoch 3, which depend on the same block.
+	src.roots[2] = "0xb2"
+	if err := c.Refresh(2); err != nil {
+		t.Fatal(err)
+	}
+	if d, _ := c.Get(3); d.Attesters != nil {
+		t.Fatalf("attesters of epoch 3 kept after reorg")
+	}
+	if d, _ := c.Get(2); d.Attesters == nil || d.ProposerRoot != "0xb2" {
+		t.Fatalf("duties of epoch 2: got %+v", d)
+	}
+	if _, err := c.Attesters(3); err != nil || src.attesterCalls != 3 {
+		t.Fatalf("attesters of epoch 3 not fetched again: %v, %d calls", err, src.attesterCalls)
+	}
+
+	c.PruneBefore(3)
+	if _, ok := c.Get(2); ok {
+		t.Fatalf("epoch 2 not pruned")
+	}
+
+	// a head in epoch 3 whose previous duty dependent root differs from the
+	// attester dependent root of the cached duties.
+	if c.OnHead(3, "0xc3", "0xb2") {
+		t.Fatalf("duties of epoch 3 dropped with matching roots")
+	}
+	if !c.OnHead(3, "0xc3", "0xd2") {
+		t.Fatalf("duties of epoch 3 kept with another dependent root")
+	}
+	if d, _ := c.Get(3); d.Attesters != nil {
+		t.Fatalf("attesters of epoch 3 kept: %+v", d)
+	}
+}
+
+func TestSyncCommitteeDuties(t *testing.T) {
+	src := &fakeSource{roots: map[uint64]string{1: "0xa1", 2: "0xa2"}}
+	c := New(src, func() []int { return []int{1, 2, 3} })
+
+	// Refresh caches the sync committee duties with the other duties.
+	if err := c.Refresh(2); err != nil {
+		t.Fatal(err)
+	}
+	if d, _ := c.Get(2); d.SyncCommittee == nil || src.syncCalls != 1 {
+		t.Fatalf("sync duties of epoch 2 not cached: %+v, %d calls", d, src.syncCalls)
+	}
+	if err := c.Refresh(2); err != nil || src.syncCalls != 1 {
+		t.Fatalf("sync duties fetched again with the same root: %v, %d calls", err, src.syncCalls)
+	}
+
+	// a new proposer dependent root reloads them.
+	src.roots[2] = "0xb2"
+	if err := c.Refresh(2); err != nil || src.syncCalls != 2 {
+		t.Fatalf("sync duties not reloaded after reorg: %v, %d calls", err, src.syncCalls)
+	}
+
+	// so does a head with another dependent root.
+	if !c.OnHead(2, "0xc2", "0xa1") {
+		t.Fatalf("duties of epoch 2 kept with another dependent root")
+	}
+	if d, _ := c.Get(2); d.SyncCommittee != nil {
+		t.Fatalf("sync duties of epoch 2 kept: %+v", d)
+	}
+	if _, err := c.SyncCommittee(2); err != nil || src.syncCalls != 3 {
+		t.Fatalf("sync duties not fetched again: %v, %d calls", err, src.syncCalls)
+	}
+}
@@ -1,7 +1,158 @@
 package luascripts
 
+import (
+	"encoding/json"
+	"errors"
+
+	lua "github.com/yuin/gopher-lua"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	ErrInvalidResult = errors.New("invalid lua hook result")
+
+	marshalOption   = protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true}
+	unmarshalOption = protojson.UnmarshalOptions{DiscardUnknown: true}
+)
+
+// BlockData is the view of a block passed to the lua block hooks.
 type BlockData struct {
+	Slot   uint64        `json:"slot"`
+	Pubkey string        `json:"pubkey"`
+	Role   string        `json:"role"`
+	Cmd    int           `json:"cmd"`
+	Fork   string        `json:"fork"`
+	Block  proto.Message `json:"-"`
 }
 
+// AttestData is the view of an attestation passed to the lua attest hooks.
 type AttestData struct {
+	Slot   uint64        `json:"slot"`
+	Pubkey string        `json:"pubkey"`
+	Role   string        `json:"role"`
+	Cmd    int           `json:"cmd"`
+	Attest proto.Message `json:"-"`
+}
+
+func (d BlockData) toTable(L *lua.LState) (*lua.LTable, error) {
+	obj, err := messageToValue(L, d.Block)
+	if err != nil {
+		return nil, err
+	}
+	t := L.NewTable()
+	t.RawSetString("slot", lua.LNumber(d.Slot))
+	t.RawSetString("pubkey", lua.LString(d.Pubkey))
+	t.RawSetString("role", lua.LString(d.Role))
+	t.RawSetString("cmd", lua.LNumber(d.Cmd))
+	t.RawSetString("fork", lua.LString(d.Fork))
+	t.RawSetString("block", obj)
+	return t, nil
+}
+
+func (d AttestData) toTable(L *lua.LState) (*lua.LTable, error) {
+	obj, err := messageToValue(L, d.Attest)
+	if err != nil {
+		return nil, err
+	}
+	t := L.NewTable()
+	t.RawSetString("slot", lua.LNumber(d.Slot))
+	t.RawSetString("pubkey", lua.LString(d.Pubkey))
+	t.RawSetString("role", lua.LString(d.Role))
+	t.RawSetString("cmd", lua.LNumber(d.Cmd))
+	t.RawSetString("attest", obj)
+	return t, nil
+}
+
+// messageToValue converts a proto message to a lua table, the field names
+// are the same as the proto names (eg. parent_root), bytes are base64 strings
+// and 64-bit integers are decimal strings.
+func messageToValue(L *lua.LState, msg proto.Message) (lua.LValue, error) {
+	data, err := marshalOption.Marshal(msg)
+	if err != nil {
+		return lua.LNil, err
+	}
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return lua.LNil, err
+	}
+	return toLValue(L, v), nil
+}
+
+// valueToMessage decodes a lua table returned by a hook into a new message
+// with the same type as tmpl.
+func valueToMessage(v lua.LValue, tmpl proto.Message) (proto.Message, error) {
+	if _, ok := v.(*lua.LTable); !ok {
+		return nil, ErrInvalidResult
+	}
+	data, err := json.Marshal(fromLValue(v))
+	if err != nil {
+		return nil, err
+	}
+	msg := tmpl.ProtoReflect().New().Interface()
+	if err := unmarshalOption.Unmarshal(data, msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+func toLValue(L *lua.LState, v interface{}) lua.LValue {
+	switch val := v.(type) {
+	case nil:
+		return lua.LNil
+	case bool:
+		return lua.LBool(val)
+	case float64:
+		return lua.LNumber(val)
+	case string:
+		return lua.LString(val)
+	case []interface{}:
+		t := L.CreateTable(len(val), 0)
+		for _, item := range val {
+			t.Append(toLValue(L, item))
+		}
+		return t
+	case map[string]interface{}:
+		t := L.CreateTable(0, len(val))
+		for k, item := range val {
+			t.RawSetString(k, toLValue(L, item))
+		}
+		return t
+	default:
+		return lua.LNil
+	}
+}
+
+func fromLValue(v lua.LValue) interface{} {
+	switch val := v.(type) {
+	case *lua.LNilType:
+		return nil
+	case lua.LBool:
+		return bool(val)
+	case lua.LNumber:
+		return float64(val)
+	case lua.LString:
+		return string(val)
+	case *lua.LTable:
+		if n := val.MaxN(); n > 0 {
+			arr := make([]interface{}, 0, n)
+			for i := 1; i <= n; i++ {
+				arr = append(arr, fromLValue(val.RawGetInt(i)))
+			}
+			return arr
+		}
+		obj := make(map[string]interface{})
+		val.ForEach(func(k lua.LValue, item lua.LValue) {
+			if key, ok := k.(lua.LString); ok {
+				obj[string(key)] = fromLValue(item)
+			}
+		})
+		if len(obj) == 0 {
+			// an empty table can't tell list from object, treat as default value.
+			return nil
+		}
+		return obj
+	default:
+		return nil
+	}
 }
@@ -0,0 +1,159 @@
+package luascripts
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/tsinghua-cel/attacker-service/types"
+	lua "github.com/yuin/gopher-lua"
+	"google.golang.org/protobuf/proto"
+)
+
+// lua function names called by the hooks.
+const (
+	BlockBeforeSign  = "block_before_sign"
+	BlockAfterSign   = "block_after_sign"
+	AttestBeforeSign = "attest_before_sign"
+	AttestAfterSign  = "attest_after_sign"
+)
+
+// Engine runs the hook functions defined in a lua script. The script is
+// reloaded when the file is modified, so a new attack can be tried by
+// replacing the script without restarting the service.
+type Engine struct {
+	file    string
+	modTime time.Time
+	state   *lua.LState
+	mux     sync.Mutex
+}
+
+func NewEngine(file string) (*Engine, error) {
+	e := &Engine{file: file}
+	if err := e.load(); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func (e *Engine) load() error {
+	info, err := os.Stat(e.file)
+	if err != nil {
+		return err
+	}
+	L := lua.NewState()
+	for _, c := range types.Commands().Commands {
+		L.SetGlobal(c.Name, lua.LNumber(c.Cmd))
+	}
+	L.SetGlobal("ROLE_NORMAL", lua.LString(types.NormalRole.String()))
+	L.SetGlobal("ROLE_ATTACKER", lua.LString(types.AttackerRole.String()))
+	L.SetGlobal("log", L.NewFunction(luaLog))
+	if err := L.DoFile(e.file); err != nil {
+		L.Close()
+		return err
+	}
+	if e.state != nil {
+		e.state.Close()
+	}
+	e.state = L
+	e.modTime = info.ModTime()
+	log.WithField("file", e.file).Info("lua script loaded")
+	return nil
+}
+
+// reloadIfChanged reloads the script when the file is modified, if the new
+// script is broken the previous one is kept.
+func (e *Engine) reloadIfChanged() {
+	info, err := os.Stat(e.file)
+	if err != nil || info.ModTime().Equal(e.modTime) {
+		return
+	}
+	if err := e.load(); err != nil {
+		log.WithError(err).WithField("file", e.file).Error("reload lua script failed, keep the old one")
+		e.modTime = info.ModTime()
+	}
+}
+
+// CallBlockHook calls the lua function named hook with the block data, and
+// returns the command and the block returned by the script. If the script
+// doesn't define the hook, ok is false.
+func (e *Engine) CallBlockHook(hook string, data BlockData) (cmd types.AttackerCommand, block proto.Message, ok bool, err error) {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+	e.reloadIfChanged()
+
+	fn, exist := e.hook(hook)
+	if !exist {
+		return types.CMD_NULL, nil, false, nil
+	}
+	arg, err := data.toTable(e.state)
+	if err != nil {
+		return types.CMD_NULL, nil, false, err
+	}
+	cmd, block, err = e.call(fn, arg, data.Block)
+	return cmd, block, true, err
+}
+
+// CallAttestHook is the same as CallBlockHook for attestation hooks.
+func (e *Engine) CallAttestHook(hook string, data AttestData) (cmd types.AttackerCommand, attest proto.Message, ok bool, err error) {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+	e.reloadIfChanged()
+
+	fn, exist := e.hook(hook)
+	if !exist {
+		return types.CMD_NULL, nil, false, nil
+	}
+	arg, err := data.toTable(e.state)
+	if err != nil {
+		return types.CMD_NULL, nil, false, err
+	}
+	cmd, attest, err = e.call(fn, arg, data.Attest)
+	return cmd, attest, true, err
+}
+
+func (e *Engine) hook(name string) (*lua.LFunction, bool) {
+	fn, ok := e.state.GetGlobal(name).(*lua.LFunction)
+	return fn, ok
+}
+
+// call runs fn(arg), the function returns (cmd, obj), obj is nil when the
+// object is not modified.
+func (e *Engine) call(fn *lua.LFunction, arg *lua.LTable, tmpl proto.Message) (types.AttackerCommand, proto.Message, error) {
+	L := e.state
+	if err := L.CallByParam(lua.P{Fn: fn, NRet: 2, Protect: true}, arg); err != nil {
+		return types.CMD_NULL, nil, err
+	}
+	ret1, ret2 := L.Get(-2), L.Get(-1)
+	L.Pop(2)
+
+	cmd := types.CMD_NULL
+	if n, ok := ret1.(lua.LNumber); ok {
+		cmd = types.AttackerCommand(n)
+	} else if ret1 != lua.LNil {
+		return types.CMD_NULL, nil, ErrInvalidResult
+	}
+	if ret2 == lua.LNil {
+		return cmd, nil, nil
+	}
+	msg, err := valueToMessage(ret2, tmpl)
+	if err != nil {
+		return types.CMD_NULL, nil, err
+	}
+	return cmd, msg, nil
+}
+
+func (e *Engine) Close() {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+	if e.state != nil {
+		e.state.Close()
+		e.state = nil
+	}
+}
+
+func luaLog(L *lua.LState) int {
+	log.WithField("module", "lua").Info(L.ToString(1))
+	return 0
+}
@@ -0,0 +1,61 @@
+package luascripts
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	ethpb "github.com/prysmaticlabs/prysm/v4/proto/prysm/v1alpha1"
+	"github.com/tsinghua-cel/attacker-service/types"
+)
+
+const testScript = `
+function attest_before_sign(data)
+    if data.role ~= ROLE_ATTACKER then
+        return data.cmd, nil
+    end
+    data.attest.beacon_block_root = data.attest.target.root
+    data.attest.committee_index = "3"
+    return CMD_RETURN, data.attest
+end
+`
+
+func TestEngineAttestHook(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "test.lua")
+	if err := os.WriteFile(file, []byte(testScript), 0644); err != nil {
+		t.Fatal(err)
+	}
+	engine, err := NewEngine(file)
+	if err != nil {
+		t.Fatalf("load script failed err:%s", err)
+	}
+	defer engine.Close()
+
+	root := make([]byte, 32)
+	root[0] = 1
+	data := &ethpb.AttestationData{
+		Slot:            10,
+		BeaconBlockRoot: make([]byte, 32),
+		Source:          &ethpb.Checkpoint{Root: make([]byte, 32)},
+		Target:          &ethpb.Checkpoint{Epoch: 1, Root: root},
+	}
+	cmd, res, ok, err := engine.CallAttestHook(AttestBeforeSign, AttestData{
+		Slot:   10,
+		Role:   types.AttackerRole.String(),
+		Attest: data,
+	})
+	if err != nil || !ok {
+		t.Fatalf("call hook failed ok:%v err:%v", ok, err)
+	}
+	if cmd != types.CMD_RETURN {
+		t.Fatalf("unexpected cmd %d", cmd)
+	}
+	modified := res.(*ethpb.AttestationData)
+	if modified.BeaconBlockRoot[0] != 1 || modified.CommitteeIndex != 3 || modified.Slot != 10 {
+		t.Fatalf("unexpected result %v", modified)
+	}
+
+	if _, _, ok, _ := engine.CallAttestHook(AttestAfterSign, AttestData{Attest: data}); ok {
+		t.Fatal("undefined hook should be skipped")
+	}
+}
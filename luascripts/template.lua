@@ -0,0 +1,37 @@
+-- Template of attacker lua script.
+--
+-- Set `lua_script` in config.toml to the path of the script, the script is
+-- reloaded automatically when the file changes.
+--
+-- Each hook receives a table with fields:
+--   slot    the slot number
+--   pubkey  the pubkey of the validator
+--   role    ROLE_NORMAL or ROLE_ATTACKER
+--   cmd     the command decided by the built-in strategy
+--   block   (block hooks) the GenericSignedBeaconBlock, the block is under
+--           the key of its fork, eg. block.capella or block.blinded_deneb
+--   fork    (block hooks) the fork of the block, eg. "capella"
+--   attest  (attest hooks) the AttestationData before sign, Attestation after sign
+--
+-- Objects use the proto field names (eg. parent_root), bytes fields are base64
+-- strings and 64-bit integers are decimal strings.
+--
+-- A hook returns (cmd, obj). cmd is one of CMD_NULL, CMD_RETURN, ... and obj
+-- is the modified object, or nil to keep it unchanged. Undefined hooks are
+-- skipped.
+
+function block_before_sign(data)
+    return data.cmd, nil
+end
+
+function block_after_sign(data)
+    return data.cmd, nil
+end
+
+function attest_before_sign(data)
+    return data.cmd, nil
+end
+
+function attest_after_sign(data)
+    return data.cmd, nil
+end
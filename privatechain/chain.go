@@ -0,0 +1,165 @@
+package privatechain
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Block is an attacker block kept off the public chain.
+type Block struct {
+	Slot       uint64    `json:"slot"`
+	Root       string    `json:"root"`
+	ParentRoot string    `json:"parent_root"`
+	Withheld   time.Time `json:"withheld"`
+	// DelayID is the delay of the broadcast hook of the block, cancelling it
+	// releases the block. Empty until the block reaches the hook.
+	DelayID string `json:"delay_id,omitempty"`
+}
+
+// Status is the state of the private chain.
+type Status struct {
+	Blocks       []Block `json:"blocks"`
+	PublicBlocks int     `json:"public_blocks"` // public blocks since the first private slot
+	Lead         int     `json:"lead"`          // private blocks ahead of the public chain
+}
+
+// Chain is the private fork of the attacker blocks. Each withheld block
+// builds on the previous one, the chain is released as a whole. The
+// public blocks seen from the first private slot are counted to know when
+// the public chain catches up.
+type Chain struct {
+	blocks []Block
+	public map[uint64]string // slot -> root of the public blocks since the fork
+	lock   sync.Mutex
+}
+
+func New() *Chain {
+	return &Chain{public: make(map[uint64]string)}
+}
+
+// Add withholds block if it builds on the tip of the chain, it returns false
+// and leaves the chain unchanged otherwise. The tip signed again for its slot
+// is replaced if it builds on the same parent.
+func (c *Chain) Add(block Block) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if n := len(c.blocks); n > 0 && c.blocks[n-1].Slot == block.Slot {
+		if c.blocks[n-1].ParentRoot != block.ParentRoot {
+			return false
+		}
+		c.blocks[n-1] = block
+		return true
+	}
+	if n := len(c.blocks); n > 0 && c.blocks[n-1].Root != block.ParentRoot {
+		return false
+	}
+	c.blocks = append(c.blocks, block)
+	c.prunePublic()
+	return true
+}
+
+// prunePublic drops the public blocks before the fork, they don't compete
+// with the private chain.
+func (c *Chain) prunePublic() {
+	for slot := range c.public {
+		if len(c.blocks) == 0 || slot < c.blocks[0].Slot {
+			delete(c.public, slot)
+		}
+	}
+}
+
+// PruneBefore drops the blocks of the slots before slot and returns them.
+func (c *Chain) PruneBefore(slot uint64) []Block {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	i := sort.Search(len(c.blocks), func(i int) bool {
+		return c.blocks[i].Slot >= slot
+	})
+	pruned := append([]Block{}, c.blocks[:i]...)
+	c.blocks = append([]Block{}, c.blocks[i:]...)
+	c.prunePublic()
+	return pruned
+}
+
+// At returns the withheld block of slot.
+func (c *Chain) At(slot uint64) (Block, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for _, b := range c.blocks {
+		if b.Slot == slot {
+			return b, true
+		}
+	}
+	return Block{}, false
+}
+
+// Tip returns the last withheld block.
+func (c *Chain) Tip() (Block, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if len(c.blocks) == 0 {
+		return Block{}, false
+	}
+	return c.blocks[len(c.blocks)-1], true
+}
+
+// SetDelay records the delay holding the broadcast of the block of slot.
+func (c *Chain) SetDelay(slot uint64, id string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for i := range c.blocks {
+		if c.blocks[i].Slot == slot {
+			c.blocks[i].DelayID = id
+		}
+	}
+}
+
+// PublicBlock counts a block of the public chain and returns the lead of the
+// private chain. Private blocks and blocks before the fork are ignored.
+func (c *Chain) PublicBlock(slot uint64, root string) int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if len(c.blocks) == 0 || slot < c.blocks[0].Slot {
+		return c.lead()
+	}
+	for _, b := range c.blocks {
+		if b.Root == root {
+			return c.lead()
+		}
+	}
+	c.public[slot] = root
+	return c.lead()
+}
+
+// Lead returns the number of private blocks ahead of the public chain.
+func (c *Chain) Lead() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.lead()
+}
+
+func (c *Chain) lead() int {
+	return len(c.blocks) - len(c.public)
+}
+
+// Status returns a copy of the chain state.
+func (c *Chain) Status() Status {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return Status{
+		Blocks:       append([]Block{}, c.blocks...),
+		PublicBlocks: len(c.public),
+		Lead:         c.lead(),
+	}
+}
+
+// Release empties the chain and returns the blocks to broadcast.
+func (c *Chain) Release() []Block {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	released := c.blocks
+	c.blocks = nil
+	c.public = make(map[uint64]string)
+	return released
+}
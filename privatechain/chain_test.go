@@ -0,0 +1,48 @@
+package privatechain
+
+import "testing"
+
+func TestChain(t *testing.T) {
+	c := New()
+	if lead := c.PublicBlock(3, "0x03"); lead != 0 {
+		t.Fatalf("empty chain: got lead %d", lead)
+	}
+	if !c.Add(Block{Slot: 4, Root: "0x04", ParentRoot: "0x03"}) {
+		t.Fatalf("first block doesn't extend the chain")
+	}
+	if !c.Add(Block{Slot: 5, Root: "0x05", ParentRoot: "0x04"}) {
+		t.Fatalf("block on the tip doesn't extend the chain")
+	}
+	if c.Add(Block{Slot: 7, Root: "0x07", ParentRoot: "0x06"}) {
+		t.Fatalf("block on a public parent extends the chain")
+	}
+	if !c.Add(Block{Slot: 7, Root: "0x07", ParentRoot: "0x05"}) {
+		t.Fatalf("block on the tip doesn't extend the chain")
+	}
+	if tip, ok := c.Tip(); !ok || tip.Slot != 7 || len(c.Status().Blocks) != 3 {
+		t.Fatalf("tip: got %+v, chain %+v", tip, c.Status())
+	}
+
+	// the private blocks seen in the events are not public.
+	if lead := c.PublicBlock(5, "0x05"); lead != 3 {
+		t.Fatalf("private block counted: lead %d", lead)
+	}
+	c.PublicBlock(6, "0x06")
+	if lead := c.PublicBlock(8, "0x08"); lead != 1 {
+		t.Fatalf("after 2 public blocks: lead %d, want 1", lead)
+	}
+
+	c.SetDelay(5, "12")
+	if b, ok := c.At(5); !ok || b.DelayID != "12" {
+		t.Fatalf("block of slot 5: got %+v", b)
+	}
+	if pruned := c.PruneBefore(5); len(pruned) != 1 || pruned[0].Slot != 4 {
+		t.Fatalf("pruned %+v, want the block of slot 4", pruned)
+	}
+	if released := c.Release(); len(released) != 2 {
+		t.Fatalf("released %d blocks, want 2", len(released))
+	}
+	if st := c.Status(); len(st.Blocks) != 0 || st.PublicBlocks != 0 {
+		t.Fatalf("after release: got %+v", st)
+	}
+}
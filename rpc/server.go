@@ -20,6 +20,7 @@ import (
 	"context"
 	log "github.com/sirupsen/logrus"
 	"io"
+	"net/http"
 	"sync"
 	"sync/atomic"
 )
@@ -208,6 +209,8 @@ type PeerInfo struct {
 		UserAgent string
 		Origin    string
 		Host      string
+		// All headers of the request.
+		Header http.Header
 	}
 }
 
@@ -0,0 +1,225 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var ErrUnknownDelay = errors.New("unknown delay")
+
+// keep released delays listed for a while, and for repeated calls of clients
+// that reconnect.
+const keepReleased = 10 * time.Minute
+
+// Delay holds a hook call of a validator client until Release, the client
+// gets the deadline in the hook response instead of a blocked request.
+type Delay struct {
+	ID        string    `json:"id"`
+	Hook      string    `json:"hook"`
+	Slot      uint64    `json:"slot"`
+	ValIdx    int       `json:"validator_index"` // -1 if the hook has no validator
+	Reason    string    `json:"reason"`
+	Created   time.Time `json:"created"`
+	Release   time.Time `json:"release"`
+	Cancelled bool      `json:"cancelled"`
+
+	done chan struct{} // closed when the delay is cancelled
+}
+
+func (d *Delay) key() string {
+	return delayKey(d.Hook, d.Slot, d.ValIdx)
+}
+
+func delayKey(hook string, slot uint64, valIdx int) string {
+	return fmt.Sprintf("%s/%d/%d", hook, slot, valIdx)
+}
+
+// Store saves the delays so that they survive a restart of the service, see
+// store.Store.
+type Store interface {
+	SaveDelay(d Delay) error
+	DeleteDelay(id string) error
+}
+
+// Scheduler keeps the delays of the hooks. A hook called again for the same
+// slot and validator, eg. after the client reconnects, gets the same delay.
+type Scheduler struct {
+	delays map[string]*Delay // id -> delay
+	byKey  map[string]*Delay
+	nextID uint64
+	store  Store // nil keeps the delays in memory only
+	lock   sync.Mutex
+}
+
+func New() *Scheduler {
+	return &Scheduler{
+		delays: make(map[string]*Delay),
+		byKey:  make(map[string]*Delay),
+	}
+}
+
+// Schedule holds the hook of slot until release and returns the delay, the
+// existing delay is returned if the hook was already delayed.
+func (s *Scheduler) Schedule(hook string, slot uint64, valIdx int, release time.Time, reason string) Delay {
+	now := time.Now()
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.prune(now)
+
+	if d, exist := s.byKey[delayKey(hook, slot, valIdx)]; exist {
+		return *d
+	}
+	s.nextID++
+	d := &Delay{
+		ID:      fmt.Sprintf("%d", s.nextID),
+		Hook:    hook,
+		Slot:    slot,
+		ValIdx:  valIdx,
+		Reason:  reason,
+		Created: now,
+		Release: release,
+		done:    make(chan struct{}),
+	}
+	s.delays[d.ID] = d
+	s.byKey[d.key()] = d
+	s.save(d)
+	return *d
+}
+
+// SetStore saves the delays to st from now on.
+func (s *Scheduler) SetStore(st Store) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.store = st
+}
+
+// Restore adds the delays saved before a restart, the ones released for too
+// long are dropped.
+func (s *Scheduler) Restore(list []Delay) {
+	now := time.Now()
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, saved := range list {
+		d := saved
+		d.done = make(chan struct{})
+		if d.Cancelled {
+			close(d.done)
+		}
+		if id, err := strconv.ParseUint(d.ID, 10, 64); err == nil && id > s.nextID {
+			s.nextID = id
+		}
+		s.delays[d.ID] = &d
+		s.byKey[d.key()] = &d
+	}
+	s.prune(now)
+}
+
+// Find returns the delay of the hook for slot and validator.
+func (s *Scheduler) Find(hook string, slot uint64, valIdx int) (Delay, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	d, exist := s.byKey[delayKey(hook, slot, valIdx)]
+	if !exist {
+		return Delay{}, false
+	}
+	return *d, true
+}
+
+// Cancel releases the delay now.
+func (s *Scheduler) Cancel(id string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	d, exist := s.delays[id]
+	if !exist {
+		return ErrUnknownDelay
+	}
+	if d.Cancelled {
+		return nil
+	}
+	d.Cancelled = true
+	if now := time.Now(); d.Release.After(now) {
+		d.Release = now
+	}
+	close(d.done)
+	s.save(d)
+	return nil
+}
+
+// Get returns the delay with id.
+func (s *Scheduler) Get(id string) (Delay, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	d, exist := s.delays[id]
+	if !exist {
+		return Delay{}, ErrUnknownDelay
+	}
+	return *d, nil
+}
+
+// Wait blocks until the delay is released or cancelled, or ctx is done.
+func (s *Scheduler) Wait(ctx context.Context, id string) (Delay, error) {
+	s.lock.Lock()
+	d, exist := s.delays[id]
+	if !exist {
+		s.lock.Unlock()
+		return Delay{}, ErrUnknownDelay
+	}
+	release, done := d.Release, d.done
+	s.lock.Unlock()
+
+	timer := time.NewTimer(time.Until(release))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-done:
+	case <-ctx.Done():
+		return Delay{}, ctx.Err()
+	}
+	return s.Get(id)
+}
+
+// List returns the pending delays and the recently released ones, ordered by
+// release time.
+func (s *Scheduler) List() []Delay {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.prune(time.Now())
+	list := make([]Delay, 0, len(s.delays))
+	for _, d := range s.delays {
+		list = append(list, *d)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Release.Before(list[j].Release)
+	})
+	return list
+}
+
+func (s *Scheduler) prune(now time.Time) {
+	for id, d := range s.delays {
+		if now.Sub(d.Release) > keepReleased {
+			delete(s.delays, id)
+			delete(s.byKey, d.key())
+			if s.store != nil {
+				if err := s.store.DeleteDelay(id); err != nil {
+					log.WithError(err).WithField("id", id).Warn("delete saved delay failed")
+				}
+			}
+		}
+	}
+}
+
+func (s *Scheduler) save(d *Delay) {
+	if s.store == nil {
+		return
+	}
+	if err := s.store.SaveDelay(*d); err != nil {
+		log.WithError(err).WithField("id", d.ID).Warn("save delay failed")
+	}
+}
@@ -0,0 +1,53 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestScheduler(t *testing.T) {
+	s := New()
+	release := time.Now().Add(time.Hour)
+	d := s.Schedule("block_beforeBroadCast", 10, -1, release, "test")
+	if again := s.Schedule("block_beforeBroadCast", 10, -1, release.Add(time.Hour), "test"); again.ID != d.ID || !again.Release.Equal(release) {
+		t.Fatalf("repeated call got a new delay %+v", again)
+	}
+	if other := s.Schedule("block_beforeBroadCast", 11, -1, release, "test"); other.ID == d.ID {
+		t.Fatalf("another slot got the same delay")
+	}
+	if list := s.List(); len(list) != 2 {
+		t.Fatalf("got %d delays, want 2", len(list))
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := s.Wait(context.Background(), d.ID)
+		done <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	if err := s.Cancel(d.ID); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("wait not released by cancel")
+	}
+	if got, _ := s.Get(d.ID); !got.Cancelled || got.Release.After(time.Now()) {
+		t.Fatalf("cancelled delay: %+v", got)
+	}
+
+	short := s.Schedule("attest_beforeBroadCast", 10, -1, time.Now().Add(20*time.Millisecond), "test")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, err := s.Wait(ctx, short.ID); err != nil {
+		t.Fatalf("wait for short delay: %v", err)
+	}
+	if err := s.Cancel("unknown"); err != ErrUnknownDelay {
+		t.Fatalf("got %v, want ErrUnknownDelay", err)
+	}
+}
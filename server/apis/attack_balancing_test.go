@@ -0,0 +1,74 @@
+package apis
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	ethpb "github.com/prysmaticlabs/prysm/v4/proto/prysm/v1alpha1"
+	"github.com/tsinghua-cel/attacker-service/beaconapi"
+	"github.com/tsinghua-cel/attacker-service/types"
+	"google.golang.org/protobuf/proto"
+)
+
+// balancingBackend makes every validator an attacker and gives a fork
+// choice.
+type balancingBackend struct {
+	Backend
+	fc beaconapi.ForkChoice
+}
+
+func (b *balancingBackend) GetValidatorRoleByPubkey(slot int, pubkey string) types.RoleType {
+	return types.AttackerRole
+}
+
+func (b *balancingBackend) GetForkChoice() (beaconapi.ForkChoice, error) {
+	return b.fc, nil
+}
+
+func (b *balancingBackend) SlotsPerEpoch() int { return 32 }
+
+func root(b byte) string {
+	r := make([]byte, 32)
+	r[0] = b
+	return hexutil.Encode(r)
+}
+
+func TestBalancingAttack(t *testing.T) {
+	// 0x01 (slot 31) forks into 0x02 (slot 33) and 0x03 (slot 34), 0x02 is
+	// ahead by one vote and has a child 0x04.
+	b := &balancingBackend{fc: beaconapi.ForkChoice{Nodes: []beaconapi.ForkChoiceNode{
+		{Slot: "31", BlockRoot: root(1), ParentRoot: root(0), Weight: "160000000000"},
+		{Slot: "33", BlockRoot: root(2), ParentRoot: root(1), Weight: "96000000000"},
+		{Slot: "34", BlockRoot: root(3), ParentRoot: root(1), Weight: "64000000000"},
+		{Slot: "35", BlockRoot: root(4), ParentRoot: root(2), Weight: "96000000000"},
+	}}}
+	a := &balancingAttack{given: make(map[uint64]map[string]int)}
+	heads := make(map[string]int)
+	for i := 0; i < 5; i++ {
+		data := &ethpb.AttestationData{
+			Slot:            35,
+			BeaconBlockRoot: make([]byte, 32),
+			Source:          &ethpb.Checkpoint{Root: make([]byte, 32)},
+			Target:          &ethpb.Checkpoint{Epoch: 1, Root: make([]byte, 32)},
+		}
+		raw, err := proto.Marshal(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res := a.AttestBeforeSign(b, 35, "0x01", base64.StdEncoding.EncodeToString(raw))
+		if err := decodeProto(res.Result, data); err != nil {
+			t.Fatal(err)
+		}
+		head := hexutil.Encode(data.BeaconBlockRoot)
+		heads[head]++
+		// the target is the epoch boundary block, the fork point.
+		if target := hexutil.Encode(data.Target.Root); target != root(1) {
+			t.Fatalf("vote %d target: got %s", i, target)
+		}
+	}
+	// the first vote evens the branches, then they alternate.
+	if heads[root(3)] != 3 || heads[root(4)] != 2 {
+		t.Fatalf("votes per head: got %v", heads)
+	}
+}
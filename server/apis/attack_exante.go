@@ -0,0 +1,78 @@
+package apis
+
+import (
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/tsinghua-cel/attacker-service/types"
+)
+
+func init() {
+	RegisterAttack(&exAnteAttack{held: make(map[uint64]string)})
+}
+
+// exAnteAttack is the ex-ante reorg: an attacker proposer followed by an
+// honest one packs the attacker attestations into its block and withholds it
+// until the honest block of the next slot is out, so the two blocks compete
+// and the honest one can be reorged.
+type exAnteAttack struct {
+	BaseAttack
+	held map[uint64]string // slot -> delay of the withheld block
+	lock sync.Mutex
+}
+
+func (*exAnteAttack) Name() string {
+	return "ex_ante"
+}
+
+// isReorgSlot returns true if slot is proposed by an attacker and the next
+// slot by an honest validator.
+func (*exAnteAttack) isReorgSlot(b Backend, slot uint64, pubkey string) bool {
+	return isAttackerProposer(b, slot, pubkey) && !isAttackerProposer(b, slot+1, "")
+}
+
+func (a *exAnteAttack) BlockBeforeSign(b Backend, slot uint64, pubkey string, blockDataBase64 string) types.AttackerResponse {
+	if !a.isReorgSlot(b, slot, pubkey) {
+		return a.BaseAttack.BlockBeforeSign(b, slot, pubkey, blockDataBase64)
+	}
+	return packAttackerAttestations(b, slot, blockDataBase64)
+}
+
+// BlockBeforeBroadCast holds the block until the honest block of the next
+// slot is seen, at most until the end of the next slot.
+func (a *exAnteAttack) BlockBeforeBroadCast(b Backend, slot uint64) types.AttackerResponse {
+	if !a.isReorgSlot(b, slot, "") {
+		return types.AttackerResponse{
+			Cmd: types.CMD_NULL,
+		}
+	}
+	res := delayUntilSlot(b, "block_beforeBroadCast", slot, slot+2, "ex-ante reorg, wait for the honest block", types.CMD_NULL)
+	a.lock.Lock()
+	a.held[slot] = res.Delay.ID
+	a.lock.Unlock()
+	return res
+}
+
+// OnBlock releases the withheld blocks once a block of a later slot, the
+// honest one, is seen.
+func (a *exAnteAttack) OnBlock(b Backend, slot uint64, root string) {
+	a.lock.Lock()
+	released := make(map[uint64]string)
+	for held, id := range a.held {
+		if held < slot {
+			released[held] = id
+			delete(a.held, held)
+		}
+	}
+	a.lock.Unlock()
+	for held, id := range released {
+		if err := b.GetDelayScheduler().Cancel(id); err != nil {
+			log.WithError(err).WithField("delay", id).Warn("release ex-ante block failed")
+			continue
+		}
+		log.WithFields(log.Fields{
+			"slot":   held,
+			"honest": root,
+		}).Info("ex-ante reorg, release withheld block")
+	}
+}
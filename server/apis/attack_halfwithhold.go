@@ -0,0 +1,42 @@
+package apis
+
+import (
+	log "github.com/sirupsen/logrus"
+	"github.com/tsinghua-cel/attacker-service/types"
+)
+
+func init() {
+	RegisterAttack(halfWithholdAttack{})
+}
+
+// halfWithholdAttack keeps the attacker proposers honest and withholds the
+// attestations of the attackers with an odd index, the even ones publish
+// theirs, so the honest validators only see half of the attacker votes.
+type halfWithholdAttack struct {
+	BaseAttack
+}
+
+func (halfWithholdAttack) Name() string {
+	return "half_withhold"
+}
+
+func (halfWithholdAttack) AttestBeforePropose(b Backend, slot uint64, pubkey string, signedAttestDataBase64 string) types.AttackerResponse {
+	val := b.GetValidatorDataSet().GetValidatorByPubkey(pubkey)
+	if val == nil || b.GetValidatorRole(int(slot), int(val.Index)) != types.AttackerRole {
+		return types.AttackerResponse{
+			Cmd: types.CMD_NULL,
+		}
+	}
+	if val.Index%2 == 1 {
+		log.WithFields(log.Fields{
+			"slot":   slot,
+			"valIdx": val.Index,
+		}).Debug("half withhold attack, withhold attest")
+		return types.AttackerResponse{
+			Cmd: types.CMD_RETURN,
+		}
+	}
+	return types.AttackerResponse{
+		Cmd: types.CMD_NULL,
+	}
+}
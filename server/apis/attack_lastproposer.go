@@ -0,0 +1,153 @@
+package apis
+
+import (
+	log "github.com/sirupsen/logrus"
+	"github.com/tsinghua-cel/attacker-service/types"
+)
+
+func init() {
+	RegisterAttack(lastProposerAttack{})
+}
+
+// lastProposerAttack only lets the last attacker proposer of each epoch build
+// a block, which packs the attestations withheld by the attackers and is
+// withheld until the end of the next epoch.
+type lastProposerAttack struct {
+	BaseAttack
+}
+
+func (lastProposerAttack) Name() string {
+	return DefaultAttack
+}
+
+// isLatestAttackerSlot returns (attacker, latest), attacker is true if the
+// proposer of slot is an attacker, latest is true if slot is the last slot of
+// the epoch proposed by an attacker.
+func (lastProposerAttack) isLatestAttackerSlot(b Backend, slot uint64, pubkey string) (bool, bool) {
+	valIdx, ok := proposerAt(b, slot, pubkey)
+	if !ok {
+		return false, false
+	}
+	role := b.GetValidatorRole(int(slot), valIdx)
+	log.WithFields(log.Fields{
+		"slot":   slot,
+		"valIdx": valIdx,
+		"role":   role,
+	}).Info("get validator by propose slot")
+	if role != types.AttackerRole {
+		return false, false
+	}
+	latestSlotWithAttacker, err := latestAttackerSlot(b, slot)
+	if err != nil {
+		return false, false
+	}
+	log.WithFields(log.Fields{
+		"slot":               slot,
+		"latestAttackerSlot": latestSlotWithAttacker,
+	}).Info("latest attacker slot")
+	return true, slot == uint64(latestSlotWithAttacker)
+}
+
+func (a lastProposerAttack) DelayForReceiveBlock(b Backend, slot uint64) types.AttackerResponse {
+	attacker, latest := a.isLatestAttackerSlot(b, slot, "")
+	if !attacker {
+		return types.AttackerResponse{
+			Cmd: types.CMD_NULL,
+		}
+	}
+	if !latest {
+		// 不是最后一个出块的恶意节点，不出块
+		return types.AttackerResponse{
+			Cmd: types.CMD_RETURN,
+		}
+	}
+	// 当前是最后一个出块的恶意节点，进行延时
+	epochSlots := uint64(b.GetSlotsPerEpoch())
+	delay := int(epochSlots - slot%epochSlots)
+	valIdx, _ := proposerAt(b, slot, "")
+	log.WithFields(log.Fields{
+		"slot":   slot,
+		"validx": valIdx,
+		"slots":  delay,
+	}).Info("delay for receive block")
+
+	return delayUntilSlot(b, "block_delayForReceiveBlock", slot, slot+uint64(delay), "last proposer receive block", types.CMD_UPDATE_STATE)
+}
+
+func (a lastProposerAttack) BlockBeforeBroadCast(b Backend, slot uint64) types.AttackerResponse {
+	attacker, latest := a.isLatestAttackerSlot(b, slot, "")
+	if !attacker {
+		return types.AttackerResponse{
+			Cmd: types.CMD_NULL,
+		}
+	}
+	if !latest {
+		// 不是最后一个出块的恶意节点，不出块
+		return types.AttackerResponse{
+			Cmd: types.CMD_RETURN,
+		}
+	}
+	// 当前是最后一个出块的恶意节点，进行延时
+	valIdx, _ := proposerAt(b, slot, "")
+	// the delay of DelayForReceiveBlock is kept by the scheduler, which saves
+	// it to the database if any.
+	lastDelay := 0
+	if _, exist := b.GetDelayScheduler().Find("block_delayForReceiveBlock", slot, -1); exist {
+		epochSlots := uint64(b.GetSlotsPerEpoch())
+		lastDelay = int(epochSlots - slot%epochSlots)
+	}
+	// the block is received after lastDelay slots, then held for 12 slots
+	// and lastDelay slots more.
+	release := slot + uint64(2*lastDelay+12)
+	log.WithFields(log.Fields{
+		"slot":        slot,
+		"validx":      valIdx,
+		"releaseSlot": release,
+	}).Info("delay for beforeBroadcastBlock")
+
+	return delayUntilSlot(b, "block_beforeBroadCast", slot, release, "last proposer broadcast block", types.CMD_NULL)
+}
+
+func (a lastProposerAttack) BlockBeforeMakeBlock(b Backend, slot uint64, pubkey string) types.AttackerResponse {
+	// 1. 只有每个epoch最后一个出块的恶意节点出块，其他节点不出快
+	if attacker, latest := a.isLatestAttackerSlot(b, slot, pubkey); attacker && !latest {
+		// 不是最后一个恶意的出块，不出块
+		return types.AttackerResponse{
+			Cmd: types.CMD_RETURN,
+		}
+	}
+	return types.AttackerResponse{
+		Cmd: types.CMD_NULL,
+	}
+}
+
+func (a lastProposerAttack) BlockBeforeSign(b Backend, slot uint64, pubkey string, blockDataBase64 string) types.AttackerResponse {
+	attacker, latest := a.isLatestAttackerSlot(b, slot, pubkey)
+	if !attacker {
+		return types.AttackerResponse{
+			Cmd:    types.CMD_NULL,
+			Result: blockDataBase64,
+		}
+	}
+	if !latest {
+		// 不是最后一个出块的恶意节点，不出块
+		return types.AttackerResponse{
+			Cmd:    types.CMD_RETURN,
+			Result: blockDataBase64,
+		}
+	}
+	return packAttackerAttestations(b, slot, blockDataBase64)
+}
+
+func (a lastProposerAttack) BlockAfterSign(b Backend, slot uint64, pubkey string, signedBlockDataBase64 string) types.AttackerResponse {
+	if attacker, latest := a.isLatestAttackerSlot(b, slot, pubkey); attacker && !latest {
+		// 不是最后一个恶意的出块，不出块
+		return types.AttackerResponse{
+			Cmd: types.CMD_RETURN,
+		}
+	}
+	return types.AttackerResponse{
+		Cmd:    types.CMD_NULL,
+		Result: signedBlockDataBase64,
+	}
+}
@@ -0,0 +1,42 @@
+package apis
+
+import "github.com/tsinghua-cel/attacker-service/types"
+
+func init() {
+	RegisterAttack(sandwichAttack{})
+}
+
+// sandwichAttack is the sandwich reorg: when an honest proposer is between
+// two attacker proposers, the first attacker block is withheld and released
+// with the second one, so the honest block in the middle is reorged.
+type sandwichAttack struct {
+	BaseAttack
+}
+
+func (sandwichAttack) Name() string {
+	return "sandwich"
+}
+
+// isSandwichStart returns true if slot, slot+1 and slot+2 are proposed by
+// attacker, honest and attacker validators.
+func (sandwichAttack) isSandwichStart(b Backend, slot uint64, pubkey string) bool {
+	return isAttackerProposer(b, slot, pubkey) &&
+		!isAttackerProposer(b, slot+1, "") &&
+		isAttackerProposer(b, slot+2, "")
+}
+
+func (a sandwichAttack) BlockBeforeSign(b Backend, slot uint64, pubkey string, blockDataBase64 string) types.AttackerResponse {
+	if !a.isSandwichStart(b, slot, pubkey) {
+		return a.BaseAttack.BlockBeforeSign(b, slot, pubkey, blockDataBase64)
+	}
+	return packAttackerAttestations(b, slot, blockDataBase64)
+}
+
+func (a sandwichAttack) BlockBeforeBroadCast(b Backend, slot uint64) types.AttackerResponse {
+	if !a.isSandwichStart(b, slot, "") {
+		return types.AttackerResponse{
+			Cmd: types.CMD_NULL,
+		}
+	}
+	return delayUntilSlot(b, "block_beforeBroadCast", slot, slot+2, "sandwich reorg, release withheld block", types.CMD_NULL)
+}
@@ -0,0 +1,26 @@
+package apis
+
+import "github.com/tsinghua-cel/attacker-service/types"
+
+func init() {
+	RegisterAttack(withholdAttack{})
+}
+
+// withholdAttack lets every attacker proposer build its block, and holds the
+// block for one slot before broadcasting it.
+type withholdAttack struct {
+	BaseAttack
+}
+
+func (withholdAttack) Name() string {
+	return "withhold"
+}
+
+func (withholdAttack) BlockBeforeBroadCast(b Backend, slot uint64) types.AttackerResponse {
+	if !isAttackerProposer(b, slot, "") {
+		return types.AttackerResponse{
+			Cmd: types.CMD_NULL,
+		}
+	}
+	return delayUntilSlot(b, "block_beforeBroadCast", slot, slot+1, "withhold block", types.CMD_NULL)
+}
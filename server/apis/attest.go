@@ -1,13 +1,16 @@
 package apis
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	ethpb "github.com/prysmaticlabs/prysm/v4/proto/prysm/v1alpha1"
 	log "github.com/sirupsen/logrus"
+	"github.com/tsinghua-cel/attacker-service/luascripts"
 	"github.com/tsinghua-cel/attacker-service/strategy"
 	"github.com/tsinghua-cel/attacker-service/types"
 	"google.golang.org/protobuf/proto"
+	"time"
 )
 
 // AttestAPI offers and API for attestation operations.
@@ -26,35 +29,93 @@ func (s *AttestAPI) GetStrategy() []byte {
 }
 
 func (s *AttestAPI) UpdateStrategy(data []byte) error {
-	var attestStrategy strategy.AttestStrategy
-	if err := json.Unmarshal(data, &attestStrategy); err != nil {
+	// decode into a copy, the active strategy stays unchanged if the update
+	// is rejected.
+	var updated strategy.AttestStrategy
+	err := s.b.UpdateStrategy(func(st *strategy.Strategy) error {
+		if err := json.Unmarshal(data, &st.Attest); err != nil {
+			return err
+		}
+		updated = st.Attest
+		return nil
+	})
+	if err != nil {
 		return err
 	}
-	s.b.GetStrategy().Attest = attestStrategy
-	log.Infof("attest strategy updated to %v\n", attestStrategy)
+	log.Infof("attest strategy updated to %v\n", updated)
 	return nil
 }
 
-func (s *AttestAPI) BeforeBroadCast(slot uint64) types.AttackerResponse {
-	return types.AttackerResponse{
-		Cmd: types.CMD_NULL,
+func (s *AttestAPI) BeforeBroadCast(ctx context.Context, slot uint64) types.AttackerResponse {
+	trackClient(ctx, s.b, "attest_beforeBroadCast", slot, "")
+	as := s.b.GetStrategy().AttestStrategyAt(int64(slot))
+	if as.DelayEnable {
+		res := delayFor(s.b, "attest_beforeBroadCast", slot, time.Millisecond*time.Duration(as.BroadCastDelay), "attest broadcast delay", types.CMD_NULL)
+		return applyCommand(s.b, "attest_beforeBroadCast", slot, "", res)
 	}
+	return applyCommand(s.b, "attest_beforeBroadCast", slot, "", types.AttackerResponse{
+		Cmd: types.CMD_NULL,
+	})
 }
 
-func (s *AttestAPI) AfterBroadCast(slot uint64) types.AttackerResponse {
-	return types.AttackerResponse{
+func (s *AttestAPI) AfterBroadCast(ctx context.Context, slot uint64) types.AttackerResponse {
+	trackClient(ctx, s.b, "attest_afterBroadCast", slot, "")
+	return applyCommand(s.b, "attest_afterBroadCast", slot, "", types.AttackerResponse{
 		Cmd: types.CMD_NULL,
+	})
+}
+
+func (s *AttestAPI) BeforeSign(ctx context.Context, slot uint64, pubkey string, attestDataBase64 string) types.AttackerResponse {
+	trackClient(ctx, s.b, "attest_beforeSign", slot, pubkey)
+	res := s.beforeSign(slot, pubkey, attestDataBase64)
+	if res.Cmd == types.CMD_NULL {
+		res = activeAttack(s.b, slot).AttestBeforeSign(s.b, slot, pubkey, res.Result)
 	}
+	res = runAttestScript(s.b, luascripts.AttestBeforeSign, slot, pubkey, attestDataBase64, new(ethpb.AttestationData), res)
+	return applyCommand(s.b, "attest_beforeSign", slot, pubkey, res)
 }
 
-func (s *AttestAPI) BeforeSign(slot uint64, pubkey string, attestDataBase64 string) types.AttackerResponse {
+func (s *AttestAPI) beforeSign(slot uint64, pubkey string, attestDataBase64 string) types.AttackerResponse {
+	data := new(ethpb.AttestationData)
+	if err := decodeProto(attestDataBase64, data); err != nil {
+		log.WithError(err).Error("decode attest data failed")
+		return types.AttackerResponse{
+			Cmd:    types.CMD_NULL,
+			Result: attestDataBase64,
+		}
+	}
+	modified, err := modifyVote(s.b, slot, pubkey, data)
+	if err != nil {
+		log.WithError(err).WithField("slot", slot).Error("modify attest vote failed")
+	}
+	if !modified || err != nil {
+		return types.AttackerResponse{
+			Cmd:    types.CMD_NULL,
+			Result: attestDataBase64,
+		}
+	}
+	d, err := proto.Marshal(data)
+	if err != nil {
+		log.WithError(err).Error("marshal attest data failed")
+		return types.AttackerResponse{
+			Cmd:    types.CMD_NULL,
+			Result: attestDataBase64,
+		}
+	}
 	return types.AttackerResponse{
 		Cmd:    types.CMD_NULL,
-		Result: attestDataBase64,
+		Result: base64.StdEncoding.EncodeToString(d),
 	}
 }
 
-func (s *AttestAPI) AfterSign(slot uint64, pubkey string, signedAttestDataBase64 string) types.AttackerResponse {
+func (s *AttestAPI) AfterSign(ctx context.Context, slot uint64, pubkey string, signedAttestDataBase64 string) types.AttackerResponse {
+	trackClient(ctx, s.b, "attest_afterSign", slot, pubkey)
+	res := s.afterSign(slot, pubkey, signedAttestDataBase64)
+	res = runAttestScript(s.b, luascripts.AttestAfterSign, slot, pubkey, signedAttestDataBase64, new(ethpb.Attestation), res)
+	return applyCommand(s.b, "attest_afterSign", slot, pubkey, res)
+}
+
+func (s *AttestAPI) afterSign(slot uint64, pubkey string, signedAttestDataBase64 string) types.AttackerResponse {
 	signedAttestData, err := base64.StdEncoding.DecodeString(signedAttestDataBase64)
 	if err != nil {
 		log.WithError(err).Error("base64 decode attest data failed")
@@ -63,12 +124,7 @@ func (s *AttestAPI) AfterSign(slot uint64, pubkey string, signedAttestDataBase64
 			Result: signedAttestDataBase64,
 		}
 	}
-	if role := s.b.GetValidatorRoleByPubkey(int(slot), pubkey); role == types.NormalRole {
-		return types.AttackerResponse{
-			Cmd:    types.CMD_NULL,
-			Result: signedAttestDataBase64,
-		}
-	}
+	// attestations of all validators are kept, queries filter attackers by role.
 	var attest = new(ethpb.Attestation)
 	if err := proto.Unmarshal(signedAttestData, attest); err != nil {
 		log.WithError(err).Error("unmarshal attest data failed")
@@ -89,27 +145,20 @@ func (s *AttestAPI) AfterSign(slot uint64, pubkey string, signedAttestDataBase64
 	}
 }
 
-func (s *AttestAPI) BeforePropose(slot uint64, pubkey string, signedAttestDataBase64 string) types.AttackerResponse {
-	isAttacker := false
-	if s.b.GetValidatorRoleByPubkey(int(slot), pubkey) == types.AttackerRole {
-		isAttacker = true
-	}
-
-	if isAttacker { // 所有的恶意节点不广播Attestation.
-		log.WithFields(log.Fields{}).Debug("this is attacker, not broadcast attest")
-		return types.AttackerResponse{
-			Cmd: types.CMD_RETURN,
-		}
-	} else {
-		return types.AttackerResponse{
-			Cmd: types.CMD_NULL,
-		}
+func (s *AttestAPI) BeforePropose(ctx context.Context, slot uint64, pubkey string, signedAttestDataBase64 string) types.AttackerResponse {
+	trackClient(ctx, s.b, "attest_beforePropose", slot, pubkey)
+	res := activeAttack(s.b, slot).AttestBeforePropose(s.b, slot, pubkey, signedAttestDataBase64)
+	res = applyCommand(s.b, "attest_beforePropose", slot, pubkey, res)
+	if res.Cmd == types.CMD_RETURN && res.Delay == nil {
+		withholdAttestation(s.b, slot, pubkey, signedAttestDataBase64)
 	}
+	return res
 }
 
-func (s *AttestAPI) AfterPropose(slot uint64, pubkey string, signedAttestDataBase64 string) types.AttackerResponse {
-	return types.AttackerResponse{
+func (s *AttestAPI) AfterPropose(ctx context.Context, slot uint64, pubkey string, signedAttestDataBase64 string) types.AttackerResponse {
+	trackClient(ctx, s.b, "attest_afterPropose", slot, pubkey)
+	return applyCommand(s.b, "attest_afterPropose", slot, pubkey, types.AttackerResponse{
 		Cmd:    types.CMD_NULL,
 		Result: signedAttestDataBase64,
-	}
+	})
 }
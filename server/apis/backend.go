@@ -4,10 +4,16 @@ import (
 	"github.com/ethereum/go-ethereum/core/types"
 	ethpb "github.com/prysmaticlabs/prysm/v4/proto/prysm/v1alpha1"
 	"github.com/tsinghua-cel/attacker-service/beaconapi"
+	"github.com/tsinghua-cel/attacker-service/duties"
+	"github.com/tsinghua-cel/attacker-service/luascripts"
+	"github.com/tsinghua-cel/attacker-service/privatechain"
 	"github.com/tsinghua-cel/attacker-service/rpc"
+	"github.com/tsinghua-cel/attacker-service/scheduler"
+	"github.com/tsinghua-cel/attacker-service/slotclock"
 	"github.com/tsinghua-cel/attacker-service/strategy"
 	types2 "github.com/tsinghua-cel/attacker-service/types"
 	"github.com/tsinghua-cel/attacker-service/validatorSet"
+	"github.com/tsinghua-cel/attacker-service/withheld"
 	"math/big"
 )
 
@@ -17,27 +23,63 @@ type Backend interface {
 	SomeNeedBackend() bool
 	// update strategy
 	GetStrategy() *strategy.Strategy
+	SetStrategy(st *strategy.Strategy) error
+	// applies update to a copy of the strategy and sets it, atomically.
+	UpdateStrategy(update func(st *strategy.Strategy) error) error
 	UpdateBlockBroadDelay(milliSecond int64) error
 	UpdateAttestBroadDelay(milliSecond int64) error
+	// lua script engine, nil if no script configured.
+	GetScriptEngine() *luascripts.Engine
 
 	// get data from execute node.
 	GetBlockHeight() (uint64, error)
 	GetBlockByNumber(number *big.Int) (*types.Block, error)
 	GetHeightByNumber(number *big.Int) (*types.Header, error)
 
+	GetCurrentSlot() (int64, error)
+	// slot clock from the genesis, error until the beacon node gave it.
+	GetSlotClock() (*slotclock.SlotClock, error)
+	// delays of the hooks, the clients wait for them.
+	GetDelayScheduler() *scheduler.Scheduler
+	GetBeaconHeader(blockId string) (beaconapi.BeaconHeaderInfo, error)
+	// chain state of the preferred beacon node, blockId and stateId are a
+	// slot, a hex root, or one of "head", "genesis", "finalized" and
+	// "justified".
+	GetBeaconHeadersBySlot(slot uint64) ([]beaconapi.BeaconHeaderInfo, error)
+	GetFinalityCheckpoints(stateId string) (beaconapi.FinalityCheckpoints, error)
+	GetCommittees(stateId string, filter beaconapi.CommitteeFilter) ([]beaconapi.Committee, error)
+	GetForkChoice() (beaconapi.ForkChoice, error)
+	// status of the beacon nodes, and a client asking only one of them, given
+	// by its endpoint or index, to see the chain as this node sees it. An
+	// empty node is the client failing over between all nodes.
+	GetBeaconNodes() []beaconapi.NodeStatus
+	GetBeaconView(node string) (*beaconapi.BeaconGwClient, error)
 	GetValidatorRole(slot int, valIdx int) types2.RoleType
 	GetValidatorRoleByPubkey(slot int, pubkey string) types2.RoleType
+	// runtime role overrides, they take precedence over the strategy.
+	SetRoleOverride(o validatorSet.RoleOverride) error
+	RemoveRoleOverride(valIdx int) error
+	GetRoleOverrides() []validatorSet.RoleOverride
+	// registry of the validator clients calling the hooks.
+	TrackClient(info types2.ClientInfo, remoteAddr string, method string, slot uint64, valIdx int)
+	GetClients() []*validatorSet.ClientSession
 	GetCurrentEpochProposeDuties() ([]beaconapi.ProposerDuty, error)
 	GetSlotsPerEpoch() int
 	SlotsPerEpoch() int
 	GetIntervalPerSlot() int
 	AddSignedAttestation(slot uint64, pubkey string, attestation *ethpb.Attestation)
 	AddSignedBlock(slot uint64, pubkey string, block *ethpb.GenericSignedBeaconBlock)
-	GetAttestSet(slot uint64) *validatorSet.SlotAttestSet
-	GetBlockSet(slot uint64) *validatorSet.SlotBlockSet
 	GetValidatorDataSet() *validatorSet.ValidatorDataSet
 	GetValidatorByProposeSlot(slot uint64) (int, error)
 	GetProposeDuties(epoch int) ([]beaconapi.ProposerDuty, error)
+	// duties per epoch, reloaded when their dependent root changes.
+	GetDutyCache() *duties.Cache
+	// events of the beacon node, subscribe to react to a new head or a reorg.
+	GetEventStream() *beaconapi.EventStream
+	// withheld attacker blocks, released together.
+	GetPrivateChain() *privatechain.Chain
+	// attestations withheld by attackers until a release rule triggers.
+	GetWithheldAttestations() *withheld.Pool
 }
 
 func GetAPIs(apiBackend Backend) []rpc.API {
@@ -54,5 +96,9 @@ func GetAPIs(apiBackend Backend) []rpc.API {
 			Namespace: "attest",
 			Service:   NewAttestAPI(apiBackend),
 		},
+		{
+			Namespace: "delay",
+			Service:   NewDelayAPI(apiBackend),
+		},
 	}
 }
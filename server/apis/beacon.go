@@ -0,0 +1,46 @@
+package apis
+
+import (
+	"github.com/tsinghua-cel/attacker-service/beaconapi"
+)
+
+// BeaconNodesInfo is the status of the beacon nodes and how they disagree.
+type BeaconNodesInfo struct {
+	Nodes      []beaconapi.NodeStatus `json:"nodes"`
+	Divergence []string               `json:"divergence"`
+}
+
+// BeaconNodes returns the status of the beacon nodes from the last check.
+func (s *AdminAPI) BeaconNodes() BeaconNodesInfo {
+	nodes := s.b.GetBeaconNodes()
+	return BeaconNodesInfo{
+		Nodes:      nodes,
+		Divergence: beaconapi.Divergence(nodes),
+	}
+}
+
+// BeaconHeader returns the header of blockId as the beacon node sees it,
+// node is an endpoint or index of beacon_rpc, the preferred node if omitted.
+func (s *AdminAPI) BeaconHeader(blockId string, node *string) (beaconapi.BeaconHeaderInfo, error) {
+	if node == nil {
+		return s.b.GetBeaconHeader(blockId)
+	}
+	view, err := s.b.GetBeaconView(*node)
+	if err != nil {
+		return beaconapi.BeaconHeaderInfo{}, err
+	}
+	return view.GetBeaconHeader(blockId)
+}
+
+// ForkChoice returns the fork choice store of the beacon node, node is an
+// endpoint or index of beacon_rpc, the preferred node if omitted.
+func (s *AdminAPI) ForkChoice(node *string) (beaconapi.ForkChoice, error) {
+	if node == nil {
+		return s.b.GetForkChoice()
+	}
+	view, err := s.b.GetBeaconView(*node)
+	if err != nil {
+		return beaconapi.ForkChoice{}, err
+	}
+	return view.GetForkChoice()
+}
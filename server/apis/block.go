@@ -1,28 +1,20 @@
 package apis
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/json"
-	"errors"
-	"fmt"
-	"strconv"
 	"time"
 
-	"github.com/prysmaticlabs/prysm/v4/cache/lru"
 	ethpb "github.com/prysmaticlabs/prysm/v4/proto/prysm/v1alpha1"
 	attaggregation "github.com/prysmaticlabs/prysm/v4/proto/prysm/v1alpha1/attestation/aggregation/attestations"
 	log "github.com/sirupsen/logrus"
+	"github.com/tsinghua-cel/attacker-service/luascripts"
 	"github.com/tsinghua-cel/attacker-service/strategy"
 	"github.com/tsinghua-cel/attacker-service/types"
 	"google.golang.org/protobuf/proto"
 )
 
-var (
-	ErrNilObject              = errors.New("nil object")
-	ErrUnsupportedBeaconBlock = errors.New("unsupported beacon block")
-	blockCacheContent         = lru.New(1000)
-)
-
 // BlockAPI offers and API for block operations.
 type BlockAPI struct {
 	b Backend
@@ -39,94 +31,51 @@ func (s *BlockAPI) GetStrategy(cliInfo string) []byte {
 }
 
 func (s *BlockAPI) UpdateStrategy(data []byte) error {
-	var blockStrategy strategy.BlockStrategy
-	if err := json.Unmarshal(data, &blockStrategy); err != nil {
+	// decode into a copy, the active strategy stays unchanged if the update
+	// is rejected.
+	var updated strategy.BlockStrategy
+	err := s.b.UpdateStrategy(func(st *strategy.Strategy) error {
+		if err := json.Unmarshal(data, &st.Block); err != nil {
+			return err
+		}
+		updated = st.Block
+		return nil
+	})
+	if err != nil {
 		return err
 	}
-	s.b.GetStrategy().Block = blockStrategy
-	log.Infof("block strategy updated to %v\n", blockStrategy)
+	log.Infof("block strategy updated to %v\n", updated)
 	return nil
 }
 
 func (s *BlockAPI) BroadCastDelay() types.AttackerResponse {
-	bs := s.b.GetStrategy().Block
+	st := s.b.GetStrategy()
+	bs := st.Block
+	slot, err := s.b.GetCurrentSlot()
+	if err == nil {
+		bs = st.BlockStrategyAt(slot)
+	} else {
+		slot = 0
+	}
 	if !bs.DelayEnable {
 		return types.AttackerResponse{
 			Cmd: types.CMD_NULL,
 		}
 	}
-	time.Sleep(time.Millisecond * time.Duration(s.b.GetStrategy().Block.BroadCastDelay))
-	return types.AttackerResponse{
-		Cmd: types.CMD_NULL,
-	}
+	return delayFor(s.b, "block_broadCastDelay", uint64(slot), time.Millisecond*time.Duration(bs.BroadCastDelay), "block broadcast delay", types.CMD_NULL)
 }
 
-func (s *BlockAPI) modifyBlock(slot uint64, pubkey string, blockDataBase64 string) types.AttackerResponse {
-	// 1. 只有每个epoch最后一个出块的恶意节点出块，其他节点不出快
-	valIdx, err := s.b.GetValidatorByProposeSlot(slot)
-	if err != nil {
-		val := s.b.GetValidatorDataSet().GetValidatorByPubkey(pubkey)
-		if val == nil {
-			return types.AttackerResponse{
-				Cmd:    types.CMD_NULL,
-				Result: blockDataBase64,
-			}
-		}
-		valIdx = int(val.Index)
-	}
-	role := s.b.GetValidatorRole(int(slot), valIdx)
-	log.WithFields(log.Fields{
-		"slot":   slot,
-		"valIdx": valIdx,
-		"role":   role,
-	}).Info("in modify block, get validator by propose slot")
-
-	if role != types.AttackerRole {
+// packAttackerAttestations adds the attestations withheld by attackers in the
+// epoch of slot to the block.
+func packAttackerAttestations(b Backend, slot uint64, blockDataBase64 string) types.AttackerResponse {
+	if b.GetStrategy().BlockStrategyAt(int64(slot)).AttestInclude == strategy.IncludeNone {
 		return types.AttackerResponse{
 			Cmd:    types.CMD_NULL,
 			Result: blockDataBase64,
 		}
 	}
-	epoch := SlotTool{s.b}.SlotToEpoch(int(slot))
 
-	duties, err := s.b.GetProposeDuties(int(epoch))
-	if err != nil {
-		return types.AttackerResponse{
-			Cmd:    types.CMD_NULL,
-			Result: blockDataBase64,
-		}
-	}
-
-	latestSlotWithAttacker := int64(-1)
-	for _, duty := range duties {
-		dutySlot, _ := strconv.ParseInt(duty.Slot, 10, 64)
-		dutyValIdx, _ := strconv.Atoi(duty.ValidatorIndex)
-		if s.b.GetValidatorRole(int(slot), dutyValIdx) == types.AttackerRole && dutySlot > latestSlotWithAttacker {
-			latestSlotWithAttacker = dutySlot
-		}
-	}
-	log.WithFields(log.Fields{
-		"slot":               slot,
-		"latestAttackerSlot": latestSlotWithAttacker,
-	}).Info("modify block")
-
-	if slot != uint64(latestSlotWithAttacker) {
-		// 不是最后一个出块的恶意节点，不出块
-		return types.AttackerResponse{
-			Cmd:    types.CMD_RETURN,
-			Result: blockDataBase64,
-		}
-	}
-
-	genericBlock, err := s.getGenericSignedBlockFromData(blockDataBase64)
-	if err != nil {
-		log.WithError(err).Error("get block from data failed")
-		return types.AttackerResponse{
-			Cmd:    types.CMD_NULL,
-			Result: blockDataBase64,
-		}
-	}
-	block, err := s.getCapellaBlockFromGenericSigned(genericBlock)
+	block, err := getSignedBlockFromData(blockDataBase64)
 	if err != nil {
 		log.WithError(err).Error("get block from data failed")
 		return types.AttackerResponse{
@@ -136,27 +85,14 @@ func (s *BlockAPI) modifyBlock(slot uint64, pubkey string, blockDataBase64 strin
 	}
 
 	// 3.出的块的一个字段attestation要包含其他恶意节点的attestation。
-	startEpoch := SlotTool{s.b}.EpochStart(epoch)
-	endEpoch := SlotTool{s.b}.EpochEnd(epoch)
+	epoch := uint64(SlotTool{b}.SlotToEpoch(int(slot)))
 	attackerAttestations := make([]*ethpb.Attestation, 0)
-	validatorSet := s.b.GetValidatorDataSet()
-	for i := startEpoch; i <= endEpoch; i++ {
-		allSlotAttest := s.b.GetAttestSet(uint64(i))
-		if allSlotAttest == nil {
-			continue
-		}
-
-		for publicKey, att := range allSlotAttest.Attestations {
-			val := validatorSet.GetValidatorByPubkey(publicKey)
-			valRole := s.b.GetValidatorRole(int(i), int(val.Index))
-			if val != nil && valRole == types.AttackerRole {
-				log.WithField("pubkey", publicKey).Debug("add attacker attestation to block")
-				attackerAttestations = append(attackerAttestations, att)
-			}
-		}
+	for _, att := range attackerAttestationsIn(b, b.GetValidatorDataSet().AttestationsInEpoch(epoch)) {
+		log.WithField("pubkey", att.Pubkey).Debug("add attacker attestation to block")
+		attackerAttestations = append(attackerAttestations, att.Attestation)
 	}
 
-	allAtt := append(block.Capella.Block.Body.Attestations, attackerAttestations...)
+	allAtt := append(block.Attestations(), attackerAttestations...)
 	{
 		// Remove duplicates from both aggregated/unaggregated attestations. This
 		// prevents inefficient aggregates being created.
@@ -187,12 +123,10 @@ func (s *BlockAPI) modifyBlock(slot uint64, pubkey string, blockDataBase64 strin
 		allAtt = atts
 	}
 
-	block.Capella.Block.Body.Attestations = allAtt
+	block.SetAttestations(allAtt)
 
 	// 4. encode to base64.
-	genericBlock.Block = block
-
-	resBlockBase64, err := s.genericSignedBlockToBase64(genericBlock)
+	resBlockBase64, err := signedBlockToBase64(block)
 	if err != nil {
 		return types.AttackerResponse{
 			Cmd:    types.CMD_NULL,
@@ -205,97 +139,33 @@ func (s *BlockAPI) modifyBlock(slot uint64, pubkey string, blockDataBase64 strin
 	}
 }
 
-func (s *BlockAPI) getCapellaBlockFromGenericSigned(block *ethpb.GenericSignedBeaconBlock) (*ethpb.GenericSignedBeaconBlock_Capella, error) {
-	switch b := block.Block.(type) {
-	case nil:
-		return nil, ErrNilObject
-	case *ethpb.GenericSignedBeaconBlock_Phase0:
-		return nil, ErrUnsupportedBeaconBlock
-	case *ethpb.GenericSignedBeaconBlock_Altair:
-		return nil, ErrUnsupportedBeaconBlock
-	case *ethpb.GenericSignedBeaconBlock_Bellatrix:
-		return nil, ErrUnsupportedBeaconBlock
-	case *ethpb.GenericSignedBeaconBlock_BlindedBellatrix:
-		return nil, ErrUnsupportedBeaconBlock
-	case *ethpb.GenericSignedBeaconBlock_Capella:
-		return b, nil
-	case *ethpb.GenericSignedBeaconBlock_BlindedCapella:
-		return nil, ErrUnsupportedBeaconBlock
-	case *ethpb.GenericSignedBeaconBlock_Deneb:
-		return nil, ErrUnsupportedBeaconBlock
-	case *ethpb.GenericSignedBeaconBlock_BlindedDeneb:
-		return nil, ErrUnsupportedBeaconBlock
-	default:
-		log.WithError(ErrUnsupportedBeaconBlock).Errorf("unsupported beacon block from type %T", b)
-		return nil, ErrUnsupportedBeaconBlock
-	}
-}
-
-func (s *BlockAPI) getCapellaBlockFromGeneric(block *ethpb.GenericBeaconBlock) (*ethpb.GenericBeaconBlock_Capella, error) {
-	switch b := block.Block.(type) {
-	case nil:
-		return nil, ErrNilObject
-	case *ethpb.GenericBeaconBlock_Phase0:
-		return nil, ErrUnsupportedBeaconBlock
-	case *ethpb.GenericBeaconBlock_Altair:
-		return nil, ErrUnsupportedBeaconBlock
-	case *ethpb.GenericBeaconBlock_Bellatrix:
-		return nil, ErrUnsupportedBeaconBlock
-	case *ethpb.GenericBeaconBlock_BlindedBellatrix:
-		return nil, ErrUnsupportedBeaconBlock
-	case *ethpb.GenericBeaconBlock_Capella:
-		return b, nil
-	case *ethpb.GenericBeaconBlock_BlindedCapella:
-		return nil, ErrUnsupportedBeaconBlock
-	case *ethpb.GenericBeaconBlock_Deneb:
-		return nil, ErrUnsupportedBeaconBlock
-	case *ethpb.GenericBeaconBlock_BlindedDeneb:
-		return nil, ErrUnsupportedBeaconBlock
-	default:
-		log.WithError(ErrUnsupportedBeaconBlock).Errorf("unsupported beacon block from type %T", b)
-		return nil, ErrUnsupportedBeaconBlock
-	}
-}
-
-func (s *BlockAPI) getGenericBlockFromData(blockDataBase64 string) (*ethpb.GenericBeaconBlock, error) {
-	blockData, err := base64.StdEncoding.DecodeString(blockDataBase64)
+// getSignedBlockFromData decodes a GenericSignedBeaconBlock of any fork.
+func getSignedBlockFromData(signedBlockDataBase64 string) (*types.SignedBlock, error) {
+	blockData, err := base64.StdEncoding.DecodeString(signedBlockDataBase64)
 	if err != nil {
 		log.WithError(err).Error("base64 decode block data failed")
 		return nil, err
 	}
-	var block = new(ethpb.GenericBeaconBlock)
-	if err := proto.Unmarshal(blockData, block); err != nil {
+	var generic = new(ethpb.GenericSignedBeaconBlock)
+	if err := proto.Unmarshal(blockData, generic); err != nil {
 		log.WithError(err).Error("unmarshal block data failed")
 		return nil, err
 	}
-	return block, nil
-}
-
-func (s *BlockAPI) getGenericSignedBlockFromData(signedBlockDataBase64 string) (*ethpb.GenericSignedBeaconBlock, error) {
-	blockData, err := base64.StdEncoding.DecodeString(signedBlockDataBase64)
+	block, err := types.NewSignedBlock(generic)
 	if err != nil {
-		log.WithError(err).Error("base64 decode block data failed")
-		return nil, err
-	}
-	var block = new(ethpb.GenericSignedBeaconBlock)
-	if err := proto.Unmarshal(blockData, block); err != nil {
-		log.WithError(err).Error("unmarshal block data failed")
+		log.WithError(err).Errorf("unsupported beacon block from type %T", generic.Block)
 		return nil, err
 	}
 	return block, nil
 }
 
-func (s *BlockAPI) genericBlockToBase64(block *ethpb.GenericBeaconBlock) (string, error) {
-	data, err := proto.Marshal(block)
+func signedBlockToBase64(block *types.SignedBlock) (string, error) {
+	generic, err := block.Generic()
 	if err != nil {
-		log.WithError(err).Error("marshal block data failed")
+		log.WithError(err).Error("convert block to generic failed")
 		return "", err
 	}
-	return base64.StdEncoding.EncodeToString(data), nil
-}
-
-func (s *BlockAPI) genericSignedBlockToBase64(block *ethpb.GenericSignedBeaconBlock) (string, error) {
-	data, err := proto.Marshal(block)
+	data, err := proto.Marshal(generic)
 	if err != nil {
 		log.WithError(err).Error("marshal block data failed")
 		return "", err
@@ -303,266 +173,133 @@ func (s *BlockAPI) genericSignedBlockToBase64(block *ethpb.GenericSignedBeaconBl
 	return base64.StdEncoding.EncodeToString(data), nil
 }
 
-func (s *BlockAPI) DelayForReceiveBlock(slot uint64) types.AttackerResponse {
-	valIdx, err := s.b.GetValidatorByProposeSlot(slot)
-	if err != nil {
-		return types.AttackerResponse{
-			Cmd: types.CMD_NULL,
-		}
-	}
-	{
-		valRole := s.b.GetValidatorRole(int(slot), valIdx)
-		//val := s.b.GetValidatorDataSet().GetValidatorByIndex(valIdx)
-		if valRole != types.AttackerRole {
-			return types.AttackerResponse{
-				Cmd: types.CMD_NULL,
-			}
-		}
-
-		duties, err := s.b.GetCurrentEpochProposeDuties()
-		if err != nil {
-			return types.AttackerResponse{
-				Cmd: types.CMD_NULL,
-			}
-		}
-
-		latestAttackerVal := int64(-1)
-		for _, duty := range duties {
-			dutySlot, _ := strconv.Atoi(duty.Slot)
-			dutyValIdx, _ := strconv.Atoi(duty.ValidatorIndex)
-			if s.b.GetValidatorRole(dutySlot, dutyValIdx) == types.AttackerRole {
-				latestAttackerVal = int64(dutyValIdx)
-			}
-		}
-		if valIdx != int(latestAttackerVal) {
-			// 不是最后一个出块的恶意节点，不出块
-			return types.AttackerResponse{
-				Cmd: types.CMD_RETURN,
-			}
-		}
-	}
-	// 当前是最后一个出块的恶意节点，进行延时
-
-	epochSlots := s.b.GetSlotsPerEpoch()
-	seconds := s.b.GetIntervalPerSlot()
-	delay := (epochSlots - int(slot%uint64(epochSlots))) * seconds
-	time.Sleep(time.Second * time.Duration(delay))
-	key := fmt.Sprintf("delay_%d_%d", slot, valIdx)
-	blockCacheContent.Add(key, delay)
-	log.WithFields(log.Fields{
-		"slot":     slot,
-		"validx":   valIdx,
-		"duration": delay,
-	}).Info("delay for receive block")
-
-	return types.AttackerResponse{
-		Cmd: types.CMD_UPDATE_STATE,
-	}
+func (s *BlockAPI) DelayForReceiveBlock(ctx context.Context, slot uint64) types.AttackerResponse {
+	trackClient(ctx, s.b, "block_delayForReceiveBlock", slot, "")
+	res := activeAttack(s.b, slot).DelayForReceiveBlock(s.b, slot)
+	return applyCommand(s.b, "block_delayForReceiveBlock", slot, "", res)
 }
 
-func (s *BlockAPI) BeforeBroadCast(slot uint64) types.AttackerResponse {
-	valIdx, err := s.b.GetValidatorByProposeSlot(slot)
-	if err != nil {
-		return types.AttackerResponse{
-			Cmd: types.CMD_NULL,
-		}
+func (s *BlockAPI) BeforeBroadCast(ctx context.Context, slot uint64) types.AttackerResponse {
+	trackClient(ctx, s.b, "block_beforeBroadCast", slot, "")
+	if res, ok := holdPrivateBlock(s.b, slot); ok {
+		return applyCommand(s.b, "block_beforeBroadCast", slot, "", res)
 	}
-	{
-		valRole := s.b.GetValidatorRole(int(slot), valIdx)
-		//val := s.b.GetValidatorDataSet().GetValidatorByIndex(valIdx)
-		if valRole != types.AttackerRole {
-			return types.AttackerResponse{
-				Cmd: types.CMD_NULL,
-			}
-		}
-
-		duties, err := s.b.GetCurrentEpochProposeDuties()
-		if err != nil {
-			return types.AttackerResponse{
-				Cmd: types.CMD_NULL,
-			}
-		}
-
-		latestAttackerVal := int64(-1)
-		for _, duty := range duties {
-			dutySlot, _ := strconv.Atoi(duty.Slot)
-			dutyValIdx, _ := strconv.Atoi(duty.ValidatorIndex)
-			if s.b.GetValidatorRole(dutySlot, dutyValIdx) == types.AttackerRole {
-				latestAttackerVal = int64(dutyValIdx)
-			}
-		}
-		if valIdx != int(latestAttackerVal) {
-			// 不是最后一个出块的恶意节点，不出块
-			return types.AttackerResponse{
-				Cmd: types.CMD_RETURN,
-			}
-		}
-	}
-	// 当前是最后一个出块的恶意节点，进行延时
-	key := fmt.Sprintf("delay_%d_%d", slot, valIdx)
-	lastDelay := 0
-	if t, exist := blockCacheContent.Get(key); exist {
-		lastDelay = t.(int)
-	}
-	seconds := s.b.GetIntervalPerSlot()
-	n2delay := 12 * seconds
-	total := n2delay + lastDelay
-	time.Sleep(time.Second * time.Duration(total))
-	log.WithFields(log.Fields{
-		"slot":     slot,
-		"validx":   valIdx,
-		"duration": total,
-	}).Info("delay for beforeBroadcastBlock")
+	res := activeAttack(s.b, slot).BlockBeforeBroadCast(s.b, slot)
+	return applyCommand(s.b, "block_beforeBroadCast", slot, "", res)
+}
 
-	return types.AttackerResponse{
+func (s *BlockAPI) AfterBroadCast(ctx context.Context, slot uint64) types.AttackerResponse {
+	trackClient(ctx, s.b, "block_afterBroadCast", slot, "")
+	return applyCommand(s.b, "block_afterBroadCast", slot, "", types.AttackerResponse{
 		Cmd: types.CMD_NULL,
-	}
+	})
 }
 
-func (s *BlockAPI) AfterBroadCast(slot uint64) types.AttackerResponse {
-	return types.AttackerResponse{
-		Cmd: types.CMD_NULL,
+func (s *BlockAPI) BeforeMakeBlock(ctx context.Context, slot uint64, pubkey string) types.AttackerResponse {
+	trackClient(ctx, s.b, "block_beforeMakeBlock", slot, pubkey)
+	if isAttackerProposer(s.b, slot, pubkey) && s.b.GetStrategy().BlockStrategyAt(int64(slot)).Withhold {
+		log.WithField("slot", slot).Info("attacker withhold block")
+		return applyCommand(s.b, "block_beforeMakeBlock", slot, pubkey, types.AttackerResponse{
+			Cmd: types.CMD_RETURN,
+		})
 	}
+	res := activeAttack(s.b, slot).BlockBeforeMakeBlock(s.b, slot, pubkey)
+	return applyCommand(s.b, "block_beforeMakeBlock", slot, pubkey, res)
 }
 
-func (s *BlockAPI) BeforeMakeBlock(slot uint64, pubkey string) types.AttackerResponse {
-	// 1. 只有每个epoch最后一个出块的恶意节点出块，其他节点不出快
-	valIdx, err := s.b.GetValidatorByProposeSlot(slot)
-	if err != nil {
-		val := s.b.GetValidatorDataSet().GetValidatorByPubkey(pubkey)
-		if val == nil {
-			return types.AttackerResponse{
-				Cmd: types.CMD_NULL,
-			}
+func (s *BlockAPI) BeforeSign(ctx context.Context, slot uint64, pubkey string, blockDataBase64 string) types.AttackerResponse {
+	trackClient(ctx, s.b, "block_beforeSign", slot, pubkey)
+	// the block is rebuilt on the parent first, the attack and the script
+	// change the block that is signed.
+	blockDataBase64 = overrideParent(s.b, slot, pubkey, blockDataBase64)
+	res := activeAttack(s.b, slot).BlockBeforeSign(s.b, slot, pubkey, blockDataBase64)
+	res = runBlockScript(s.b, luascripts.BlockBeforeSign, slot, pubkey, blockDataBase64, res)
+	if res.Cmd == types.CMD_NULL {
+		res.Result = censorAttestations(s.b, slot, pubkey, res.Result)
+		if !extendsPrivateChain(s.b, slot, pubkey, res.Result) {
+			res.Cmd = types.CMD_RETURN
 		}
-		valIdx = int(val.Index)
 	}
-	role := s.b.GetValidatorRole(int(slot), valIdx)
-	log.WithFields(log.Fields{
-		"slot":   slot,
-		"valIdx": valIdx,
-		"role":   role,
-	}).Info("in BeforeMakeBlock, get validator by propose slot")
-
-	if role != types.AttackerRole {
-		return types.AttackerResponse{
-			Cmd: types.CMD_NULL,
-		}
+	if eq := s.b.GetStrategy().BlockStrategyAt(int64(slot)).Equivocate; eq != nil && res.Cmd == types.CMD_NULL && isAttackerProposer(s.b, slot, pubkey) {
+		res.Equivocation = equivocate(s.b, eq, slot, res.Result)
 	}
-	epoch := SlotTool{s.b}.SlotToEpoch(int(slot))
+	return applyCommand(s.b, "block_beforeSign", slot, pubkey, res)
+}
 
-	duties, err := s.b.GetProposeDuties(int(epoch))
+// equivocate asks a beacon node for a second block of slot with the randao
+// reveal of the first one, nil if it can't be produced.
+func equivocate(b Backend, eq *strategy.EquivocateStrategy, slot uint64, blockDataBase64 string) *types.EquivocationInfo {
+	first, err := getSignedBlockFromData(blockDataBase64)
 	if err != nil {
-		return types.AttackerResponse{
-			Cmd: types.CMD_NULL,
-		}
+		return nil
 	}
-
-	latestSlotWithAttacker := int64(-1)
-	for _, duty := range duties {
-		dutySlot, _ := strconv.ParseInt(duty.Slot, 10, 64)
-		dutyValIdx, _ := strconv.Atoi(duty.ValidatorIndex)
-		log.WithFields(log.Fields{
-			"slot":   dutySlot,
-			"valIdx": dutyValIdx,
-		}).Debug("duty slot")
-		if s.b.GetValidatorRole(int(slot), dutyValIdx) == types.AttackerRole && dutySlot > latestSlotWithAttacker {
-			latestSlotWithAttacker = dutySlot
-			log.WithField("latestSlotWithAttacker", latestSlotWithAttacker).Debug("update latestSlotWithAttacker")
-		}
-	}
-	log.WithFields(log.Fields{
-		"slot":               slot,
-		"latestAttackerSlot": latestSlotWithAttacker,
-	}).Info("modify block")
-
-	if slot != uint64(latestSlotWithAttacker) {
-		// 不是最后一个恶意的出块，不出块
-		return types.AttackerResponse{
-			Cmd: types.CMD_RETURN,
-		}
+	client, err := b.GetBeaconView(eq.Node)
+	if err != nil {
+		log.WithError(err).Error("equivocation node unknown")
+		return nil
 	}
-
-	return types.AttackerResponse{
-		Cmd: types.CMD_NULL,
+	graffiti := eq.Graffiti
+	if graffiti == "" {
+		graffiti = "equivocation"
 	}
-}
-
-func (s *BlockAPI) BeforeSign(slot uint64, pubkey string, blockDataBase64 string) types.AttackerResponse {
-	modifyBlockRes := s.modifyBlock(slot, pubkey, blockDataBase64)
-	return modifyBlockRes
-}
-
-func (s *BlockAPI) AfterSign(slot uint64, pubkey string, signedBlockDataBase64 string) types.AttackerResponse {
-	valIdx, err := s.b.GetValidatorByProposeSlot(slot)
+	fork, data, err := client.ProduceBlockSSZ(slot, first.RandaoReveal(), []byte(graffiti))
 	if err != nil {
-		val := s.b.GetValidatorDataSet().GetValidatorByPubkey(pubkey)
-		if val == nil {
-			return types.AttackerResponse{
-				Cmd: types.CMD_NULL,
-			}
-		}
-		valIdx = int(val.Index)
+		log.WithError(err).WithField("slot", slot).Error("produce equivocation block failed")
+		return nil
 	}
-	role := s.b.GetValidatorRole(int(slot), valIdx)
-	log.WithFields(log.Fields{
-		"slot":   slot,
-		"valIdx": valIdx,
-		"role":   role,
-	}).Info("in AfterSign, get validator by propose slot")
-
-	if role != types.AttackerRole {
-		return types.AttackerResponse{
-			Cmd: types.CMD_NULL,
-		}
+	second, err := types.NewBlockFromSSZ(fork, data)
+	if err != nil {
+		log.WithError(err).WithField("fork", fork).Error("decode equivocation block failed")
+		return nil
 	}
-	epoch := SlotTool{s.b}.SlotToEpoch(int(slot))
-
-	duties, err := s.b.GetProposeDuties(int(epoch))
+	secondBase64, err := signedBlockToBase64(second)
 	if err != nil {
-		return types.AttackerResponse{
-			Cmd: types.CMD_NULL,
-		}
+		return nil
 	}
-
-	latestSlotWithAttacker := int64(-1)
-	for _, duty := range duties {
-		dutySlot, _ := strconv.ParseInt(duty.Slot, 10, 64)
-		dutyValIdx, _ := strconv.Atoi(duty.ValidatorIndex)
-		log.WithFields(log.Fields{
-			"slot":   dutySlot,
-			"valIdx": dutyValIdx,
-		}).Debug("duty slot")
-		if s.b.GetValidatorRole(int(slot), dutyValIdx) == types.AttackerRole && dutySlot > latestSlotWithAttacker {
-			latestSlotWithAttacker = dutySlot
-			log.WithField("latestSlotWithAttacker", latestSlotWithAttacker).Debug("update latestSlotWithAttacker")
-		}
+	log.WithFields(log.Fields{
+		"slot": slot,
+		"node": client.Endpoint(),
+	}).Info("attacker proposer equivocates")
+	return &types.EquivocationInfo{
+		Block:      secondBase64,
+		Targets:    eq.Targets,
+		AltTargets: eq.AltTargets,
 	}
+}
 
-	if slot != uint64(latestSlotWithAttacker) {
-		// 不是最后一个恶意的出块，不出块
-		return types.AttackerResponse{
-			Cmd: types.CMD_RETURN,
-		}
-	}
+func (s *BlockAPI) AfterSign(ctx context.Context, slot uint64, pubkey string, signedBlockDataBase64 string) types.AttackerResponse {
+	trackClient(ctx, s.b, "block_afterSign", slot, pubkey)
+	s.recordBlock(slot, pubkey, signedBlockDataBase64)
+	withholdPrivateBlock(s.b, slot, pubkey, signedBlockDataBase64)
+	res := activeAttack(s.b, slot).BlockAfterSign(s.b, slot, pubkey, signedBlockDataBase64)
+	res = runBlockScript(s.b, luascripts.BlockAfterSign, slot, pubkey, signedBlockDataBase64, res)
+	return applyCommand(s.b, "block_afterSign", slot, pubkey, res)
+}
 
-	return types.AttackerResponse{
-		Cmd:    types.CMD_NULL,
-		Result: signedBlockDataBase64,
+// recordBlock keeps the signed blocks, attackers vote for the ones signed by
+// attackers with the "attacker" head.
+func (s *BlockAPI) recordBlock(slot uint64, pubkey string, signedBlockDataBase64 string) {
+	block := new(ethpb.GenericSignedBeaconBlock)
+	if err := decodeProto(signedBlockDataBase64, block); err != nil {
+		log.WithError(err).Error("decode signed block failed")
+		return
 	}
+	s.b.AddSignedBlock(slot, pubkey, block)
 }
 
-func (s *BlockAPI) BeforePropose(slot uint64, pubkey string, signedBlockDataBase64 string) types.AttackerResponse {
-	return types.AttackerResponse{
+func (s *BlockAPI) BeforePropose(ctx context.Context, slot uint64, pubkey string, signedBlockDataBase64 string) types.AttackerResponse {
+	trackClient(ctx, s.b, "block_beforePropose", slot, pubkey)
+	return applyCommand(s.b, "block_beforePropose", slot, pubkey, types.AttackerResponse{
 		Cmd:    types.CMD_NULL,
 		Result: signedBlockDataBase64,
-	}
+	})
 }
 
-func (s *BlockAPI) AfterPropose(slot uint64, pubkey string, signedBlockDataBase64 string) types.AttackerResponse {
-	return types.AttackerResponse{
+func (s *BlockAPI) AfterPropose(ctx context.Context, slot uint64, pubkey string, signedBlockDataBase64 string) types.AttackerResponse {
+	trackClient(ctx, s.b, "block_afterPropose", slot, pubkey)
+	return applyCommand(s.b, "block_afterPropose", slot, pubkey, types.AttackerResponse{
 		Cmd:    types.CMD_NULL,
 		Result: signedBlockDataBase64,
-	}
+	})
 
 }
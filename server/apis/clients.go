@@ -0,0 +1,45 @@
+package apis
+
+import (
+	"context"
+	"net"
+
+	"github.com/tsinghua-cel/attacker-service/rpc"
+	"github.com/tsinghua-cel/attacker-service/types"
+)
+
+// ClientFromContext returns the info of the validator client making the
+// call, ok is false if the client didn't send it.
+func ClientFromContext(ctx context.Context) (info types.ClientInfo, ok bool) {
+	peer := rpc.PeerInfoFromContext(ctx)
+	if peer.HTTP.Header == nil {
+		return info, false
+	}
+	data := peer.HTTP.Header.Get(types.ClientInfoHeader)
+	if data == "" {
+		return info, false
+	}
+	info = types.ToClientInfo(data)
+	return info, info.UUID != ""
+}
+
+// trackClient records the call in the client registry. Clients that don't
+// send their info are tracked by their host.
+func trackClient(ctx context.Context, b Backend, method string, slot uint64, pubkey string) {
+	peer := rpc.PeerInfoFromContext(ctx)
+	info, ok := ClientFromContext(ctx)
+	if !ok {
+		host, _, err := net.SplitHostPort(peer.RemoteAddr)
+		if err != nil {
+			host = peer.RemoteAddr
+		}
+		info = types.ClientInfo{UUID: "anonymous@" + host, ValidatorIndex: -1}
+	}
+	valIdx := -1
+	if pubkey != "" {
+		if val := b.GetValidatorDataSet().GetValidatorByPubkey(pubkey); val != nil {
+			valIdx = int(val.Index)
+		}
+	}
+	b.TrackClient(info, peer.RemoteAddr, method, slot, valIdx)
+}
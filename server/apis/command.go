@@ -0,0 +1,75 @@
+package apis
+
+import (
+	log "github.com/sirupsen/logrus"
+	"github.com/tsinghua-cel/attacker-service/types"
+	"github.com/tsinghua-cel/attacker-service/validatorSet"
+)
+
+// applyCommand replaces the command of res by the command rule of the
+// strategy matching the call, then enforces the server side of the command.
+func applyCommand(b Backend, hook string, slot uint64, pubkey string, res types.AttackerResponse) types.AttackerResponse {
+	valIdx := -1
+	if pubkey != "" {
+		if val := b.GetValidatorDataSet().GetValidatorByPubkey(pubkey); val != nil {
+			valIdx = int(val.Index)
+		}
+	}
+	if cmd, ok := b.GetStrategy().CommandAt(hook, int64(slot), valIdx); ok && cmd != res.Cmd {
+		log.WithFields(log.Fields{
+			"hook":   hook,
+			"slot":   slot,
+			"valIdx": valIdx,
+			"from":   res.Cmd,
+			"to":     cmd,
+		}).Info("strategy overrides command")
+		res.Cmd = cmd
+	}
+	res.Cmd = enforceCommand(b, hook, slot, valIdx, res.Cmd)
+	return res
+}
+
+// enforceCommand does what the service owes for cmd and returns the command
+// sent to the client, commands that can't apply are turned into CMD_NULL.
+func enforceCommand(b Backend, hook string, slot uint64, valIdx int, cmd types.AttackerCommand) types.AttackerCommand {
+	logger := log.WithFields(log.Fields{
+		"hook":   hook,
+		"slot":   slot,
+		"valIdx": valIdx,
+		"cmd":    cmd,
+	})
+	if cmd < types.CMD_NULL || cmd > types.CMD_UPDATE_STATE {
+		logger.Warn("unknown command, ignored")
+		return types.CMD_NULL
+	}
+	if cmd.NeedValidator() && valIdx < 0 {
+		logger.Warn("command needs a validator, ignored")
+		return types.CMD_NULL
+	}
+	switch cmd {
+	case types.CMD_ROLE_TO_NORMAL, types.CMD_ROLE_TO_ATTACKER:
+		role := types.NormalRole
+		if cmd == types.CMD_ROLE_TO_ATTACKER {
+			role = types.AttackerRole
+		}
+		if b.GetValidatorRole(int(slot), valIdx) == role {
+			return cmd
+		}
+		err := b.SetRoleOverride(validatorSet.RoleOverride{
+			ValidatorIndex: valIdx,
+			Role:           role,
+			StartSlot:      int64(slot),
+			EndSlot:        -1,
+		})
+		if err != nil {
+			logger.WithError(err).Error("update validator role failed")
+		}
+	case types.CMD_EXIT:
+		// the validator exits once, later calls go on as usual.
+		if !b.GetValidatorDataSet().RequestExit(valIdx, slot) {
+			return types.CMD_NULL
+		}
+		logger.Info("validator requested to exit")
+	}
+	return cmd
+}
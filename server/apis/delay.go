@@ -0,0 +1,81 @@
+package apis
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/tsinghua-cel/attacker-service/scheduler"
+	"github.com/tsinghua-cel/attacker-service/types"
+)
+
+// waitLimit bounds a delay_wait call, far below the http timeout of the
+// server, the client calls it again until the delay is released.
+const waitLimit = 10 * time.Second
+
+// DelayAPI lets validator clients wait for the delay of a hook.
+type DelayAPI struct {
+	b Backend
+}
+
+func NewDelayAPI(b Backend) *DelayAPI {
+	return &DelayAPI{b}
+}
+
+// Wait returns when the delay is released or cancelled, or after waitLimit
+// with Released false.
+func (s *DelayAPI) Wait(ctx context.Context, id string) (types.DelayInfo, error) {
+	waitCtx, cancel := context.WithTimeout(ctx, waitLimit)
+	defer cancel()
+	d, err := s.b.GetDelayScheduler().Wait(waitCtx, id)
+	if err != nil && ctx.Err() == nil && errors.Is(err, context.DeadlineExceeded) {
+		d, err = s.b.GetDelayScheduler().Get(id)
+	}
+	if err != nil {
+		return types.DelayInfo{}, err
+	}
+	info := delayInfo(d)
+	info.Released = d.Cancelled || !d.Release.After(time.Now())
+	return info, nil
+}
+
+func delayInfo(d scheduler.Delay) types.DelayInfo {
+	return types.DelayInfo{
+		ID:      d.ID,
+		Release: d.Release.UnixMilli(),
+	}
+}
+
+// delayUntilSlot holds the hook until the start of releaseSlot. If the slot
+// clock isn't initialised the delay counts from now.
+func delayUntilSlot(b Backend, hook string, slot uint64, releaseSlot uint64, reason string, cmd types.AttackerCommand) types.AttackerResponse {
+	clock, err := b.GetSlotClock()
+	if err != nil {
+		log.WithError(err).Warn("slot clock not ready, delay from now")
+		release := time.Now().Add(time.Duration(releaseSlot-slot) * time.Duration(b.GetIntervalPerSlot()) * time.Second)
+		return delayUntil(b, hook, slot, release, reason, cmd)
+	}
+	return delayUntil(b, hook, slot, clock.SlotStart(releaseSlot), reason, cmd)
+}
+
+// delayFor holds the hook for d.
+func delayFor(b Backend, hook string, slot uint64, d time.Duration, reason string, cmd types.AttackerCommand) types.AttackerResponse {
+	return delayUntil(b, hook, slot, time.Now().Add(d), reason, cmd)
+}
+
+func delayUntil(b Backend, hook string, slot uint64, release time.Time, reason string, cmd types.AttackerCommand) types.AttackerResponse {
+	d := b.GetDelayScheduler().Schedule(hook, slot, -1, release, reason)
+	info := delayInfo(d)
+	log.WithFields(log.Fields{
+		"hook":    hook,
+		"slot":    slot,
+		"id":      d.ID,
+		"release": d.Release,
+		"reason":  reason,
+	}).Info("delay hook")
+	return types.AttackerResponse{
+		Cmd:   cmd,
+		Delay: &info,
+	}
+}
@@ -0,0 +1,44 @@
+package apis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/tsinghua-cel/attacker-service/scheduler"
+)
+
+// delayBackend gives the delay scheduler only.
+type delayBackend struct {
+	Backend
+	delays *scheduler.Scheduler
+}
+
+func (b *delayBackend) GetDelayScheduler() *scheduler.Scheduler {
+	return b.delays
+}
+
+func TestDelayWait(t *testing.T) {
+	b := &delayBackend{delays: scheduler.New()}
+	api := NewDelayAPI(b)
+	d := b.delays.Schedule("block_beforeBroadCast", 4, -1, time.Now().Add(time.Hour), "test")
+
+	// a cancelled delay returns early, released.
+	time.AfterFunc(50*time.Millisecond, func() { b.delays.Cancel(d.ID) })
+	start := time.Now()
+	info, err := api.Wait(context.Background(), d.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.Released || time.Since(start) > waitLimit {
+		t.Fatalf("cancelled delay: got %+v after %v", info, time.Since(start))
+	}
+
+	short := b.delays.Schedule("attest_beforeBroadCast", 4, -1, time.Now().Add(20*time.Millisecond), "test")
+	if info, err := api.Wait(context.Background(), short.ID); err != nil || !info.Released {
+		t.Fatalf("released delay: got %+v, %v", info, err)
+	}
+	if _, err := api.Wait(context.Background(), "unknown"); err != scheduler.ErrUnknownDelay {
+		t.Fatalf("unknown delay: got %v", err)
+	}
+}
@@ -0,0 +1,29 @@
+package apis
+
+import (
+	"github.com/tsinghua-cel/attacker-service/types"
+	"github.com/tsinghua-cel/attacker-service/validatorSet"
+)
+
+// isAttacker tells if the validator of a record is an attacker at slot, the
+// pubkey is used when the index wasn't known at record time.
+func isAttacker(b Backend, slot uint64, valIdx int, pubkey string) bool {
+	if valIdx < 0 {
+		return b.GetValidatorRoleByPubkey(int(slot), pubkey) == types.AttackerRole
+	}
+	return b.GetValidatorRole(int(slot), valIdx) == types.AttackerRole
+}
+
+// attackerAttestationsIn returns the attestations of list signed by attackers.
+func attackerAttestationsIn(b Backend, list []validatorSet.SignedAttestation) []validatorSet.SignedAttestation {
+	return validatorSet.FilterAttestations(list, func(a validatorSet.SignedAttestation) bool {
+		return isAttacker(b, a.Slot, a.ValidatorIndex, a.Pubkey)
+	})
+}
+
+// attackerBlocksIn returns the blocks of list signed by attackers.
+func attackerBlocksIn(b Backend, list []validatorSet.SignedBlock) []validatorSet.SignedBlock {
+	return validatorSet.FilterBlocks(list, func(blk validatorSet.SignedBlock) bool {
+		return isAttacker(b, blk.Slot, blk.ValidatorIndex, blk.Pubkey)
+	})
+}
@@ -0,0 +1,98 @@
+package apis
+
+import (
+	"fmt"
+	"strconv"
+
+	ethpb "github.com/prysmaticlabs/prysm/v4/proto/prysm/v1alpha1"
+	log "github.com/sirupsen/logrus"
+	"github.com/tsinghua-cel/attacker-service/beaconapi"
+	"github.com/tsinghua-cel/attacker-service/strategy"
+	"github.com/tsinghua-cel/attacker-service/types"
+)
+
+// censorAttestations removes from the attacker block of slot the
+// attestations excluded by the inclusion strategy.
+func censorAttestations(b Backend, slot uint64, pubkey string, blockDataBase64 string) string {
+	inc := b.GetStrategy().BlockStrategyAt(int64(slot)).Inclusion
+	if inc == nil || !isAttackerProposer(b, slot, pubkey) {
+		return blockDataBase64
+	}
+	block, err := getSignedBlockFromData(blockDataBase64)
+	if err != nil {
+		return blockDataBase64
+	}
+	atts := types.ProposerAtts(block.Attestations())
+	before := len(atts)
+	if len(inc.ExcludeCommittees) > 0 {
+		indices := make(map[uint64]bool)
+		for _, i := range inc.ExcludeCommittees {
+			indices[i] = true
+		}
+		atts = atts.ExcludeCommittees(indices)
+	}
+	if len(inc.ExcludeHeads) > 0 {
+		roots := make([][]byte, 0, len(inc.ExcludeHeads))
+		for _, head := range inc.ExcludeHeads {
+			if root, err := strategy.ParseRoot(head); err == nil {
+				roots = append(roots, root)
+			}
+		}
+		atts = atts.ExcludeHeads(roots)
+	}
+	if inc.MinDelay > 0 {
+		atts = atts.MinInclusionDelay(slot, inc.MinDelay)
+	}
+	if len(inc.ExcludeValidators) > 0 {
+		validators := make(map[uint64]bool)
+		for _, v := range inc.ExcludeValidators {
+			validators[uint64(v)] = true
+		}
+		atts = atts.ExcludeValidators(committeeLookup(b), validators)
+	}
+	if len(atts) == before {
+		return blockDataBase64
+	}
+	block.SetAttestations([]*ethpb.Attestation(atts))
+	data, err := signedBlockToBase64(block)
+	if err != nil {
+		return blockDataBase64
+	}
+	log.WithFields(log.Fields{
+		"slot":     slot,
+		"censored": before - len(atts),
+		"kept":     len(atts),
+	}).Info("attestations censored in attacker block")
+	return data
+}
+
+// committeeLookup returns the validators of a committee from the head state
+// of the beacon node, the committees of an epoch are asked once.
+func committeeLookup(b Backend) func(slot uint64, index uint64) ([]uint64, error) {
+	slotsPerEpoch := uint64(b.SlotsPerEpoch())
+	committees := make(map[string][]uint64)
+	asked := make(map[uint64]bool)
+	return func(slot uint64, index uint64) ([]uint64, error) {
+		key := fmt.Sprintf("%d/%d", slot, index)
+		if epoch := slot / slotsPerEpoch; !asked[epoch] {
+			asked[epoch] = true
+			list, err := b.GetCommittees("head", beaconapi.CommitteeFilter{Epoch: &epoch})
+			if err != nil {
+				log.WithError(err).WithField("epoch", epoch).Warn("get committees failed")
+			}
+			for _, c := range list {
+				members := make([]uint64, 0, len(c.Validators))
+				for _, v := range c.Validators {
+					idx, _ := strconv.ParseUint(v, 10, 64)
+					members = append(members, idx)
+				}
+				committees[c.Slot+"/"+c.Index] = members
+			}
+		}
+		members, exist := committees[key]
+		if !exist {
+			return nil, fmt.Errorf("unknown committee %s", key)
+		}
+		return members, nil
+	}
+}
@@ -0,0 +1,121 @@
+package apis
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/tsinghua-cel/attacker-service/strategy"
+	"github.com/tsinghua-cel/attacker-service/types"
+)
+
+var (
+	ErrNoAncestor   = errors.New("no ancestor for the parent rule")
+	ErrNoParentNode = errors.New("head of the parent rule node is not the parent")
+)
+
+// parentRule returns the parent rule of the attacker block of slot.
+func parentRule(b Backend, slot uint64, pubkey string) *strategy.ParentStrategy {
+	if !isAttackerProposer(b, slot, pubkey) {
+		return nil
+	}
+	return b.GetStrategy().BlockStrategyAt(int64(slot)).Parent
+}
+
+// overrideParent rebuilds the attacker block of slot on the ancestor chosen
+// by the parent rule. The block is unchanged if it already builds on it or
+// if it can't be rebuilt.
+func overrideParent(b Backend, slot uint64, pubkey string, blockDataBase64 string) string {
+	rule := parentRule(b, slot, pubkey)
+	if rule == nil {
+		return blockDataBase64
+	}
+	block, err := getSignedBlockFromData(blockDataBase64)
+	if err != nil {
+		return blockDataBase64
+	}
+	current := rootHex(block.ParentRoot())
+	parent, err := ancestorFor(b, rule, slot)
+	if err != nil {
+		log.WithError(err).WithField("slot", slot).Warn("parent rule not applied")
+		return blockDataBase64
+	}
+	if parent == current {
+		return blockDataBase64
+	}
+	rebuilt, err := buildOnParent(b, rule.Node, block, parent)
+	if err != nil {
+		log.WithError(err).WithFields(log.Fields{
+			"slot":   slot,
+			"parent": parent,
+		}).Error("rebuild block on parent failed")
+		return blockDataBase64
+	}
+	data, err := signedBlockToBase64(rebuilt)
+	if err != nil {
+		return blockDataBase64
+	}
+	log.WithFields(log.Fields{
+		"slot": slot,
+		"old":  current,
+		"new":  parent,
+		"rule": rule.Ancestor,
+	}).Info("attacker block parent changed")
+	return data
+}
+
+func rootHex(root []byte) string {
+	return "0x" + hex.EncodeToString(root)
+}
+
+// ancestorFor returns the root of the block the rule picks as parent of the
+// block of slot.
+func ancestorFor(b Backend, rule *strategy.ParentStrategy, slot uint64) (string, error) {
+	switch rule.Ancestor {
+	case strategy.ParentLastAttacker:
+		if tip, ok := b.GetPrivateChain().Tip(); ok {
+			return tip.Root, nil
+		}
+		epoch := slot / uint64(b.SlotsPerEpoch())
+		for e := int64(epoch); e >= 0 && e >= int64(epoch)-1; e-- {
+			blocks := attackerBlocksIn(b, b.GetValidatorDataSet().BlocksInEpoch(uint64(e)))
+			for i := len(blocks) - 1; i >= 0; i-- {
+				if blocks[i].Slot < slot && blocks[i].Root != "" {
+					return blocks[i].Root, nil
+				}
+			}
+		}
+		return "", fmt.Errorf("%w: no attacker block", ErrNoAncestor)
+	}
+	return "", fmt.Errorf("%w: unknown ancestor %q", ErrNoAncestor, rule.Ancestor)
+}
+
+// buildOnParent asks node, if its head is parent, to produce the block with
+// the randao reveal and graffiti of block. The produced block is returned
+// unchanged, its state root only holds for the body built by the node.
+func buildOnParent(b Backend, node string, block *types.SignedBlock, parent string) (*types.SignedBlock, error) {
+	client, err := b.GetBeaconView(node)
+	if err != nil {
+		return nil, err
+	}
+	head, err := client.GetBeaconHeader("head")
+	if err != nil {
+		return nil, err
+	}
+	if head.Root != parent {
+		return nil, fmt.Errorf("%w: %s has head %s, want %s", ErrNoParentNode, client.Endpoint(), head.Root, parent)
+	}
+	fork, data, err := client.ProduceBlockSSZ(block.Slot(), block.RandaoReveal(), block.Graffiti())
+	if err != nil {
+		return nil, err
+	}
+	rebuilt, err := types.NewBlockFromSSZ(fork, data)
+	if err != nil {
+		return nil, err
+	}
+	if got := rootHex(rebuilt.ParentRoot()); got != parent {
+		return nil, fmt.Errorf("beacon node built on %s", got)
+	}
+	return rebuilt, nil
+}
@@ -0,0 +1,234 @@
+package apis
+
+import (
+	"encoding/hex"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/tsinghua-cel/attacker-service/privatechain"
+	"github.com/tsinghua-cel/attacker-service/slotclock"
+	"github.com/tsinghua-cel/attacker-service/strategy"
+	"github.com/tsinghua-cel/attacker-service/types"
+)
+
+// catchUpLimit bounds the delay of a block withheld until the public chain
+// catches up, the delay is cancelled when it does.
+const catchUpLimit = 24 * time.Hour
+
+// privateStrategy returns the private chain strategy at slot if the proposer
+// of slot is an attacker.
+func privateStrategy(b Backend, slot uint64, pubkey string) *strategy.PrivateChainStrategy {
+	p := b.GetStrategy().BlockStrategyAt(int64(slot)).Private
+	if p == nil || !isAttackerProposer(b, slot, pubkey) {
+		return nil
+	}
+	return p
+}
+
+// extendsPrivateChain returns false if the attacker block would go on the
+// private chain but doesn't build on its tip, after the parent rule if any.
+// Such a block isn't signed: it would compete with the private chain instead
+// of extending it.
+func extendsPrivateChain(b Backend, slot uint64, pubkey string, blockDataBase64 string) bool {
+	if privateStrategy(b, slot, pubkey) == nil {
+		return true
+	}
+	tip, ok := b.GetPrivateChain().Tip()
+	if !ok {
+		return true
+	}
+	block, err := getSignedBlockFromData(blockDataBase64)
+	if err != nil {
+		return true
+	}
+	if parent := "0x" + hex.EncodeToString(block.ParentRoot()); parent != tip.Root {
+		log.WithFields(log.Fields{
+			"slot":   slot,
+			"parent": parent,
+			"tip":    tip.Root,
+		}).Warn("attacker block doesn't build on the private chain, not proposed")
+		return false
+	}
+	return true
+}
+
+// withholdPrivateBlock adds the signed attacker block to the private chain
+// and publishes it to the beacon node of the parent rule, so that the next
+// attacker block can be built on it.
+func withholdPrivateBlock(b Backend, slot uint64, pubkey string, signedBlockDataBase64 string) {
+	if privateStrategy(b, slot, pubkey) == nil {
+		return
+	}
+	block, err := getSignedBlockFromData(signedBlockDataBase64)
+	if err != nil {
+		return
+	}
+	root, err := block.Root()
+	if err != nil {
+		log.WithError(err).Error("hash private block failed")
+		return
+	}
+	private := privatechain.Block{
+		Slot:       slot,
+		Root:       "0x" + hex.EncodeToString(root),
+		ParentRoot: "0x" + hex.EncodeToString(block.ParentRoot()),
+		Withheld:   time.Now(),
+	}
+	if !b.GetPrivateChain().Add(private) {
+		log.WithFields(log.Fields{
+			"slot":   slot,
+			"root":   private.Root,
+			"parent": private.ParentRoot,
+		}).Warn("attacker block doesn't build on the private chain, not withheld")
+		return
+	}
+	log.WithFields(log.Fields{
+		"slot": slot,
+		"root": private.Root,
+	}).Info("attacker block withheld on the private chain")
+	if rule := b.GetStrategy().BlockStrategyAt(int64(slot)).Parent; rule != nil {
+		publishPrivateBlock(b, rule.Node, block)
+	}
+}
+
+// publishPrivateBlock sends the withheld block to node, a beacon node kept on
+// the attacker branch whose head becomes the private tip.
+func publishPrivateBlock(b Backend, node string, block *types.SignedBlock) {
+	logger := log.WithField("slot", block.Slot())
+	client, err := b.GetBeaconView(node)
+	if err != nil {
+		logger.WithError(err).Error("private chain node unknown")
+		return
+	}
+	if block.IsBlinded() {
+		logger.Warn("blinded private block not published")
+		return
+	}
+	data, err := block.MarshalSSZ()
+	if err != nil {
+		logger.WithError(err).Error("encode private block failed")
+		return
+	}
+	if err := client.PublishBlockSSZ(block.Fork(), data); err != nil {
+		logger.WithError(err).WithField("node", client.Endpoint()).Error("publish private block failed")
+		return
+	}
+	logger.WithField("node", client.Endpoint()).Info("private block published to the attacker node")
+}
+
+// holdPrivateBlock holds the broadcast of the private block of slot until
+// the release of the chain, ok is false if slot has no private block.
+func holdPrivateBlock(b Backend, slot uint64) (types.AttackerResponse, bool) {
+	chain := b.GetPrivateChain()
+	if _, ok := chain.At(slot); !ok {
+		return types.AttackerResponse{}, false
+	}
+	p := b.GetStrategy().BlockStrategyAt(int64(slot)).Private
+	if p == nil {
+		ReleasePrivateChain(b, "private chain disabled")
+		return types.AttackerResponse{}, false
+	}
+	var res types.AttackerResponse
+	switch p.Release {
+	case strategy.ReleaseNever:
+		log.WithField("slot", slot).Info("private block never broadcast")
+		return types.AttackerResponse{
+			Cmd: types.CMD_RETURN,
+		}, true
+	case strategy.ReleaseAtSlot:
+		clock, err := b.GetSlotClock()
+		if err != nil {
+			log.WithError(err).Warn("slot clock not ready, release private chain")
+			ReleasePrivateChain(b, "no slot clock")
+			return types.AttackerResponse{}, false
+		}
+		release := clock.IntervalStart(uint64(p.Slot), slotclock.Interval(p.Interval))
+		if !release.After(time.Now()) {
+			ReleasePrivateChain(b, "release slot reached")
+			return types.AttackerResponse{}, false
+		}
+		res = delayUntil(b, "block_beforeBroadCast", slot, release, "private chain", types.CMD_NULL)
+	default:
+		if chain.Lead() <= p.Lead {
+			ReleasePrivateChain(b, "public chain caught up")
+			return types.AttackerResponse{}, false
+		}
+		res = delayUntil(b, "block_beforeBroadCast", slot, time.Now().Add(catchUpLimit), "private chain", types.CMD_NULL)
+	}
+	chain.SetDelay(slot, res.Delay.ID)
+	return res, true
+}
+
+// OnPublicBlock counts a block of the beacon node for the private chain and
+// releases the chain when the public chain caught up or the release slot is
+// reached.
+func OnPublicBlock(b Backend, slot uint64, root string) {
+	chain := b.GetPrivateChain()
+	if _, ok := chain.Tip(); !ok {
+		return
+	}
+	lead := chain.PublicBlock(slot, root)
+	p := b.GetStrategy().BlockStrategyAt(int64(slot)).Private
+	switch {
+	case p == nil:
+		ReleasePrivateChain(b, "private chain disabled")
+	case p.Release == strategy.ReleaseCatchUp && lead <= p.Lead:
+		ReleasePrivateChain(b, "public chain caught up")
+	case p.Release == strategy.ReleaseAtSlot && int64(slot) >= p.Slot:
+		// the delays are already released.
+		ReleasePrivateChain(b, "release slot reached")
+	case p.Release == strategy.ReleaseNever:
+		expireNeverBlocks(b, slot)
+	}
+}
+
+// expireNeverBlocks drops the blocks never broadcast that are more than an
+// epoch old, the public chain is too far ahead for them.
+func expireNeverBlocks(b Backend, slot uint64) {
+	epoch := uint64(b.SlotsPerEpoch())
+	if slot < epoch {
+		return
+	}
+	if expired := b.GetPrivateChain().PruneBefore(slot - epoch); len(expired) > 0 {
+		log.WithFields(log.Fields{
+			"blocks": len(expired),
+			"from":   expired[0].Slot,
+			"to":     expired[len(expired)-1].Slot,
+		}).Info("private blocks expired")
+	}
+}
+
+// ReleasePrivateChain empties the private chain and releases the delays
+// holding its blocks.
+func ReleasePrivateChain(b Backend, reason string) []privatechain.Block {
+	released := b.GetPrivateChain().Release()
+	for _, block := range released {
+		if block.DelayID == "" {
+			continue
+		}
+		if err := b.GetDelayScheduler().Cancel(block.DelayID); err != nil {
+			log.WithError(err).WithField("delay", block.DelayID).Warn("release private block failed")
+		}
+	}
+	if len(released) > 0 {
+		log.WithFields(log.Fields{
+			"blocks": len(released),
+			"from":   released[0].Slot,
+			"to":     released[len(released)-1].Slot,
+			"reason": reason,
+		}).Info("private chain released")
+	}
+	return released
+}
+
+// PrivateChain returns the withheld attacker blocks and the lead of the
+// private chain.
+func (s *AdminAPI) PrivateChain() privatechain.Status {
+	return s.b.GetPrivateChain().Status()
+}
+
+// ReleasePrivateChain broadcasts the withheld attacker blocks now, the blocks
+// withheld with the "never" policy are dropped.
+func (s *AdminAPI) ReleasePrivateChain() []privatechain.Block {
+	return ReleasePrivateChain(s.b, "admin")
+}
@@ -0,0 +1,150 @@
+package apis
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/prysmaticlabs/prysm/v4/consensus-types/primitives"
+	ethpb "github.com/prysmaticlabs/prysm/v4/proto/prysm/v1alpha1"
+	"github.com/tsinghua-cel/attacker-service/beaconapi"
+	"github.com/tsinghua-cel/attacker-service/privatechain"
+	"github.com/tsinghua-cel/attacker-service/strategy"
+	"github.com/tsinghua-cel/attacker-service/types"
+	"github.com/tsinghua-cel/attacker-service/validatorSet"
+	"google.golang.org/protobuf/proto"
+)
+
+// privateBackend makes every proposer an attacker, with a private chain and
+// the attacker beacon node of the parent rule.
+type privateBackend struct {
+	Backend
+	strategy *strategy.Strategy
+	chain    *privatechain.Chain
+	vals     *validatorSet.ValidatorDataSet
+	node     *beaconapi.BeaconGwClient
+}
+
+func (b *privateBackend) GetStrategy() *strategy.Strategy { return b.strategy }
+
+func (b *privateBackend) GetPrivateChain() *privatechain.Chain { return b.chain }
+
+func (b *privateBackend) GetValidatorDataSet() *validatorSet.ValidatorDataSet { return b.vals }
+
+func (b *privateBackend) GetValidatorByProposeSlot(slot uint64) (int, error) { return 1, nil }
+
+func (b *privateBackend) GetValidatorRole(slot int, valIdx int) types.RoleType {
+	return types.AttackerRole
+}
+
+func (b *privateBackend) SlotsPerEpoch() int { return 32 }
+
+func (b *privateBackend) GetBeaconView(node string) (*beaconapi.BeaconGwClient, error) {
+	return b.node, nil
+}
+
+// attackerNode is a beacon node producing phase0 blocks on its head, the
+// head is the last block published to it.
+type attackerNode struct {
+	lock      sync.Mutex
+	head      [32]byte
+	published []*ethpb.SignedBeaconBlock
+}
+
+func phase0Block(slot uint64, parent []byte) *ethpb.BeaconBlock {
+	return &ethpb.BeaconBlock{
+		Slot:       primitives.Slot(slot),
+		ParentRoot: parent,
+		StateRoot:  make([]byte, 32),
+		Body: &ethpb.BeaconBlockBody{
+			RandaoReveal: make([]byte, 96),
+			Eth1Data: &ethpb.Eth1Data{
+				DepositRoot: make([]byte, 32),
+				BlockHash:   make([]byte, 32),
+			},
+			Graffiti: make([]byte, 32),
+		},
+	}
+}
+
+func (n *attackerNode) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	switch {
+	case r.URL.Path == "/eth/v1/beacon/headers/head":
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]string{"root": "0x" + hex.EncodeToString(n.head[:])},
+		})
+	case strings.HasPrefix(r.URL.Path, "/eth/v2/validator/blocks/"):
+		var slot uint64
+		json.Unmarshal([]byte(strings.TrimPrefix(r.URL.Path, "/eth/v2/validator/blocks/")), &slot)
+		data, _ := phase0Block(slot, n.head[:]).MarshalSSZ()
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Eth-Consensus-Version", "phase0")
+		w.Write(data)
+	case r.URL.Path == "/eth/v2/beacon/blocks" && r.Header.Get("Eth-Consensus-Version") == "phase0":
+		data, _ := io.ReadAll(r.Body)
+		block := new(ethpb.SignedBeaconBlock)
+		if err := block.UnmarshalSSZ(data); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		n.published = append(n.published, block)
+		n.head, _ = block.Block.HashTreeRoot()
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestPrivateChainGrows(t *testing.T) {
+	node := &attackerNode{}
+	srv := httptest.NewServer(node)
+	defer srv.Close()
+	b := &privateBackend{
+		strategy: &strategy.Strategy{Block: strategy.BlockStrategy{
+			Private: &strategy.PrivateChainStrategy{Release: strategy.ReleaseNever},
+			Parent:  &strategy.ParentStrategy{Ancestor: strategy.ParentLastAttacker, Node: srv.URL},
+		}},
+		chain: privatechain.New(),
+		vals:  validatorSet.NewValidatorSet(),
+		node:  beaconapi.NewBeaconGwClient(srv.URL),
+	}
+	// the validator client's beacon node builds both blocks on the public
+	// head.
+	public := make([]byte, 32)
+	public[0] = 0xaa
+	for slot := uint64(4); slot <= 5; slot++ {
+		generic := &ethpb.GenericSignedBeaconBlock{Block: &ethpb.GenericSignedBeaconBlock_Phase0{
+			Phase0: &ethpb.SignedBeaconBlock{Block: phase0Block(slot, public), Signature: make([]byte, 96)},
+		}}
+		raw, err := proto.Marshal(generic)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data := overrideParent(b, slot, "", base64.StdEncoding.EncodeToString(raw))
+		if !extendsPrivateChain(b, slot, "", data) {
+			t.Fatalf("block of slot %d doesn't extend the private chain", slot)
+		}
+		withholdPrivateBlock(b, slot, "", data)
+	}
+
+	status := b.chain.Status()
+	if len(status.Blocks) != 2 || status.Blocks[0].Slot != 4 || status.Blocks[1].Slot != 5 {
+		t.Fatalf("private chain: got %+v", status.Blocks)
+	}
+	if status.Blocks[1].ParentRoot != status.Blocks[0].Root {
+		t.Fatalf("block 5 builds on %s, want %s", status.Blocks[1].ParentRoot, status.Blocks[0].Root)
+	}
+	if len(node.published) != 2 {
+		t.Fatalf("published to the attacker node: got %d blocks", len(node.published))
+	}
+	if tip, _ := b.chain.Tip(); tip.Root != "0x"+hex.EncodeToString(node.head[:]) {
+		t.Fatalf("attacker node head is not the private tip %s", tip.Root)
+	}
+}
@@ -0,0 +1,159 @@
+package apis
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	ethpb "github.com/prysmaticlabs/prysm/v4/proto/prysm/v1alpha1"
+	log "github.com/sirupsen/logrus"
+	"github.com/tsinghua-cel/attacker-service/beaconapi"
+	"github.com/tsinghua-cel/attacker-service/strategy"
+	"github.com/tsinghua-cel/attacker-service/withheld"
+)
+
+// withholdAttestation keeps the attestation the attacker didn't broadcast if
+// the attest strategy at slot releases it later, the time rules are armed.
+func withholdAttestation(b Backend, slot uint64, pubkey string, signedAttestDataBase64 string) {
+	rules := b.GetStrategy().AttestStrategyAt(int64(slot)).Release
+	if len(rules) == 0 {
+		return
+	}
+	att := new(ethpb.Attestation)
+	if err := decodeProto(signedAttestDataBase64, att); err != nil {
+		log.WithError(err).Error("decode withheld attestation failed")
+		return
+	}
+	b.GetWithheldAttestations().Add(withheld.Attestation{
+		Slot:        slot,
+		Pubkey:      pubkey,
+		Withheld:    time.Now(),
+		Attestation: att,
+	})
+	for _, r := range rules {
+		if r.Trigger != strategy.TriggerTime {
+			continue
+		}
+		clock, err := b.GetSlotClock()
+		if err != nil {
+			log.WithError(err).Warn("slot clock not ready, time release rule skipped")
+			continue
+		}
+		release := clock.SlotStart(slot + r.Slot).Add(time.Duration(r.Ms) * time.Millisecond)
+		time.AfterFunc(time.Until(release), func() {
+			releaseAttestations(b, strategy.TriggerTime, func(a withheld.Attestation) bool {
+				return a.Slot == slot && a.Pubkey == pubkey
+			})
+		})
+	}
+}
+
+// hasRule returns true if a release rule of the attestation slot matches.
+func hasRule(b Backend, a withheld.Attestation, match func(r strategy.ReleaseRule) bool) bool {
+	for _, r := range b.GetStrategy().AttestStrategyAt(int64(a.Slot)).Release {
+		if match(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// ReleaseOnBlock broadcasts the withheld attestations waiting for the block
+// of slot, if an honest validator proposed it. It doesn't wait for the
+// beacon node, the caller is the event loop.
+func ReleaseOnBlock(b Backend, slot uint64) {
+	go func() {
+		if isAttackerProposer(b, slot, "") {
+			return
+		}
+		releaseAttestations(b, strategy.TriggerBlock, func(a withheld.Attestation) bool {
+			return hasRule(b, a, func(r strategy.ReleaseRule) bool {
+				return r.Trigger == strategy.TriggerBlock && a.Slot+r.Slot == slot
+			})
+		})
+	}()
+}
+
+// ReleaseOnHead broadcasts the withheld attestations waiting for a new head,
+// received is the time of the head event. It doesn't wait for the beacon
+// node, the caller is the event loop.
+func ReleaseOnHead(b Backend, received time.Time) {
+	go releaseAttestations(b, strategy.TriggerHead, func(a withheld.Attestation) bool {
+		return a.Withheld.Before(received) && hasRule(b, a, func(r strategy.ReleaseRule) bool {
+			return r.Trigger == strategy.TriggerHead
+		})
+	})
+}
+
+// releaseAttestations submits the withheld attestations selected by release
+// to the pool of the beacon node and returns the accepted ones. If the node
+// can't be reached or fails, they go back to the withheld pool for a later
+// release; the ones the node rejects as invalid are dropped.
+func releaseAttestations(b Backend, trigger string, release func(a withheld.Attestation) bool) []withheld.Attestation {
+	pool := b.GetWithheldAttestations()
+	taken := pool.Take(release)
+	if len(taken) == 0 {
+		return taken
+	}
+	client, err := b.GetBeaconView("")
+	if err != nil {
+		log.WithError(err).Error("no beacon node to release attestations")
+		pool.Restore(taken)
+		return nil
+	}
+	list := make([]beaconapi.Attestation, 0, len(taken))
+	for _, a := range taken {
+		list = append(list, beaconapi.NewAttestation(a.Attestation))
+	}
+	logger := log.WithFields(log.Fields{
+		"trigger":      trigger,
+		"attestations": len(list),
+		"from":         taken[0].Slot,
+		"to":           taken[len(taken)-1].Slot,
+	})
+	if err := client.SubmitAttestations(list); err != nil {
+		var apiErr *beaconapi.APIError
+		if !errors.As(err, &apiErr) || apiErr.StatusCode >= http.StatusInternalServerError {
+			logger.WithError(err).Error("release withheld attestations failed, retry later")
+			pool.Restore(taken)
+			return nil
+		}
+		if len(apiErr.Failures) == 0 {
+			logger.WithError(err).Error("withheld attestations rejected, dropped")
+			return nil
+		}
+		failed := make(map[int]string)
+		for _, f := range apiErr.Failures {
+			failed[f.Index] = f.Message
+		}
+		accepted := make([]withheld.Attestation, 0, len(taken))
+		for i, a := range taken {
+			msg, rejected := failed[i]
+			if !rejected {
+				accepted = append(accepted, a)
+				continue
+			}
+			log.WithFields(log.Fields{
+				"slot":   a.Slot,
+				"pubkey": a.Pubkey,
+				"reason": msg,
+			}).Warn("withheld attestation rejected, dropped")
+		}
+		logger.WithField("rejected", len(taken)-len(accepted)).Warn("withheld attestations partly released")
+		return accepted
+	}
+	logger.Info("withheld attestations released")
+	return taken
+}
+
+// ListWithheldAttestations returns the attestations withheld by attackers
+// waiting for a release rule.
+func (s *AdminAPI) ListWithheldAttestations() []withheld.Attestation {
+	return s.b.GetWithheldAttestations().List()
+}
+
+// ReleaseAttestations broadcasts the withheld attestations now, it returns
+// the number of released attestations.
+func (s *AdminAPI) ReleaseAttestations() int {
+	return len(releaseAttestations(s.b, "admin", func(withheld.Attestation) bool { return true }))
+}
@@ -0,0 +1,69 @@
+package apis
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	ethpb "github.com/prysmaticlabs/prysm/v4/proto/prysm/v1alpha1"
+	"github.com/tsinghua-cel/attacker-service/beaconapi"
+	"github.com/tsinghua-cel/attacker-service/withheld"
+)
+
+// releaseBackend gives the withheld pool and a beacon node, the other
+// methods of Backend are not used by the release.
+type releaseBackend struct {
+	Backend
+	pool   *withheld.Pool
+	client *beaconapi.BeaconGwClient
+}
+
+func (b *releaseBackend) GetWithheldAttestations() *withheld.Pool {
+	return b.pool
+}
+
+func (b *releaseBackend) GetBeaconView(node string) (*beaconapi.BeaconGwClient, error) {
+	return b.client, nil
+}
+
+func TestReleaseFailures(t *testing.T) {
+	status, reply := http.StatusOK, ""
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var atts []beaconapi.Attestation
+		json.NewDecoder(r.Body).Decode(&atts)
+		w.WriteHeader(status)
+		w.Write([]byte(reply))
+	}))
+	defer srv.Close()
+	b := &releaseBackend{pool: withheld.New(), client: beaconapi.NewBeaconGwClient(srv.URL)}
+	att := &ethpb.Attestation{
+		AggregationBits: []byte{0x03},
+		Data: &ethpb.AttestationData{
+			BeaconBlockRoot: make([]byte, 32),
+			Source:          &ethpb.Checkpoint{Root: make([]byte, 32)},
+			Target:          &ethpb.Checkpoint{Root: make([]byte, 32)},
+		},
+		Signature: make([]byte, 96),
+	}
+	for _, pubkey := range []string{"0x01", "0x02"} {
+		b.pool.Add(withheld.Attestation{Slot: 4, Pubkey: pubkey, Attestation: att})
+	}
+	all := func(withheld.Attestation) bool { return true }
+
+	// the beacon node fails, they are released later.
+	status, reply = http.StatusServiceUnavailable, `{"code":503,"message":"syncing"}`
+	if released := releaseAttestations(b, "admin", all); len(released) != 0 || len(b.pool.List()) != 2 {
+		t.Fatalf("failed release: released %d, withheld %d", len(released), len(b.pool.List()))
+	}
+	// the second one is invalid, it is dropped.
+	status, reply = http.StatusBadRequest, `{"code":400,"message":"some failed","failures":[{"index":1,"message":"invalid signature"}]}`
+	if released := releaseAttestations(b, "admin", all); len(released) != 1 || len(b.pool.List()) != 0 {
+		t.Fatalf("partial release: released %+v, withheld %+v", released, b.pool.List())
+	}
+	b.pool.Add(withheld.Attestation{Slot: 4, Pubkey: "0x03", Attestation: att})
+	status, reply = http.StatusOK, ""
+	if released := releaseAttestations(b, "admin", all); len(released) != 1 || len(b.pool.List()) != 0 {
+		t.Fatalf("release: released %d, withheld %d", len(released), len(b.pool.List()))
+	}
+}
@@ -1,5 +1,23 @@
 package apis
 
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/tsinghua-cel/attacker-service/duties"
+	"github.com/tsinghua-cel/attacker-service/scheduler"
+	"github.com/tsinghua-cel/attacker-service/slotclock"
+	"github.com/tsinghua-cel/attacker-service/types"
+	"github.com/tsinghua-cel/attacker-service/validatorSet"
+)
+
+var (
+	ErrInvalidSlotRange = errors.New("start slot is after end slot")
+	ErrUnknownValidator = errors.New("unknown validator")
+	ErrDutiesNotCached  = errors.New("duties of epoch not cached")
+)
+
 // RoleAPI offers and API for role operations.
 type AdminAPI struct {
 	b Backend
@@ -10,12 +28,141 @@ func NewAdminAPI(b Backend) *AdminAPI {
 	return &AdminAPI{b}
 }
 
-func (s *AdminAPI) SetRoleAttacker(valIndex int) {
-	//valSet := s.b.GetValidatorDataSet()
-	//valSet.SetValidatorRole(valIndex, types.AttackerRole)
+// SetRoleAttacker makes the validator an attacker from startSlot to endSlot,
+// both are optional and unbounded if omitted.
+func (s *AdminAPI) SetRoleAttacker(valIndex int, startSlot *int64, endSlot *int64) error {
+	return s.setRole(valIndex, types.AttackerRole, startSlot, endSlot)
+}
+
+// SetRoleNormal makes the validator honest from startSlot to endSlot, both are
+// optional and unbounded if omitted.
+func (s *AdminAPI) SetRoleNormal(valIndex int, startSlot *int64, endSlot *int64) error {
+	return s.setRole(valIndex, types.NormalRole, startSlot, endSlot)
+}
+
+// ClearRole removes the runtime role of the validator, its role comes from
+// the strategy again.
+func (s *AdminAPI) ClearRole(valIndex int) error {
+	return s.b.RemoveRoleOverride(valIndex)
+}
+
+// GetRoles returns the runtime roles of the validators.
+func (s *AdminAPI) GetRoles() []validatorSet.RoleOverride {
+	return s.b.GetRoleOverrides()
+}
+
+func (s *AdminAPI) setRole(valIndex int, role types.RoleType, startSlot *int64, endSlot *int64) error {
+	o := validatorSet.RoleOverride{
+		ValidatorIndex: valIndex,
+		Role:           role,
+		StartSlot:      -1,
+		EndSlot:        -1,
+	}
+	if startSlot != nil {
+		o.StartSlot = *startSlot
+	}
+	if endSlot != nil {
+		o.EndSlot = *endSlot
+	}
+	if o.StartSlot >= 0 && o.EndSlot >= 0 && o.StartSlot > o.EndSlot {
+		return ErrInvalidSlotRange
+	}
+	return s.b.SetRoleOverride(o)
+}
+
+// GetValidator returns the validator with its status and balance from the
+// registry.
+func (s *AdminAPI) GetValidator(valIndex int) (*validatorSet.ValidatorInfo, error) {
+	v := s.b.GetValidatorDataSet().GetValidatorByIndex(valIndex)
+	if v == nil {
+		return nil, ErrUnknownValidator
+	}
+	return v, nil
+}
+
+// ListValidators returns the validators of the registry, the ones whose
+// status starts with status if given, like "active" or "exited".
+func (s *AdminAPI) ListValidators(status *string) []validatorSet.ValidatorInfo {
+	if status != nil {
+		return s.b.GetValidatorDataSet().ListValidators(*status)
+	}
+	return s.b.GetValidatorDataSet().ListValidators()
+}
+
+// GetDuties returns the cached proposer, attester and sync committee duties
+// of epoch with their dependent roots.
+func (s *AdminAPI) GetDuties(epoch uint64) (duties.EpochDuties, error) {
+	d, ok := s.b.GetDutyCache().Get(epoch)
+	if !ok {
+		return duties.EpochDuties{}, ErrDutiesNotCached
+	}
+	return d, nil
+}
+
+// ListClients returns the validator clients that called the service.
+func (s *AdminAPI) ListClients() []*validatorSet.ClientSession {
+	return s.b.GetClients()
+}
+
+// CommandSchema returns the versioned list of the commands the hooks may
+// return.
+func (s *AdminAPI) CommandSchema() types.CommandSchema {
+	return types.Commands()
+}
+
+// ListExits returns the validators asked to exit with CMD_EXIT and the slot
+// they were asked at.
+func (s *AdminAPI) ListExits() map[int]uint64 {
+	return s.b.GetValidatorDataSet().GetExitRequests()
+}
+
+// HistoryStats returns how many signed blocks and attestations are kept and
+// the oldest slot of them.
+func (s *AdminAPI) HistoryStats() validatorSet.HistoryStats {
+	return s.b.GetValidatorDataSet().HistoryStats()
+}
+
+// ListEquivocations returns the validators that signed two blocks for a slot
+// of epoch, with both blocks.
+func (s *AdminAPI) ListEquivocations(epoch uint64) []validatorSet.Equivocation {
+	return s.b.GetValidatorDataSet().EquivocationsInEpoch(epoch)
+}
+
+// ListDelays returns the pending and recently released delays of the hooks.
+func (s *AdminAPI) ListDelays() []scheduler.Delay {
+	return s.b.GetDelayScheduler().List()
+}
+
+// CancelDelay releases the delay now, clients waiting on delay_wait return.
+func (s *AdminAPI) CancelDelay(id string) error {
+	return s.b.GetDelayScheduler().Cancel(id)
+}
+
+// SlotClock returns the current slot, epoch and offset in the slot from the
+// slot clock.
+func (s *AdminAPI) SlotClock() (types.SlotClockInfo, error) {
+	clock, err := s.b.GetSlotClock()
+	if err != nil {
+		return types.SlotClockInfo{}, err
+	}
+	slot := clock.CurrentSlot()
+	info := types.SlotClockInfo{
+		Genesis:        clock.Genesis().Unix(),
+		SecondsPerSlot: int(clock.SlotDuration() / time.Second),
+		SlotsPerEpoch:  int(clock.SlotsPerEpoch()),
+		Slot:           slot,
+		Epoch:          clock.EpochOf(slot),
+		Offset:         clock.SlotOffset().Milliseconds(),
+	}
+	for i := range info.Intervals {
+		info.Intervals[i] = clock.IntervalStart(slot, slotclock.Interval(i)).UnixMilli()
+	}
+	return info, nil
 }
 
-func (s *AdminAPI) SetRoleNormal(valIndex int) {
-	//valSet := s.b.GetValidatorDataSet()
-	//valSet.SetValidatorRole(valIndex, types.NormalRole)
+// ListAttacks returns the names of the attacks that can be selected in the strategy.
+func (s *AdminAPI) ListAttacks() []string {
+	names := AttackNames()
+	sort.Strings(names)
+	return names
 }
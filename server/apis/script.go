@@ -0,0 +1,109 @@
+package apis
+
+import (
+	"encoding/base64"
+
+	ethpb "github.com/prysmaticlabs/prysm/v4/proto/prysm/v1alpha1"
+	log "github.com/sirupsen/logrus"
+	"github.com/tsinghua-cel/attacker-service/luascripts"
+	"github.com/tsinghua-cel/attacker-service/types"
+	"google.golang.org/protobuf/proto"
+)
+
+// runBlockScript passes the block decided by the built-in strategy to the lua
+// hook, and replaces the response with the script result. dataBase64 is the
+// original block used when res doesn't carry one.
+func runBlockScript(b Backend, hook string, slot uint64, pubkey string, dataBase64 string, res types.AttackerResponse) types.AttackerResponse {
+	engine := b.GetScriptEngine()
+	if engine == nil {
+		return res
+	}
+	if res.Result != "" {
+		dataBase64 = res.Result
+	}
+	block := new(ethpb.GenericSignedBeaconBlock)
+	if err := decodeProto(dataBase64, block); err != nil {
+		log.WithError(err).Error("decode block for lua script failed")
+		return res
+	}
+	fork := ""
+	if sb, err := types.NewSignedBlock(block); err == nil {
+		fork = sb.Fork()
+	}
+	cmd, modified, ok, err := engine.CallBlockHook(hook, luascripts.BlockData{
+		Slot:   slot,
+		Pubkey: pubkey,
+		Role:   b.GetValidatorRoleByPubkey(int(slot), pubkey).String(),
+		Cmd:    int(res.Cmd),
+		Fork:   fork,
+		Block:  block,
+	})
+	if err != nil {
+		log.WithError(err).WithField("hook", hook).Error("call lua hook failed")
+		return res
+	}
+	if !ok {
+		return res
+	}
+	return scriptResponse(cmd, modified, dataBase64)
+}
+
+// runAttestScript is the same as runBlockScript for attestation hooks, tmpl
+// is the type of the object carried in dataBase64.
+func runAttestScript(b Backend, hook string, slot uint64, pubkey string, dataBase64 string, tmpl proto.Message, res types.AttackerResponse) types.AttackerResponse {
+	engine := b.GetScriptEngine()
+	if engine == nil {
+		return res
+	}
+	if res.Result != "" {
+		dataBase64 = res.Result
+	}
+	if err := decodeProto(dataBase64, tmpl); err != nil {
+		log.WithError(err).Error("decode attest for lua script failed")
+		return res
+	}
+	cmd, modified, ok, err := engine.CallAttestHook(hook, luascripts.AttestData{
+		Slot:   slot,
+		Pubkey: pubkey,
+		Role:   b.GetValidatorRoleByPubkey(int(slot), pubkey).String(),
+		Cmd:    int(res.Cmd),
+		Attest: tmpl,
+	})
+	if err != nil {
+		log.WithError(err).WithField("hook", hook).Error("call lua hook failed")
+		return res
+	}
+	if !ok {
+		return res
+	}
+	return scriptResponse(cmd, modified, dataBase64)
+}
+
+func scriptResponse(cmd types.AttackerCommand, modified proto.Message, dataBase64 string) types.AttackerResponse {
+	if modified == nil {
+		return types.AttackerResponse{
+			Cmd:    cmd,
+			Result: dataBase64,
+		}
+	}
+	data, err := proto.Marshal(modified)
+	if err != nil {
+		log.WithError(err).Error("marshal lua hook result failed")
+		return types.AttackerResponse{
+			Cmd:    cmd,
+			Result: dataBase64,
+		}
+	}
+	return types.AttackerResponse{
+		Cmd:    cmd,
+		Result: base64.StdEncoding.EncodeToString(data),
+	}
+}
+
+func decodeProto(dataBase64 string, msg proto.Message) error {
+	data, err := base64.StdEncoding.DecodeString(dataBase64)
+	if err != nil {
+		return err
+	}
+	return proto.Unmarshal(data, msg)
+}
@@ -0,0 +1,71 @@
+package apis
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/tsinghua-cel/attacker-service/strategy"
+)
+
+// strategyBackend keeps a strategy, the other methods of Backend are not
+// used by the strategy updates.
+type strategyBackend struct {
+	Backend
+	st *strategy.Strategy
+}
+
+func (b *strategyBackend) GetStrategy() *strategy.Strategy {
+	return b.st
+}
+
+func (b *strategyBackend) SetStrategy(st *strategy.Strategy) error {
+	if err := st.Validate(); err != nil {
+		return err
+	}
+	b.st = st
+	return nil
+}
+
+func (b *strategyBackend) UpdateStrategy(update func(st *strategy.Strategy) error) error {
+	st := b.st.Copy()
+	if err := update(st); err != nil {
+		return err
+	}
+	return b.SetStrategy(st)
+}
+
+func TestRejectedUpdate(t *testing.T) {
+	epoch := int64(2)
+	b := &strategyBackend{st: &strategy.Strategy{
+		Block: strategy.BlockStrategy{
+			AttestInclude: strategy.IncludeAttacker,
+			Equivocate:    &strategy.EquivocateStrategy{Graffiti: "twin", Targets: []string{"a"}},
+		},
+		Attest: strategy.AttestStrategy{
+			Votes: []strategy.VoteRule{{
+				Validators: []int{1, 2},
+				Slots:      &strategy.Range{Start: 1, End: 5},
+				Source:     &strategy.Checkpoint{Epoch: &epoch},
+			}},
+		},
+	}}
+	active := b.GetStrategy()
+	before := active.Copy()
+
+	if err := NewBlockAPI(b).UpdateStrategy([]byte(`{"equivocate": {"graffiti": "a graffiti longer than 32 bytes....", "targets": ["b"]}}`)); err == nil {
+		t.Fatal("block update with a long graffiti accepted")
+	}
+	if err := NewAttestAPI(b).UpdateStrategy([]byte(`{"votes": [{"validators": [3], "slots": {"start": 9, "end": 4}, "source": {"epoch": 7}}]}`)); err == nil {
+		t.Fatal("attest update with an inverted slot range accepted")
+	}
+	if b.GetStrategy() != active || !reflect.DeepEqual(active.Copy(), before) {
+		t.Fatalf("rejected updates changed the strategy: got %+v, want %+v", active, before)
+	}
+
+	if err := NewBlockAPI(b).UpdateStrategy([]byte(`{"equivocate": {"graffiti": "other"}}`)); err != nil {
+		t.Fatal(err)
+	}
+	if got := b.GetStrategy().Block.Equivocate.Graffiti; got != "other" || active.Block.Equivocate.Graffiti != "twin" {
+		t.Fatalf("accepted update: got %q, active before %q", got, active.Block.Equivocate.Graffiti)
+	}
+}
@@ -0,0 +1,102 @@
+package apis
+
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/prysmaticlabs/prysm/v4/consensus-types/primitives"
+	ethpb "github.com/prysmaticlabs/prysm/v4/proto/prysm/v1alpha1"
+	log "github.com/sirupsen/logrus"
+	"github.com/tsinghua-cel/attacker-service/strategy"
+	"github.com/tsinghua-cel/attacker-service/types"
+)
+
+var (
+	ErrNoAttackerHead = errors.New("no attacker block found")
+	ErrNilCheckpoint  = errors.New("nil checkpoint")
+)
+
+// modifyVote rewrites data according to the vote rule of the validator,
+// it returns false if no rule applies.
+func modifyVote(b Backend, slot uint64, pubkey string, data *ethpb.AttestationData) (bool, error) {
+	val := b.GetValidatorDataSet().GetValidatorByPubkey(pubkey)
+	if val == nil {
+		return false, nil
+	}
+	rule := b.GetStrategy().VoteRuleAt(int(val.Index), int64(slot))
+	if rule == nil {
+		return false, nil
+	}
+	if rule.Head != "" {
+		head, err := voteHead(b, slot, rule.Head, data.BeaconBlockRoot)
+		if err != nil {
+			return false, err
+		}
+		data.BeaconBlockRoot = head
+	}
+	if rule.Source != nil {
+		if err := overrideCheckpoint(data.Source, rule.Source); err != nil {
+			return false, err
+		}
+	}
+	if rule.Target != nil {
+		if err := overrideCheckpoint(data.Target, rule.Target); err != nil {
+			return false, err
+		}
+	}
+	log.WithFields(log.Fields{
+		"slot":   slot,
+		"valIdx": val.Index,
+		"head":   hexutil.Encode(data.BeaconBlockRoot),
+	}).Info("modify attestation vote")
+	return true, nil
+}
+
+// voteHead returns the head root the rule votes for instead of current.
+func voteHead(b Backend, slot uint64, head string, current []byte) ([]byte, error) {
+	switch head {
+	case strategy.VoteHeadParent:
+		header, err := b.GetBeaconHeader(hexutil.Encode(current))
+		if err != nil {
+			return nil, err
+		}
+		return strategy.ParseRoot(header.Header.Message.ParentRoot)
+	case strategy.VoteHeadAttacker:
+		return attackerHead(b, slot)
+	default:
+		return strategy.ParseRoot(head)
+	}
+}
+
+// attackerHead returns the root of the latest block signed by an attacker in
+// the two epochs before slot.
+func attackerHead(b Backend, slot uint64) ([]byte, error) {
+	window := uint64(2 * b.GetSlotsPerEpoch())
+	for i := uint64(0); i <= window && i <= slot; i++ {
+		for _, signed := range attackerBlocksIn(b, b.GetValidatorDataSet().BlocksAtSlot(slot-i)) {
+			block, err := types.NewSignedBlock(signed.Block)
+			if err != nil {
+				continue
+			}
+			return block.Root()
+		}
+	}
+	return nil, ErrNoAttackerHead
+}
+
+func overrideCheckpoint(cp *ethpb.Checkpoint, rule *strategy.Checkpoint) error {
+	if cp == nil {
+		return ErrNilCheckpoint
+	}
+	if rule.Epoch != nil {
+		cp.Epoch = primitives.Epoch(*rule.Epoch)
+	}
+	if rule.Root != "" {
+		root, err := strategy.ParseRoot(rule.Root)
+		if err != nil {
+			return err
+		}
+		cp.Root = root
+	}
+	return nil
+}
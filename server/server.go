@@ -2,7 +2,6 @@ package server
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
@@ -10,25 +9,47 @@ import (
 	log "github.com/sirupsen/logrus"
 	"github.com/tsinghua-cel/attacker-service/beaconapi"
 	"github.com/tsinghua-cel/attacker-service/config"
+	"github.com/tsinghua-cel/attacker-service/duties"
+	"github.com/tsinghua-cel/attacker-service/luascripts"
+	"github.com/tsinghua-cel/attacker-service/privatechain"
 	"github.com/tsinghua-cel/attacker-service/rpc"
+	"github.com/tsinghua-cel/attacker-service/scheduler"
 	"github.com/tsinghua-cel/attacker-service/server/apis"
+	"github.com/tsinghua-cel/attacker-service/slotclock"
+	"github.com/tsinghua-cel/attacker-service/store"
 	"github.com/tsinghua-cel/attacker-service/strategy"
 	types2 "github.com/tsinghua-cel/attacker-service/types"
 	"github.com/tsinghua-cel/attacker-service/validatorSet"
+	"github.com/tsinghua-cel/attacker-service/withheld"
 	"math/big"
 	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
 type Server struct {
 	config       *config.Config
-	rpcAPIs      []rpc.API   // List of APIs currently provided by the node
-	http         *httpServer //
-	strategy     *strategy.Strategy
+	rpcAPIs      []rpc.API    // List of APIs currently provided by the node
+	http         *httpServer  //
+	strategy     atomic.Value // *strategy.Strategy
+	strategyLock sync.Mutex   // serializes the strategy updates
 	execClient   *ethclient.Client
 	beaconClient *beaconapi.BeaconGwClient
+	luaEngine    *luascripts.Engine
 
 	validatorSetInfo *validatorSet.ValidatorDataSet
+	roleOverrides    *validatorSet.RoleOverrides
+	clients          *validatorSet.ClientSet
+	delays           *scheduler.Scheduler
+	clock            atomic.Value // *slotclock.SlotClock, set once the genesis is known
+	store            *store.Store // nil if no database configured
+	duties           *duties.Cache
+	events           *beaconapi.EventStream
+	finalized        atomic.Int64 // finalized epoch from the event stream, -1 until known
+	private          *privatechain.Chain
+	withheld         *withheld.Pool
 }
 
 func NewServer() *Server {
@@ -40,13 +61,97 @@ func NewServer() *Server {
 		panic(fmt.Sprintf("dial execute failed with err:%v", err))
 	}
 	s.execClient = client
-	s.beaconClient = beaconapi.NewBeaconGwClient(s.config.BeaconRpc)
+	var opts []beaconapi.Option
+	if s.config.BeaconTimeout > 0 {
+		opts = append(opts, beaconapi.WithTimeout(time.Duration(s.config.BeaconTimeout)*time.Second))
+	}
+	if s.config.BeaconMaxLag > 0 {
+		opts = append(opts, beaconapi.WithMaxLag(uint64(s.config.BeaconMaxLag)))
+	}
+	s.beaconClient = beaconapi.NewBeaconGwClient(s.config.BeaconRpc, opts...)
+	s.events = beaconapi.NewEventStream(s.beaconClient)
+	s.finalized.Store(-1)
 	s.http = newHTTPServer(log.WithField("module", "server"), rpc.DefaultHTTPTimeouts)
-	s.strategy = strategy.ParseStrategy(config.GetConfig().Strategy)
+	if s.config.LenientStrategy {
+		s.strategy.Store(strategy.ParseStrategy(s.config.Strategy))
+	} else {
+		st, err := strategy.LoadStrategy(s.config.Strategy, false)
+		if err != nil {
+			panic(fmt.Sprintf("load strategy failed with err:%v, fix it or use --lenient-strategy", err))
+		}
+		s.strategy.Store(st)
+	}
 	s.validatorSetInfo = validatorSet.NewValidatorSet()
+	s.clients = validatorSet.NewClientSet()
+	s.delays = scheduler.New()
+	s.private = privatechain.New()
+	s.withheld = withheld.New()
+	s.duties = duties.New(s.beaconClient, s.validatorSetInfo.ValidatorIndices)
+	if s.config.Database != "" {
+		if err := s.openStore(s.config.Database); err != nil {
+			panic(fmt.Sprintf("open database failed with err:%v", err))
+		}
+	} else {
+		s.roleOverrides, err = validatorSet.NewRoleOverrides(s.config.RoleFile)
+		if err != nil {
+			panic(fmt.Sprintf("load role overrides failed with err:%v", err))
+		}
+	}
+	if s.config.LuaScript != "" {
+		engine, err := luascripts.NewEngine(s.config.LuaScript)
+		if err != nil {
+			panic(fmt.Sprintf("load lua script failed with err:%v", err))
+		}
+		s.luaEngine = engine
+	}
 	return s
 }
 
+// openStore opens the database, reloads what was saved before the restart
+// and saves the signed data, the role overrides, the delays and the withheld
+// attestations to it.
+func (s *Server) openStore(path string) error {
+	db, err := store.Open(path)
+	if err != nil {
+		return err
+	}
+	attests, err := db.Attestations()
+	if err != nil {
+		return err
+	}
+	blocks, err := db.Blocks()
+	if err != nil {
+		return err
+	}
+	delays, err := db.Delays()
+	if err != nil {
+		return err
+	}
+	withheldAtts, err := db.WithheldAttestations()
+	if err != nil {
+		return err
+	}
+	s.roleOverrides, err = validatorSet.NewRoleOverridesFromStore(db)
+	if err != nil {
+		return err
+	}
+	s.validatorSetInfo.Restore(attests, blocks)
+	s.validatorSetInfo.SetStore(db)
+	s.delays.Restore(delays)
+	s.delays.SetStore(db)
+	s.withheld.Restore(withheldAtts)
+	s.withheld.SetStore(db)
+	s.store = db
+	log.WithFields(log.Fields{
+		"attestations": len(attests),
+		"blocks":       len(blocks),
+		"delays":       len(delays),
+		"withheld":     len(withheldAtts),
+		"roles":        len(s.roleOverrides.List()),
+	}).Info("database loaded")
+	return nil
+}
+
 // startRPC is a helper method to configure all the various RPC endpoints during node
 // startup. It's not meant to be called at any time afterwards as it makes certain
 // assumptions about the state of the node.
@@ -97,38 +202,148 @@ func (s *Server) monitorDuties() {
 	ticker := time.NewTicker(time.Millisecond * 100)
 	defer ticker.Stop()
 
-	validatorUpdateTicker := time.NewTicker(time.Second)
-	defer validatorUpdateTicker.Stop()
-
 	for {
 		select {
-		case <-validatorUpdateTicker.C:
-			//latest, err := s.beaconClient.GetLatestBeaconHeader()
-			//if err != nil {
-			//	continue
-			//}
-			//slot, _ := strconv.Atoi(latest.Header.Message.Slot)
-			//for _, val := range s.strategy.Validators {
-			//	valRole := s.GetValidatorRole(slot, val.ValidatorIndex)
-			//	if slot >= val.AttackerStartSlot && slot <= val.AttackerEndSlot && valRole != types2.AttackerRole {
-			//		s.validatorSetInfo.SetValidatorRole(val.ValidatorIndex, types2.AttackerRole)
-			//	} else if slot > val.AttackerEndSlot && valRole == types2.AttackerRole {
-			//		s.validatorSetInfo.SetValidatorRole(val.ValidatorIndex, types2.NormalRole)
-			//	}
-			//}
-
 		case <-ticker.C:
-			duties, err := s.beaconClient.GetCurrentEpochAttestDuties()
+			if err := s.syncValidators(); err != nil {
+				log.WithError(err).Debug("sync validator registry failed, retry later")
+				ticker.Reset(time.Second * 2)
+				continue
+			}
+			s.GetStrategy().SetSlotsPerEpoch(s.GetSlotsPerEpoch())
+			s.validatorSetInfo.SetSlotsPerEpoch(s.GetSlotsPerEpoch())
+
+			// the registry changes at epoch boundaries only.
+			ticker.Reset(time.Duration(s.GetSlotsPerEpoch()*s.GetIntervalPerSlot()) * time.Second)
+
+		}
+	}
+}
+
+// syncValidators loads the full validator registry from the head state, so
+// that every pubkey resolves to its index, and updates the deposits, exits
+// and balances.
+func (s *Server) syncValidators() error {
+	states, err := s.beaconClient.GetStateValidators("head")
+	if err != nil {
+		return err
+	}
+	list := make([]validatorSet.ValidatorInfo, 0, len(states))
+	for _, state := range states {
+		index, err := strconv.ParseInt(state.Index, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid validator index %q", state.Index)
+		}
+		v := validatorSet.ValidatorInfo{
+			Index:   index,
+			Pubkey:  state.Validator.Pubkey,
+			Status:  state.Status,
+			Slashed: state.Validator.Slashed,
+		}
+		v.Balance, _ = strconv.ParseUint(state.Balance, 10, 64)
+		v.EffectiveBalance, _ = strconv.ParseUint(state.Validator.EffectiveBalance, 10, 64)
+		v.ActivationEpoch, _ = strconv.ParseUint(state.Validator.ActivationEpoch, 10, 64)
+		v.ExitEpoch, _ = strconv.ParseUint(state.Validator.ExitEpoch, 10, 64)
+		list = append(list, v)
+	}
+	changes := s.validatorSetInfo.SyncValidators(list)
+	if changes.Added > 0 || changes.Exited > 0 {
+		log.WithFields(log.Fields{
+			"validators": len(list),
+			"added":      changes.Added,
+			"exited":     changes.Exited,
+			"updated":    changes.Updated,
+		}).Info("validator registry synced")
+	}
+	return nil
+}
+
+// retention returns the retention policy of the signed blocks and
+// attestations from the config, the last 4 epochs by default and everything
+// with retain_epochs = 0.
+func (s *Server) retention() validatorSet.Retention {
+	r := validatorSet.Retention{Epochs: 4}
+	if n := s.config.RetainEpochs; n != nil {
+		if *n < 0 {
+			log.WithField("retain_epochs", *n).Warn("negative retain_epochs, keep everything")
+			r.Epochs = 0
+		} else {
+			r.Epochs = uint64(*n)
+		}
+	}
+	r.Finalized = s.config.RetentionMode == "finalized"
+	return r
+}
+
+// pruneHistory drops the signed blocks and attestations out of the retention
+// at every epoch start.
+func (s *Server) pruneHistory() {
+	var clock *slotclock.SlotClock
+	for {
+		var err error
+		if clock, err = s.GetSlotClock(); err == nil {
+			break
+		}
+		time.Sleep(time.Second * 2)
+	}
+	retention := s.retention()
+	ticker := clock.NewTicker(slotclock.SlotStart)
+	defer ticker.Stop()
+	for tick := range ticker.C {
+		if tick.Slot%clock.SlotsPerEpoch() != 0 {
+			continue
+		}
+		var finalized uint64
+		if epoch := s.finalized.Load(); retention.Finalized && epoch >= 0 {
+			finalized = uint64(epoch)
+		} else if retention.Finalized {
+			checkpoints, err := s.beaconClient.GetFinalityCheckpoints("head")
 			if err != nil {
+				log.WithError(err).Warn("get finalized checkpoint failed, skip pruning")
 				continue
 			}
-			for _, duty := range duties {
-				idx, _ := strconv.Atoi(duty.ValidatorIndex)
-				s.validatorSetInfo.AddValidator(idx, duty.Pubkey)
+			epoch, _ := strconv.ParseUint(checkpoints.Finalized.Epoch, 10, 64)
+			finalized = epoch
+		}
+		oldest := retention.OldestSlot(clock.EpochOf(tick.Slot), finalized, clock.SlotsPerEpoch())
+		if n := s.validatorSetInfo.PruneBefore(oldest); n > 0 {
+			log.WithFields(log.Fields{
+				"before":  oldest,
+				"removed": n,
+			}).Debug("pruned signed history")
+		}
+		// withheld attestations older than an epoch can't be included.
+		if tick.Slot >= clock.SlotsPerEpoch() {
+			if n := s.withheld.PruneBefore(tick.Slot - clock.SlotsPerEpoch()); n > 0 {
+				log.WithField("dropped", n).Info("withheld attestations expired")
 			}
+		}
+	}
+}
 
-			ticker.Reset(time.Second * 2)
-
+// refreshDuties fills the duty cache for the current and the next epoch at
+// 1/3 of every slot, when the block of the slot is known, so that the duties
+// are reloaded soon after their dependent root changed.
+func (s *Server) refreshDuties() {
+	var clock *slotclock.SlotClock
+	for {
+		var err error
+		if clock, err = s.GetSlotClock(); err == nil {
+			break
+		}
+		time.Sleep(time.Second * 2)
+	}
+	ticker := clock.NewTicker(slotclock.OneThird)
+	defer ticker.Stop()
+	for tick := range ticker.C {
+		epoch := clock.EpochOf(tick.Slot)
+		for _, e := range []uint64{epoch, epoch + 1} {
+			if err := s.duties.Refresh(e); err != nil {
+				log.WithError(err).WithField("epoch", e).Debug("refresh duties failed")
+			}
+		}
+		if epoch > 0 {
+			s.duties.PruneBefore(epoch - 1)
 		}
 	}
 }
@@ -139,8 +354,19 @@ func (s *Server) Start() {
 	if err != nil {
 		s.stopRPC()
 	}
+	go s.initSlotClock()
+	go s.events.Run(context.Background())
+	go s.handleEvents()
 	// start collect duties info.
 	go s.monitorDuties()
+	go s.refreshDuties()
+	go s.pruneHistory()
+	if len(s.beaconClient.Nodes()) > 1 {
+		go s.checkBeaconNodes()
+	}
+	if s.config.Strategy != "" {
+		go s.watchStrategy(s.config.Strategy)
+	}
 }
 
 func (s *Server) stopRPC() {
@@ -164,18 +390,63 @@ func (s *Server) GetHeightByNumber(number *big.Int) (*types.Header, error) {
 	return s.execClient.HeaderByNumber(context.Background(), number)
 }
 
+// GetStrategy returns the current strategy. The returned strategy must not
+// be modified, use SetStrategy to replace it.
 func (s *Server) GetStrategy() *strategy.Strategy {
-	return s.strategy
+	return s.strategy.Load().(*strategy.Strategy)
 }
 
-func (s *Server) UpdateBlockBroadDelay(milliSecond int64) error {
-	s.strategy.Block.BroadCastDelay = milliSecond
+// SetStrategy validates st and replaces the current strategy with it.
+func (s *Server) SetStrategy(st *strategy.Strategy) error {
+	s.strategyLock.Lock()
+	defer s.strategyLock.Unlock()
+	return s.setStrategy(st)
+}
+
+// UpdateStrategy applies update to a copy of the current strategy and
+// replaces the strategy with it, no other update can happen in between.
+func (s *Server) UpdateStrategy(update func(st *strategy.Strategy) error) error {
+	s.strategyLock.Lock()
+	defer s.strategyLock.Unlock()
+	st := s.GetStrategy().Copy()
+	if err := update(st); err != nil {
+		return err
+	}
+	return s.setStrategy(st)
+}
+
+func (s *Server) setStrategy(st *strategy.Strategy) error {
+	if err := st.Validate(); err != nil {
+		return err
+	}
+	old := s.GetStrategy()
+	st.SetSlotsPerEpoch(s.GetSlotsPerEpoch())
+	s.strategy.Store(st)
+
+	changes := strategy.Diff(old, st)
+	log.WithField("changes", len(changes)).Info("strategy updated")
+	for _, c := range changes {
+		log.Info("strategy changed ", c)
+	}
 	return nil
 }
 
+func (s *Server) GetScriptEngine() *luascripts.Engine {
+	return s.luaEngine
+}
+
+func (s *Server) UpdateBlockBroadDelay(milliSecond int64) error {
+	return s.UpdateStrategy(func(st *strategy.Strategy) error {
+		st.Block.BroadCastDelay = milliSecond
+		return nil
+	})
+}
+
 func (s *Server) UpdateAttestBroadDelay(milliSecond int64) error {
-	s.strategy.Attest.BroadCastDelay = milliSecond
-	return nil
+	return s.UpdateStrategy(func(st *strategy.Strategy) error {
+		st.Attest.BroadCastDelay = milliSecond
+		return nil
+	})
 }
 
 func (s *Server) GetValidatorRoleByPubkey(slot int, pubkey string) types2.RoleType {
@@ -187,14 +458,35 @@ func (s *Server) GetValidatorRoleByPubkey(slot int, pubkey string) types2.RoleTy
 }
 
 func (s *Server) GetCurrentEpochProposeDuties() ([]beaconapi.ProposerDuty, error) {
-	return s.beaconClient.GetCurrentEpochProposerDuties()
+	epoch, err := s.currentEpoch()
+	if err != nil {
+		return nil, err
+	}
+	return s.duties.Proposers(epoch)
 }
 
+// GetCurrentEpochAttestDuties returns the attester duties of all validators
+// of the registry in the current epoch.
 func (s *Server) GetCurrentEpochAttestDuties() ([]beaconapi.AttestDuty, error) {
-	return s.beaconClient.GetCurrentEpochAttestDuties()
+	epoch, err := s.currentEpoch()
+	if err != nil {
+		return nil, err
+	}
+	return s.duties.Attesters(epoch)
+}
+
+func (s *Server) currentEpoch() (uint64, error) {
+	slot, err := s.GetCurrentSlot()
+	if err != nil {
+		return 0, err
+	}
+	return uint64(slot) / uint64(s.GetSlotsPerEpoch()), nil
 }
 
 func (s *Server) GetSlotsPerEpoch() int {
+	if clock, ok := s.clock.Load().(*slotclock.SlotClock); ok {
+		return int(clock.SlotsPerEpoch())
+	}
 	count, err := s.beaconClient.GetIntConfig(beaconapi.SLOTS_PER_EPOCH)
 	if err != nil {
 		return 6
@@ -203,6 +495,9 @@ func (s *Server) GetSlotsPerEpoch() int {
 }
 
 func (s *Server) GetIntervalPerSlot() int {
+	if clock, ok := s.clock.Load().(*slotclock.SlotClock); ok {
+		return int(clock.SlotDuration() / time.Second)
+	}
 	interval, err := s.beaconClient.GetIntConfig(beaconapi.SECONDS_PER_SLOT)
 	if err != nil {
 		return 12
@@ -218,50 +513,264 @@ func (s *Server) AddSignedBlock(slot uint64, pubkey string, block *ethpb.Generic
 	s.validatorSetInfo.AddSignedBlock(slot, pubkey, block)
 }
 
-func (s *Server) GetAttestSet(slot uint64) *validatorSet.SlotAttestSet {
-	return s.validatorSetInfo.GetAttestSet(slot)
+func (s *Server) GetValidatorDataSet() *validatorSet.ValidatorDataSet {
+	return s.validatorSetInfo
 }
 
-func (s *Server) GetBlockSet(slot uint64) *validatorSet.SlotBlockSet {
-	return s.validatorSetInfo.GetBlockSet(slot)
+func (s *Server) GetValidatorByProposeSlot(slot uint64) (int, error) {
+	return s.duties.ProposerAt(slot, uint64(s.GetSlotsPerEpoch()))
 }
 
-func (s *Server) GetValidatorDataSet() *validatorSet.ValidatorDataSet {
-	return s.validatorSetInfo
+func (s *Server) GetProposeDuties(epoch int) ([]beaconapi.ProposerDuty, error) {
+	return s.duties.Proposers(uint64(epoch))
 }
 
-func (s *Server) GetValidatorByProposeSlot(slot uint64) (int, error) {
-	epochPerSlot := uint64(s.GetSlotsPerEpoch())
-	epoch := slot / epochPerSlot
-	duties, err := s.beaconClient.GetProposerDuties(int(epoch))
+func (s *Server) GetDutyCache() *duties.Cache {
+	return s.duties
+}
+
+func (s *Server) GetEventStream() *beaconapi.EventStream {
+	return s.events
+}
+
+func (s *Server) GetPrivateChain() *privatechain.Chain {
+	return s.private
+}
+
+func (s *Server) GetWithheldAttestations() *withheld.Pool {
+	return s.withheld
+}
+
+func (s *Server) SlotsPerEpoch() int {
+	return s.GetSlotsPerEpoch()
+}
+
+// GetCurrentSlot returns the current slot from the slot clock, the head of
+// the beacon node is only asked while the clock isn't initialised.
+func (s *Server) GetCurrentSlot() (int64, error) {
+	if clock, err := s.GetSlotClock(); err == nil {
+		return int64(clock.CurrentSlot()), nil
+	}
+	header, err := s.beaconClient.GetLatestBeaconHeader()
 	if err != nil {
 		return 0, err
 	}
-	for _, duty := range duties {
-		dutySlot, _ := strconv.ParseInt(duty.Slot, 10, 64)
-		if uint64(dutySlot) == slot {
-			idx, _ := strconv.Atoi(duty.ValidatorIndex)
-			return idx, nil
+	return strconv.ParseInt(header.Header.Message.Slot, 10, 64)
+}
+
+func (s *Server) GetDelayScheduler() *scheduler.Scheduler {
+	return s.delays
+}
+
+// GetSlotClock returns the slot clock, it is initialised from the genesis
+// and the spec of the beacon node on first success.
+func (s *Server) GetSlotClock() (*slotclock.SlotClock, error) {
+	if clock, ok := s.clock.Load().(*slotclock.SlotClock); ok {
+		return clock, nil
+	}
+	info, err := s.beaconClient.GetGenesis()
+	if err != nil {
+		return nil, err
+	}
+	genesis, err := strconv.ParseInt(info.GenesisTime, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	secondsPerSlot, err := s.beaconClient.GetIntConfig(beaconapi.SECONDS_PER_SLOT)
+	if err != nil {
+		return nil, err
+	}
+	slotsPerEpoch, err := s.beaconClient.GetIntConfig(beaconapi.SLOTS_PER_EPOCH)
+	if err != nil {
+		return nil, err
+	}
+	if secondsPerSlot <= 0 || slotsPerEpoch <= 0 {
+		return nil, fmt.Errorf("invalid spec, seconds per slot %d, slots per epoch %d", secondsPerSlot, slotsPerEpoch)
+	}
+	clock := slotclock.New(time.Unix(genesis, 0), secondsPerSlot, slotsPerEpoch)
+	s.clock.Store(clock)
+	log.WithFields(log.Fields{
+		"genesis":        clock.Genesis(),
+		"secondsPerSlot": secondsPerSlot,
+		"slotsPerEpoch":  slotsPerEpoch,
+	}).Info("slot clock initialised")
+	return clock, nil
+}
+
+// initSlotClock retries until the beacon node gives the genesis.
+func (s *Server) initSlotClock() {
+	for {
+		_, err := s.GetSlotClock()
+		if err == nil {
+			return
 		}
+		log.WithError(err).Debug("init slot clock failed, retry later")
+		time.Sleep(time.Second * 2)
 	}
-	return 0, errors.New("not found")
 }
 
-func (s *Server) GetProposeDuties(epoch int) ([]beaconapi.ProposerDuty, error) {
-	return s.beaconClient.GetProposerDuties(epoch)
+func (s *Server) GetBeaconHeader(blockId string) (beaconapi.BeaconHeaderInfo, error) {
+	return s.beaconClient.GetBeaconHeader(blockId)
 }
 
-func (s *Server) SlotsPerEpoch() int {
-	return s.GetSlotsPerEpoch()
+func (s *Server) GetBeaconHeadersBySlot(slot uint64) ([]beaconapi.BeaconHeaderInfo, error) {
+	return s.beaconClient.GetBeaconHeadersBySlot(slot)
+}
+
+func (s *Server) GetFinalityCheckpoints(stateId string) (beaconapi.FinalityCheckpoints, error) {
+	return s.beaconClient.GetFinalityCheckpoints(stateId)
+}
+
+func (s *Server) GetCommittees(stateId string, filter beaconapi.CommitteeFilter) ([]beaconapi.Committee, error) {
+	return s.beaconClient.GetCommittees(stateId, filter)
+}
+
+func (s *Server) GetForkChoice() (beaconapi.ForkChoice, error) {
+	return s.beaconClient.GetForkChoice()
+}
+
+func (s *Server) GetBeaconNodes() []beaconapi.NodeStatus {
+	return s.beaconClient.Nodes()
+}
+
+func (s *Server) GetBeaconView(node string) (*beaconapi.BeaconGwClient, error) {
+	if node == "" {
+		return s.beaconClient, nil
+	}
+	return s.beaconClient.View(node)
 }
 
 func (s *Server) GetValidatorRole(slot int, valIdx int) types2.RoleType {
 	if slot < 0 {
-		header, err := s.beaconClient.GetLatestBeaconHeader()
+		current, err := s.GetCurrentSlot()
 		if err != nil {
 			return types2.NormalRole
 		}
-		slot, _ = strconv.Atoi(header.Header.Message.Slot)
+		slot = int(current)
+	}
+	if role, ok := s.roleOverrides.Get(valIdx, int64(slot)); ok {
+		return role
+	}
+	return s.GetStrategy().GetValidatorRole(valIdx, int64(slot))
+}
+
+func (s *Server) SetRoleOverride(o validatorSet.RoleOverride) error {
+	if err := s.roleOverrides.Set(o); err != nil {
+		return err
+	}
+	log.WithFields(log.Fields{
+		"valIdx":    o.ValidatorIndex,
+		"role":      o.Role,
+		"startSlot": o.StartSlot,
+		"endSlot":   o.EndSlot,
+	}).Info("validator role overridden")
+	return nil
+}
+
+func (s *Server) RemoveRoleOverride(valIdx int) error {
+	if err := s.roleOverrides.Remove(valIdx); err != nil {
+		return err
+	}
+	log.WithField("valIdx", valIdx).Info("validator role override removed")
+	return nil
+}
+
+func (s *Server) GetRoleOverrides() []validatorSet.RoleOverride {
+	return s.roleOverrides.List()
+}
+
+func (s *Server) TrackClient(info types2.ClientInfo, remoteAddr string, method string, slot uint64, valIdx int) {
+	s.clients.Seen(info, remoteAddr, method, slot, valIdx)
+}
+
+// GetClients returns the validator clients, the ones that haven't called for
+// two epochs are stale.
+func (s *Server) GetClients() []*validatorSet.ClientSession {
+	staleAfter := time.Duration(2*s.GetSlotsPerEpoch()*s.GetIntervalPerSlot()) * time.Second
+	return s.clients.List(staleAfter)
+}
+
+// handleEvents keeps the duty cache and the finalized epoch up to date from
+// the event stream of the beacon node.
+func (s *Server) handleEvents() {
+	sub := s.events.Subscribe(64, beaconapi.TopicHead, beaconapi.TopicBlock, beaconapi.TopicChainReorg,
+		beaconapi.TopicFinalizedCheckpoint, beaconapi.TopicVoluntaryExit)
+	defer sub.Unsubscribe()
+	for ev := range sub.C {
+		switch data := ev.Data.(type) {
+		case *beaconapi.HeadEvent:
+			slot, err := strconv.ParseUint(data.Slot, 10, 64)
+			if err != nil {
+				continue
+			}
+			apis.ReleaseOnHead(s, ev.Received)
+			epoch := slot / uint64(s.GetSlotsPerEpoch())
+			if s.duties.OnHead(epoch, data.CurrentDutyDependentRoot, data.PreviousDutyDependentRoot) {
+				for _, e := range []uint64{epoch, epoch + 1} {
+					if err := s.duties.Refresh(e); err != nil {
+						log.WithError(err).WithField("epoch", e).Debug("refresh duties failed")
+					}
+				}
+			}
+		case *beaconapi.BlockEvent:
+			if slot, err := strconv.ParseUint(data.Slot, 10, 64); err == nil {
+				apis.OnPublicBlock(s, slot, data.Block)
+				apis.OnAttackBlock(s, slot, data.Block)
+				apis.ReleaseOnBlock(s, slot)
+			}
+		case *beaconapi.ChainReorgEvent:
+			log.WithFields(log.Fields{
+				"slot":     data.Slot,
+				"depth":    data.Depth,
+				"old_head": data.OldHeadBlock,
+				"new_head": data.NewHeadBlock,
+			}).Warn("chain reorg")
+		case *beaconapi.FinalizedCheckpointEvent:
+			if epoch, err := strconv.ParseInt(data.Epoch, 10, 64); err == nil {
+				s.finalized.Store(epoch)
+			}
+			log.WithFields(log.Fields{
+				"epoch": data.Epoch,
+				"block": data.Block,
+			}).Info("checkpoint finalized")
+		case *beaconapi.VoluntaryExitEvent:
+			log.WithFields(log.Fields{
+				"validator": data.Message.ValidatorIndex,
+				"epoch":     data.Message.Epoch,
+			}).Info("voluntary exit")
+		}
+	}
+}
+
+// checkBeaconNodes asks the beacon nodes for their head and finality at 2/3
+// of every slot, the requests avoid the nodes behind and the divergences are
+// reported with beacon_check.
+func (s *Server) checkBeaconNodes() {
+	var clock *slotclock.SlotClock
+	for {
+		var err error
+		if clock, err = s.GetSlotClock(); err == nil {
+			break
+		}
+		time.Sleep(time.Second * 2)
+	}
+	ticker := clock.NewTicker(slotclock.TwoThirds)
+	defer ticker.Stop()
+	var last string
+	for tick := range ticker.C {
+		nodes := s.beaconClient.CheckNodes()
+		if !s.config.BeaconCheck {
+			continue
+		}
+		reasons := beaconapi.Divergence(nodes)
+		// report a divergence once, and when it ends.
+		if report := strings.Join(reasons, "; "); report != last {
+			if len(reasons) > 0 {
+				log.WithField("slot", tick.Slot).Warn("beacon nodes diverged: ", report)
+			} else {
+				log.WithField("slot", tick.Slot).Info("beacon nodes agree again")
+			}
+			last = report
+		}
 	}
-	return s.strategy.GetValidatorRole(valIdx, int64(slot))
 }
@@ -0,0 +1,69 @@
+package server
+
+import (
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	log "github.com/sirupsen/logrus"
+	"github.com/tsinghua-cel/attacker-service/strategy"
+)
+
+const strategyReloadDelay = 200 * time.Millisecond
+
+// watchStrategy reloads the strategy file when it changes. The directory is
+// watched instead of the file, so editors that replace the file by rename
+// are handled too.
+func (s *Server) watchStrategy(file string) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.WithError(err).Error("create strategy watcher failed")
+		return
+	}
+	defer watcher.Close()
+
+	file = filepath.Clean(file)
+	if err := watcher.Add(filepath.Dir(file)); err != nil {
+		log.WithError(err).WithField("file", file).Error("watch strategy file failed")
+		return
+	}
+	log.WithField("file", file).Info("watching strategy file")
+
+	// events come in bursts when a file is saved, reload once after it settles.
+	reload := time.NewTimer(strategyReloadDelay)
+	reload.Stop()
+	defer reload.Stop()
+
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if filepath.Clean(event.Name) != file {
+				continue
+			}
+			if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) != 0 {
+				reload.Reset(strategyReloadDelay)
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			log.WithError(err).Error("strategy watcher error")
+		case <-reload.C:
+			s.reloadStrategy(file)
+		}
+	}
+}
+
+func (s *Server) reloadStrategy(file string) {
+	st, err := strategy.LoadStrategy(file, s.config.LenientStrategy)
+	if err != nil {
+		log.WithError(err).WithField("file", file).Error("reload strategy failed, keep the previous strategy")
+		return
+	}
+	if err := s.SetStrategy(st); err != nil {
+		log.WithError(err).WithField("file", file).Error("reload strategy failed, keep the previous strategy")
+	}
+}
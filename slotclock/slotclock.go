@@ -0,0 +1,158 @@
+package slotclock
+
+import (
+	"sync"
+	"time"
+)
+
+// Interval is a point within a slot, the spec has the block proposed at the
+// slot start, attestations at 1/3 and aggregates at 2/3 of the slot.
+type Interval int
+
+const (
+	SlotStart Interval = iota
+	OneThird
+	TwoThirds
+	intervalsPerSlot
+)
+
+func (i Interval) String() string {
+	switch i {
+	case SlotStart:
+		return "slot_start"
+	case OneThird:
+		return "one_third"
+	case TwoThirds:
+		return "two_thirds"
+	}
+	return "unknown"
+}
+
+// SlotClock computes slots from the genesis time and the spec, so the current
+// slot needs no request to the beacon node.
+type SlotClock struct {
+	genesis       time.Time
+	slotDuration  time.Duration
+	slotsPerEpoch uint64
+
+	now func() time.Time
+}
+
+func New(genesis time.Time, secondsPerSlot int, slotsPerEpoch int) *SlotClock {
+	return &SlotClock{
+		genesis:       genesis,
+		slotDuration:  time.Duration(secondsPerSlot) * time.Second,
+		slotsPerEpoch: uint64(slotsPerEpoch),
+		now:           time.Now,
+	}
+}
+
+func (c *SlotClock) Genesis() time.Time {
+	return c.genesis
+}
+
+func (c *SlotClock) SlotDuration() time.Duration {
+	return c.slotDuration
+}
+
+func (c *SlotClock) SlotsPerEpoch() uint64 {
+	return c.slotsPerEpoch
+}
+
+// SlotAt returns the slot at t, slot 0 before genesis.
+func (c *SlotClock) SlotAt(t time.Time) uint64 {
+	if t.Before(c.genesis) {
+		return 0
+	}
+	return uint64(t.Sub(c.genesis) / c.slotDuration)
+}
+
+func (c *SlotClock) CurrentSlot() uint64 {
+	return c.SlotAt(c.now())
+}
+
+func (c *SlotClock) CurrentEpoch() uint64 {
+	return c.CurrentSlot() / c.slotsPerEpoch
+}
+
+// EpochOf returns the epoch of slot.
+func (c *SlotClock) EpochOf(slot uint64) uint64 {
+	return slot / c.slotsPerEpoch
+}
+
+// SlotOffset returns the time elapsed since the start of the current slot,
+// 0 before genesis.
+func (c *SlotClock) SlotOffset() time.Duration {
+	now := c.now()
+	if now.Before(c.genesis) {
+		return 0
+	}
+	return now.Sub(c.genesis) % c.slotDuration
+}
+
+// SlotStart returns the start time of slot.
+func (c *SlotClock) SlotStart(slot uint64) time.Time {
+	return c.genesis.Add(time.Duration(slot) * c.slotDuration)
+}
+
+// IntervalStart returns the time of interval in slot.
+func (c *SlotClock) IntervalStart(slot uint64, interval Interval) time.Time {
+	return c.SlotStart(slot).Add(time.Duration(interval) * c.slotDuration / time.Duration(intervalsPerSlot))
+}
+
+// next returns the first time of interval after t, and its slot.
+func (c *SlotClock) next(t time.Time, interval Interval) (uint64, time.Time) {
+	slot := c.SlotAt(t)
+	at := c.IntervalStart(slot, interval)
+	for !at.After(t) {
+		slot++
+		at = c.IntervalStart(slot, interval)
+	}
+	return slot, at
+}
+
+// Tick is sent by a Ticker when interval of Slot starts.
+type Tick struct {
+	Slot     uint64
+	Interval Interval
+	Time     time.Time
+}
+
+// Ticker sends a Tick at the given interval of every slot. Ticks are dropped
+// if the receiver is not ready, like time.Ticker.
+type Ticker struct {
+	C    <-chan Tick
+	stop chan struct{}
+	once sync.Once
+}
+
+// NewTicker returns a ticker for interval of every slot, starting with the
+// next one.
+func (c *SlotClock) NewTicker(interval Interval) *Ticker {
+	ch := make(chan Tick, 1)
+	t := &Ticker{C: ch, stop: make(chan struct{})}
+	go c.tick(interval, ch, t.stop)
+	return t
+}
+
+func (c *SlotClock) tick(interval Interval, ch chan<- Tick, stop <-chan struct{}) {
+	for {
+		slot, at := c.next(c.now(), interval)
+		timer := time.NewTimer(at.Sub(c.now()))
+		select {
+		case <-timer.C:
+		case <-stop:
+			timer.Stop()
+			return
+		}
+		select {
+		case ch <- Tick{Slot: slot, Interval: interval, Time: at}:
+		default:
+		}
+	}
+}
+
+// Stop turns off the ticker, no more ticks are sent.
+func (t *Ticker) Stop() {
+	t.once.Do(func() { close(t.stop) })
+}
@@ -0,0 +1,54 @@
+package slotclock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSlotClock(t *testing.T) {
+	genesis := time.Unix(1700000000, 0)
+	c := New(genesis, 12, 32)
+	now := genesis.Add(100*12*time.Second + 5*time.Second)
+	c.now = func() time.Time { return now }
+
+	if slot := c.CurrentSlot(); slot != 100 {
+		t.Fatalf("got slot %d, want 100", slot)
+	}
+	if epoch := c.CurrentEpoch(); epoch != 3 {
+		t.Fatalf("got epoch %d, want 3", epoch)
+	}
+	if offset := c.SlotOffset(); offset != 5*time.Second {
+		t.Fatalf("got offset %v, want 5s", offset)
+	}
+	if at := c.IntervalStart(100, TwoThirds); !at.Equal(genesis.Add(100*12*time.Second + 8*time.Second)) {
+		t.Fatalf("got 2/3 of slot 100 at %v", at)
+	}
+	if slot, at := c.next(now, OneThird); slot != 101 || !at.Equal(c.IntervalStart(101, OneThird)) {
+		t.Fatalf("next 1/3 is slot %d at %v, want slot 101", slot, at)
+	}
+	if slot, _ := c.next(now, TwoThirds); slot != 100 {
+		t.Fatalf("next 2/3 is slot %d, want 100", slot)
+	}
+
+	now = genesis.Add(-time.Minute)
+	if slot, offset := c.CurrentSlot(), c.SlotOffset(); slot != 0 || offset != 0 {
+		t.Fatalf("before genesis got slot %d offset %v", slot, offset)
+	}
+}
+
+func TestTicker(t *testing.T) {
+	c := New(time.Now().Add(-10*time.Second), 1, 4)
+	ticker := c.NewTicker(OneThird)
+	defer ticker.Stop()
+	select {
+	case tick := <-ticker.C:
+		if tick.Interval != OneThird || !tick.Time.Equal(c.IntervalStart(tick.Slot, OneThird)) {
+			t.Fatalf("unexpected tick %+v", tick)
+		}
+		if tick.Slot < 10 {
+			t.Fatalf("got tick of past slot %d", tick.Slot)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("no tick")
+	}
+}
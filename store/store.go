@@ -0,0 +1,358 @@
+package store
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	ethpb "github.com/prysmaticlabs/prysm/v4/proto/prysm/v1alpha1"
+	"github.com/tsinghua-cel/attacker-service/scheduler"
+	"github.com/tsinghua-cel/attacker-service/validatorSet"
+	"github.com/tsinghua-cel/attacker-service/withheld"
+	bolt "go.etcd.io/bbolt"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	attestationsBucket = []byte("attestations")
+	blocksBucket       = []byte("blocks")
+	equivocationBucket = []byte("equivocations") // second blocks of a validator for a slot
+	rolesBucket        = []byte("roles")
+	delaysBucket       = []byte("delays")
+	withheldBucket     = []byte("withheld") // attestations withheld until a release rule
+)
+
+var ErrClosed = errors.New("store is closed")
+
+// Store is the embedded database of the service. It keeps the signed
+// attestations and blocks, the role overrides and the delays of the hooks so
+// that an experiment survives a restart of the service.
+type Store struct {
+	db   *bolt.DB // nil once closed
+	lock sync.RWMutex
+}
+
+// Open opens the database at path, creating it if needed.
+func Open(path string) (*Store, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{attestationsBucket, blocksBucket, equivocationBucket, rolesBucket, delaysBucket, withheldBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Store{db: db}, nil
+}
+
+// Close closes the database, the methods of the store return ErrClosed
+// afterwards.
+func (s *Store) Close() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.db == nil {
+		return ErrClosed
+	}
+	err := s.db.Close()
+	s.db = nil
+	return err
+}
+
+func (s *Store) view(fn func(tx *bolt.Tx) error) error {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if s.db == nil {
+		return ErrClosed
+	}
+	return s.db.View(fn)
+}
+
+func (s *Store) update(fn func(tx *bolt.Tx) error) error {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if s.db == nil {
+		return ErrClosed
+	}
+	return s.db.Update(fn)
+}
+
+func (s *Store) batch(fn func(tx *bolt.Tx) error) error {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if s.db == nil {
+		return ErrClosed
+	}
+	return s.db.Batch(fn)
+}
+
+// signedRecord is a signed attestation or block on disk, the slot and the
+// pubkey are in the key.
+type signedRecord struct {
+	Epoch          uint64 `json:"epoch"`
+	ValidatorIndex int    `json:"validator_index"`
+	Data           []byte `json:"data"` // protobuf encoding
+}
+
+// slotKey orders the records by slot, then by pubkey.
+func slotKey(slot uint64, pubkey string) []byte {
+	key := make([]byte, 8+len(pubkey))
+	binary.BigEndian.PutUint64(key, slot)
+	copy(key[8:], pubkey)
+	return key
+}
+
+func parseSlotKey(key []byte) (uint64, string) {
+	return binary.BigEndian.Uint64(key[:8]), string(key[8:])
+}
+
+func (s *Store) putSigned(bucket []byte, slot uint64, pubkey string, epoch uint64, valIdx int, msg proto.Message) error {
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	value, err := json.Marshal(signedRecord{
+		Epoch:          epoch,
+		ValidatorIndex: valIdx,
+		Data:           data,
+	})
+	if err != nil {
+		return err
+	}
+	// signed data comes from many clients at once, batch the writes.
+	return s.batch(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucket).Put(slotKey(slot, pubkey), value)
+	})
+}
+
+func (s *Store) forEachSigned(bucket []byte, fn func(slot uint64, pubkey string, r signedRecord) error) error {
+	return s.view(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucket).ForEach(func(k, v []byte) error {
+			var r signedRecord
+			if err := json.Unmarshal(v, &r); err != nil {
+				return err
+			}
+			slot, pubkey := parseSlotKey(k)
+			return fn(slot, pubkey, r)
+		})
+	})
+}
+
+// SaveAttestation saves the signed attestation, replacing the one of the
+// validator for the same slot.
+func (s *Store) SaveAttestation(a validatorSet.SignedAttestation) error {
+	return s.putSigned(attestationsBucket, a.Slot, a.Pubkey, a.Epoch, a.ValidatorIndex, a.Attestation)
+}
+
+// SaveBlock saves the signed block, replacing the one of the validator for
+// the same slot. Equivocations are saved beside the first block.
+func (s *Store) SaveBlock(b validatorSet.SignedBlock) error {
+	bucket := blocksBucket
+	if b.Equivocation {
+		bucket = equivocationBucket
+	}
+	return s.putSigned(bucket, b.Slot, b.Pubkey, b.Epoch, b.ValidatorIndex, b.Block)
+}
+
+// Attestations returns the saved attestations ordered by slot.
+func (s *Store) Attestations() ([]validatorSet.SignedAttestation, error) {
+	list := make([]validatorSet.SignedAttestation, 0)
+	err := s.forEachSigned(attestationsBucket, func(slot uint64, pubkey string, r signedRecord) error {
+		att := new(ethpb.Attestation)
+		if err := proto.Unmarshal(r.Data, att); err != nil {
+			return err
+		}
+		list = append(list, validatorSet.SignedAttestation{
+			Slot:           slot,
+			Epoch:          r.Epoch,
+			Pubkey:         pubkey,
+			ValidatorIndex: r.ValidatorIndex,
+			Attestation:    att,
+		})
+		return nil
+	})
+	return list, err
+}
+
+// Blocks returns the saved blocks ordered by slot, then the equivocations.
+func (s *Store) Blocks() ([]validatorSet.SignedBlock, error) {
+	list := make([]validatorSet.SignedBlock, 0)
+	for _, bucket := range [][]byte{blocksBucket, equivocationBucket} {
+		err := s.forEachSigned(bucket, func(slot uint64, pubkey string, r signedRecord) error {
+			block := new(ethpb.GenericSignedBeaconBlock)
+			if err := proto.Unmarshal(r.Data, block); err != nil {
+				return err
+			}
+			list = append(list, validatorSet.SignedBlock{
+				Slot:           slot,
+				Epoch:          r.Epoch,
+				Pubkey:         pubkey,
+				ValidatorIndex: r.ValidatorIndex,
+				Block:          block,
+				Equivocation:   bytes.Equal(bucket, equivocationBucket),
+			})
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return list, nil
+}
+
+// PruneBefore deletes the attestations and blocks of the slots before slot.
+func (s *Store) PruneBefore(slot uint64) error {
+	end := slotKey(slot, "")
+	return s.update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{attestationsBucket, blocksBucket, equivocationBucket} {
+			c := tx.Bucket(name).Cursor()
+			for k, _ := c.First(); k != nil && bytes.Compare(k, end) < 0; k, _ = c.First() {
+				if err := c.Delete(); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// SaveRoleOverrides replaces the saved role overrides with list.
+func (s *Store) SaveRoleOverrides(list []validatorSet.RoleOverride) error {
+	return s.update(func(tx *bolt.Tx) error {
+		if err := tx.DeleteBucket(rolesBucket); err != nil {
+			return err
+		}
+		bucket, err := tx.CreateBucket(rolesBucket)
+		if err != nil {
+			return err
+		}
+		for _, o := range list {
+			value, err := json.Marshal(o)
+			if err != nil {
+				return err
+			}
+			if err := bucket.Put([]byte(strconv.Itoa(o.ValidatorIndex)), value); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// RoleOverrides returns the saved role overrides.
+func (s *Store) RoleOverrides() ([]validatorSet.RoleOverride, error) {
+	list := make([]validatorSet.RoleOverride, 0)
+	err := s.view(func(tx *bolt.Tx) error {
+		return tx.Bucket(rolesBucket).ForEach(func(k, v []byte) error {
+			var o validatorSet.RoleOverride
+			if err := json.Unmarshal(v, &o); err != nil {
+				return err
+			}
+			list = append(list, o)
+			return nil
+		})
+	})
+	return list, err
+}
+
+// SaveDelay saves the delay, replacing the one with the same id.
+func (s *Store) SaveDelay(d scheduler.Delay) error {
+	value, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+	return s.update(func(tx *bolt.Tx) error {
+		return tx.Bucket(delaysBucket).Put([]byte(d.ID), value)
+	})
+}
+
+// DeleteDelay deletes the delay with id.
+func (s *Store) DeleteDelay(id string) error {
+	return s.update(func(tx *bolt.Tx) error {
+		return tx.Bucket(delaysBucket).Delete([]byte(id))
+	})
+}
+
+// Delays returns the saved delays.
+func (s *Store) Delays() ([]scheduler.Delay, error) {
+	list := make([]scheduler.Delay, 0)
+	err := s.view(func(tx *bolt.Tx) error {
+		return tx.Bucket(delaysBucket).ForEach(func(k, v []byte) error {
+			var d scheduler.Delay
+			if err := json.Unmarshal(v, &d); err != nil {
+				return err
+			}
+			list = append(list, d)
+			return nil
+		})
+	})
+	return list, err
+}
+
+// withheldRecord is a withheld attestation on disk, the slot and the pubkey
+// are in the key.
+type withheldRecord struct {
+	Withheld time.Time `json:"withheld"`
+	Data     []byte    `json:"data"` // protobuf encoding
+}
+
+// SaveWithheld saves the withheld attestation, replacing the one of the
+// validator for the same slot.
+func (s *Store) SaveWithheld(a withheld.Attestation) error {
+	data, err := proto.Marshal(a.Attestation)
+	if err != nil {
+		return err
+	}
+	value, err := json.Marshal(withheldRecord{Withheld: a.Withheld, Data: data})
+	if err != nil {
+		return err
+	}
+	return s.batch(func(tx *bolt.Tx) error {
+		return tx.Bucket(withheldBucket).Put(slotKey(a.Slot, a.Pubkey), value)
+	})
+}
+
+// DeleteWithheld deletes the withheld attestation of the validator for slot.
+func (s *Store) DeleteWithheld(slot uint64, pubkey string) error {
+	return s.batch(func(tx *bolt.Tx) error {
+		return tx.Bucket(withheldBucket).Delete(slotKey(slot, pubkey))
+	})
+}
+
+// WithheldAttestations returns the saved withheld attestations ordered by
+// slot.
+func (s *Store) WithheldAttestations() ([]withheld.Attestation, error) {
+	list := make([]withheld.Attestation, 0)
+	err := s.view(func(tx *bolt.Tx) error {
+		return tx.Bucket(withheldBucket).ForEach(func(k, v []byte) error {
+			var r withheldRecord
+			if err := json.Unmarshal(v, &r); err != nil {
+				return err
+			}
+			att := new(ethpb.Attestation)
+			if err := proto.Unmarshal(r.Data, att); err != nil {
+				return err
+			}
+			slot, pubkey := parseSlotKey(k)
+			list = append(list, withheld.Attestation{
+				Slot:        slot,
+				Pubkey:      pubkey,
+				Withheld:    r.Withheld,
+				Attestation: att,
+			})
+			return nil
+		})
+	})
+	return list, err
+}
@@ -0,0 +1,163 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prysmaticlabs/prysm/v4/consensus-types/primitives"
+	ethpb "github.com/prysmaticlabs/prysm/v4/proto/prysm/v1alpha1"
+	"github.com/tsinghua-cel/attacker-service/scheduler"
+	"github.com/tsinghua-cel/attacker-service/types"
+	"github.com/tsinghua-cel/attacker-service/validatorSet"
+	"github.com/tsinghua-cel/attacker-service/withheld"
+)
+
+func testBlock(slot uint64, graffiti byte) *ethpb.GenericSignedBeaconBlock {
+	g := make([]byte, 32)
+	g[0] = graffiti
+	return &ethpb.GenericSignedBeaconBlock{
+		Block: &ethpb.GenericSignedBeaconBlock_Phase0{Phase0: &ethpb.SignedBeaconBlock{
+			Block: &ethpb.BeaconBlock{
+				Slot:       primitives.Slot(slot),
+				ParentRoot: make([]byte, 32),
+				StateRoot:  make([]byte, 32),
+				Body: &ethpb.BeaconBlockBody{
+					RandaoReveal: make([]byte, 96),
+					Eth1Data: &ethpb.Eth1Data{
+						DepositRoot: make([]byte, 32),
+						BlockHash:   make([]byte, 32),
+					},
+					Graffiti: g,
+				},
+			},
+			Signature: make([]byte, 96),
+		}},
+	}
+}
+
+func TestReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "attacker.db")
+	st, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vs := validatorSet.NewValidatorSet()
+	vs.SetSlotsPerEpoch(4)
+	vs.AddValidator(1, "0x01")
+	vs.SetStore(st)
+	for slot := uint64(3); slot <= 9; slot += 3 {
+		vs.AddSignedAttestation(slot, "0x01", &ethpb.Attestation{
+			Data: &ethpb.AttestationData{
+				Slot:   primitives.Slot(slot),
+				Target: &ethpb.Checkpoint{Epoch: primitives.Epoch(slot / 4), Root: []byte{0xaa}},
+			},
+		})
+	}
+	vs.AddSignedBlock(9, "0x01", testBlock(9, 1))
+	vs.AddSignedBlock(9, "0x01", testBlock(9, 2))
+	vs.PruneBefore(4)
+
+	roles, err := validatorSet.NewRoleOverridesFromStore(st)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := roles.Set(validatorSet.RoleOverride{ValidatorIndex: 7, Role: types.AttackerRole, StartSlot: 10, EndSlot: -1}); err != nil {
+		t.Fatal(err)
+	}
+
+	delays := scheduler.New()
+	delays.SetStore(st)
+	d := delays.Schedule("block_delayForReceiveBlock", 9, -1, time.Now().Add(time.Minute), "test")
+
+	pool := withheld.New()
+	pool.SetStore(st)
+	for slot := uint64(8); slot <= 9; slot++ {
+		pool.Add(withheld.Attestation{Slot: slot, Pubkey: "0x01", Withheld: time.Now(), Attestation: &ethpb.Attestation{
+			Data: &ethpb.AttestationData{Slot: primitives.Slot(slot)},
+		}})
+	}
+	if taken := pool.Take(func(a withheld.Attestation) bool { return a.Slot == 8 }); len(taken) != 1 {
+		t.Fatalf("taken: got %d", len(taken))
+	}
+	if err := st.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	st, err = Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+	attests, err := st.Attestations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(attests) != 2 || attests[0].Slot != 6 || attests[1].Epoch != 2 || attests[1].ValidatorIndex != 1 {
+		t.Fatalf("attestations: got %+v", attests)
+	}
+	blocks, err := st.Blocks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored := validatorSet.NewValidatorSet()
+	restored.Restore(attests, blocks)
+	if list := restored.AttestationsByTarget(2, []byte{0xaa}); len(list) != 1 || list[0].Slot != 9 {
+		t.Fatalf("restored target 2: got %+v", list)
+	}
+	if list := restored.BlocksInEpoch(2); len(list) != 1 {
+		t.Fatalf("restored blocks: got %+v", list)
+	}
+	if list := restored.EquivocationsInEpoch(2); len(list) != 1 || list[0].First.Root == list[0].Second.Root {
+		t.Fatalf("restored equivocations: got %+v", list)
+	}
+
+	roles, err = validatorSet.NewRoleOverridesFromStore(st)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if role, ok := roles.Get(7, 11); !ok || role != types.AttackerRole {
+		t.Fatalf("restored role: got %v, %v", role, ok)
+	}
+
+	saved, err := st.Delays()
+	if err != nil {
+		t.Fatal(err)
+	}
+	delays = scheduler.New()
+	delays.Restore(saved)
+	if got, ok := delays.Find("block_delayForReceiveBlock", 9, -1); !ok || got.ID != d.ID || !got.Release.Equal(d.Release) {
+		t.Fatalf("restored delay: got %+v, want %+v", got, d)
+	}
+	if next := delays.Schedule("attest_beforeBroadCast", 9, -1, time.Now(), "test"); next.ID == d.ID {
+		t.Fatalf("restored scheduler reuses id %s", next.ID)
+	}
+
+	withheldAtts, err := st.WithheldAttestations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(withheldAtts) != 1 || withheldAtts[0].Slot != 9 || withheldAtts[0].Pubkey != "0x01" || withheldAtts[0].Attestation.Data.Slot != 9 {
+		t.Fatalf("withheld attestations: got %+v", withheldAtts)
+	}
+}
+
+func TestClosed(t *testing.T) {
+	st, err := Open(filepath.Join(t.TempDir(), "attacker.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := st.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.Close(); err != ErrClosed {
+		t.Fatalf("second close: got %v", err)
+	}
+	if _, err := st.Attestations(); err != ErrClosed {
+		t.Fatalf("attestations: got %v", err)
+	}
+	if err := st.DeleteWithheld(1, "0x01"); err != ErrClosed {
+		t.Fatalf("delete withheld: got %v", err)
+	}
+}
@@ -0,0 +1,263 @@
+package strategy
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// CheckFile checks the strategy file strictly, see Check.
+func CheckFile(file string) ([]Issue, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return Check(data), nil
+}
+
+// Check validates a strategy strictly: unknown fields and values with a wrong
+// type are errors, then the values are checked like Validate does. Every issue
+// carries the json path and the line of the value.
+func Check(data []byte) []Issue {
+	c := &checker{
+		data:  data,
+		dec:   json.NewDecoder(bytes.NewReader(data)),
+		lines: make(map[string]int),
+	}
+	c.dec.UseNumber()
+	if err := c.walk(reflect.TypeOf(Strategy{}), ""); err != nil {
+		line := c.line()
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			line = bytes.Count(data[:syntaxErr.Offset], []byte("\n")) + 1
+		}
+		c.issues = append(c.issues, Issue{Line: line, Severity: SeverityError, Message: syntaxMessage(err)})
+		return c.issues
+	}
+	if _, err := c.dec.Token(); err != io.EOF {
+		c.issues = append(c.issues, Issue{Line: c.line(), Severity: SeverityError, Message: "unexpected data after the strategy"})
+	}
+	s, err := decodeStrategy(data)
+	if err != nil {
+		// the type errors are already reported.
+		if !hasError(c.issues) {
+			c.issues = append(c.issues, Issue{Severity: SeverityError, Message: err.Error()})
+		}
+		return c.sorted()
+	}
+	for _, issue := range s.issues() {
+		issue.Line = c.lineOf(issue.Path)
+		c.issues = append(c.issues, issue)
+	}
+	return c.sorted()
+}
+
+type checker struct {
+	data   []byte
+	dec    *json.Decoder
+	lines  map[string]int // json path -> line
+	issues []Issue
+}
+
+func (c *checker) line() int {
+	offset := c.dec.InputOffset()
+	if offset > int64(len(c.data)) {
+		offset = int64(len(c.data))
+	}
+	return bytes.Count(c.data[:offset], []byte("\n")) + 1
+}
+
+// lineOf returns the line of path, or of its closest known parent.
+func (c *checker) lineOf(path string) int {
+	for path != "" {
+		if l, exist := c.lines[path]; exist {
+			return l
+		}
+		i := strings.LastIndexAny(path, ".[")
+		if i < 0 {
+			break
+		}
+		path = path[:i]
+	}
+	return 0
+}
+
+func (c *checker) sorted() []Issue {
+	sort.SliceStable(c.issues, func(i, j int) bool {
+		return c.issues[i].Line < c.issues[j].Line
+	})
+	return c.issues
+}
+
+func (c *checker) addError(path string, format string, args ...interface{}) {
+	issue := newError(path, format, args...)
+	issue.Line = c.line()
+	c.issues = append(c.issues, issue)
+}
+
+// walk reads the next value from the decoder and checks it against t.
+func (c *checker) walk(t reflect.Type, path string) error {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	tok, err := c.dec.Token()
+	if err != nil {
+		return err
+	}
+	if path != "" {
+		if _, exist := c.lines[path]; !exist {
+			c.lines[path] = c.line()
+		}
+	}
+	if tok == nil {
+		return nil
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if tok != json.Delim('{') {
+			c.addError(path, "expect an object")
+			return c.skipRest(tok)
+		}
+		fields := jsonFields(t)
+		for c.dec.More() {
+			keyTok, err := c.dec.Token()
+			if err != nil {
+				return err
+			}
+			key := keyTok.(string)
+			fieldPath := joinPath(path, key)
+			field, exist := fields[key]
+			if !exist {
+				c.lines[fieldPath] = c.line()
+				issue := newError(fieldPath, "unknown field %q", key)
+				issue.Line = c.line()
+				issue.unknownField = true
+				c.issues = append(c.issues, issue)
+				if err := c.skip(); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := c.walk(field.Type, fieldPath); err != nil {
+				return err
+			}
+		}
+		_, err = c.dec.Token() // '}'
+		return err
+	case reflect.Slice:
+		if tok != json.Delim('[') {
+			c.addError(path, "expect an array")
+			return c.skipRest(tok)
+		}
+		for i := 0; c.dec.More(); i++ {
+			if err := c.walk(t.Elem(), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+		_, err = c.dec.Token() // ']'
+		return err
+	case reflect.Bool:
+		if _, ok := tok.(bool); !ok {
+			c.addError(path, "expect a boolean, got %v", tok)
+			return c.skipRest(tok)
+		}
+	case reflect.String:
+		if _, ok := tok.(string); !ok {
+			c.addError(path, "expect a string, got %v", tok)
+			return c.skipRest(tok)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, ok := tok.(json.Number)
+		if !ok {
+			c.addError(path, "expect an integer, got %v", tok)
+			return c.skipRest(tok)
+		}
+		if _, err := n.Int64(); err != nil {
+			c.addError(path, "expect an integer, got %s", n)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, ok := tok.(json.Number)
+		if !ok {
+			c.addError(path, "expect an integer, got %v", tok)
+			return c.skipRest(tok)
+		}
+		if _, err := strconv.ParseUint(n.String(), 10, t.Bits()); err != nil {
+			if strings.HasPrefix(n.String(), "-") {
+				c.addError(path, "expect a non-negative integer, got %s", n)
+			} else {
+				c.addError(path, "expect an integer, got %s", n)
+			}
+		}
+	}
+	return nil
+}
+
+// skip skips the next value.
+func (c *checker) skip() error {
+	tok, err := c.dec.Token()
+	if err != nil {
+		return err
+	}
+	return c.skipRest(tok)
+}
+
+// skipRest skips the rest of a value whose first token is tok.
+func (c *checker) skipRest(tok json.Token) error {
+	if tok != json.Delim('{') && tok != json.Delim('[') {
+		return nil
+	}
+	for depth := 1; depth > 0; {
+		tok, err := c.dec.Token()
+		if err != nil {
+			return err
+		}
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+	}
+	return nil
+}
+
+func jsonFields(t reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue // unexported
+		}
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = f
+	}
+	return fields
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func syntaxMessage(err error) string {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return "invalid json: unexpected end of file"
+	}
+	return "invalid json: " + err.Error()
+}
@@ -0,0 +1,90 @@
+package strategy
+
+import "testing"
+
+const checkStrategy = `{
+  "validator": [
+    {"validator_index": 1, "attacker_start_slot": 1, "attacker_end_slot": 1000},
+    {"validator_index": 1, "attacker_start_slot": 20, "attacker_end_slot": 10}
+  ],
+  "block": {
+    "enable": false,
+    "broad_cast_delay": -1
+  },
+  "timeline": [
+    {"name": "attack", "epochs": {"start": 1, "end": 2}, "attest": {"withold": true}}
+  ]
+}`
+
+func TestCheck(t *testing.T) {
+	expects := []Issue{
+		{Path: "validator[1].attacker_start_slot", Line: 4, Severity: SeverityError},
+		{Path: "validator[1].validator_index", Line: 4, Severity: SeverityWarning},
+		{Path: "block.enable", Line: 7, Severity: SeverityError},
+		{Path: "block.broad_cast_delay", Line: 8, Severity: SeverityError},
+		{Path: "timeline[0].attest.withold", Line: 11, Severity: SeverityError},
+	}
+	issues := Check([]byte(checkStrategy))
+	if len(issues) != len(expects) {
+		t.Fatalf("got %d issues %v, want %d", len(issues), issues, len(expects))
+	}
+	for _, expect := range expects {
+		found := false
+		for _, issue := range issues {
+			if issue.Path == expect.Path && issue.Line == expect.Line && issue.Severity == expect.Severity {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("issue %s at line %d not found in %v", expect.Path, expect.Line, issues)
+		}
+	}
+
+	if issues := Check([]byte(`{"validator": [`)); !hasError(issues) {
+		t.Fatal("broken json should be an error")
+	}
+}
+
+func TestCheckUnsigned(t *testing.T) {
+	issues := Check([]byte(`{
+  "block": {
+    "inclusion": {"exclude_committees": [1, -2], "min_delay": 1.5}
+  },
+  "attest": {
+    "release": [{"trigger": "block", "slot": -1}]
+  }
+}`))
+	expects := map[string]int{
+		"block.inclusion.exclude_committees[1]": 3,
+		"block.inclusion.min_delay":             3,
+		"attest.release[0].slot":                6,
+	}
+	if len(issues) != len(expects) {
+		t.Fatalf("got %d issues %v, want %d", len(issues), issues, len(expects))
+	}
+	for _, issue := range issues {
+		if line, exist := expects[issue.Path]; !exist || issue.Line != line || issue.Severity != SeverityError {
+			t.Errorf("unexpected issue %v", issue)
+		}
+	}
+}
+
+func TestCheckParentNode(t *testing.T) {
+	issues := Check([]byte(`{
+  "block": {
+    "parent": {"ancestor": "last_attacker"}
+  }
+}`))
+	if len(issues) != 1 || issues[0].Path != "block.parent.node" || issues[0].Line != 3 || issues[0].Severity != SeverityError {
+		t.Fatalf("parent rule without node: got %v", issues)
+	}
+	if issues := Check([]byte(`{"block": {"parent": {"ancestor": "last_attacker", "node": "0"}}}`)); len(issues) != 0 {
+		t.Fatalf("parent rule with node: got %v", issues)
+	}
+	for _, ancestor := range []string{ParentSlotsBack, ParentBeforeHonest} {
+		issues := Check([]byte(`{"block": {"parent": {"ancestor": "` + ancestor + `", "node": "0"}}}`))
+		if len(issues) != 1 || issues[0].Path != "block.parent.ancestor" || issues[0].Severity != SeverityError {
+			t.Fatalf("parent rule %s: got %v", ancestor, issues)
+		}
+	}
+}
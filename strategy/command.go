@@ -0,0 +1,72 @@
+package strategy
+
+import "github.com/tsinghua-cel/attacker-service/types"
+
+// CommandRule makes a hook return Cmd, it overrides the command decided by
+// the attack.
+type CommandRule struct {
+	Hook  string `json:"hook"` // rpc method, like "block_beforeSign"
+	Slots *Range `json:"slots,omitempty"`
+	// Validators lists the validators the rule applies to, nil means all
+	// attackers, or every call for hooks without validator.
+	Validators []int  `json:"validators"`
+	Cmd        string `json:"cmd"` // like "CMD_EXIT"
+}
+
+func (r *CommandRule) matches(hook string, slot int64, valIdx int, role types.RoleType) bool {
+	if r.Hook != hook {
+		return false
+	}
+	if r.Slots != nil && !r.Slots.contains(slot) {
+		return false
+	}
+	if r.Validators == nil {
+		return valIdx < 0 || role == types.AttackerRole
+	}
+	for _, idx := range r.Validators {
+		if idx == valIdx {
+			return true
+		}
+	}
+	return false
+}
+
+// CommandAt returns the command of the first rule matching the call, valIdx
+// is -1 for hooks without validator.
+func (s *Strategy) CommandAt(hook string, slot int64, valIdx int) (types.AttackerCommand, bool) {
+	role := types.NormalRole
+	if valIdx >= 0 {
+		role = s.GetValidatorRole(valIdx, slot)
+	}
+	for i := range s.Commands {
+		if s.Commands[i].matches(hook, slot, valIdx, role) {
+			cmd, err := types.ParseCommand(s.Commands[i].Cmd)
+			if err != nil {
+				return types.CMD_NULL, false
+			}
+			return cmd, true
+		}
+	}
+	return types.CMD_NULL, false
+}
+
+func (r CommandRule) issues(path string) []Issue {
+	issues := make([]Issue, 0)
+	withValidator, known := types.Hooks[r.Hook]
+	if !known {
+		issues = append(issues, newError(path+".hook", "unknown hook %q", r.Hook))
+	}
+	if r.Slots != nil {
+		issues = append(issues, r.Slots.issues(path+".slots")...)
+	}
+	cmd, err := types.ParseCommand(r.Cmd)
+	if err != nil {
+		issues = append(issues, newError(path+".cmd", "%v", err))
+	} else if known && !withValidator && cmd.NeedValidator() {
+		issues = append(issues, newError(path+".cmd", "%s needs a validator, hook %s has none", r.Cmd, r.Hook))
+	}
+	if known && !withValidator && r.Validators != nil {
+		issues = append(issues, newWarning(path+".validators", "hook %s has no validator, the rule never applies", r.Hook))
+	}
+	return issues
+}
@@ -0,0 +1,50 @@
+package strategy
+
+import (
+	"testing"
+
+	"github.com/tsinghua-cel/attacker-service/types"
+)
+
+const commandStrategy = `{
+  "validator": [
+    {"validator_index": 1, "attacker_start_slot": 0, "attacker_end_slot": 1000}
+  ],
+  "commands": [
+    {"hook": "block_beforeSign", "slots": {"start": 10, "end": 20}, "cmd": "CMD_SKIP"},
+    {"hook": "attest_beforeSign", "validators": [5], "cmd": "CMD_ROLE_TO_ATTACKER"},
+    {"hook": "block_beforeBroadCast", "cmd": "CMD_UPDATE_STATE"}
+  ]
+}`
+
+func TestCommandAt(t *testing.T) {
+	s, err := decodeStrategy([]byte(commandStrategy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if cmd, ok := s.CommandAt("block_beforeSign", 15, 1); !ok || cmd != types.CMD_SKIP {
+		t.Fatalf("attacker 1 block_beforeSign at 15: got %v %v, want CMD_SKIP", cmd, ok)
+	}
+	if _, ok := s.CommandAt("block_beforeSign", 15, 2); ok {
+		t.Fatalf("honest validator 2 should not match")
+	}
+	if cmd, ok := s.CommandAt("attest_beforeSign", 15, 5); !ok || cmd != types.CMD_ROLE_TO_ATTACKER {
+		t.Fatalf("validator 5 attest_beforeSign: got %v %v, want CMD_ROLE_TO_ATTACKER", cmd, ok)
+	}
+	if cmd, ok := s.CommandAt("block_beforeBroadCast", 3, -1); !ok || cmd != types.CMD_UPDATE_STATE {
+		t.Fatalf("block_beforeBroadCast: got %v %v, want CMD_UPDATE_STATE", cmd, ok)
+	}
+
+	bad := s.Copy()
+	bad.Commands = []CommandRule{{Hook: "block_beforeBroadCast", Cmd: "CMD_EXIT"}}
+	if err := bad.Validate(); err == nil {
+		t.Fatalf("CMD_EXIT on a hook without validator should be invalid")
+	}
+	bad.Commands = []CommandRule{{Hook: "block_unknown", Cmd: "CMD_NULL"}}
+	if err := bad.Validate(); err == nil {
+		t.Fatalf("unknown hook should be invalid")
+	}
+}
@@ -0,0 +1,185 @@
+package strategy
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// Copy returns a deep copy of the strategy that can be modified, or decoded
+// into, without affecting s.
+func (s *Strategy) Copy() *Strategy {
+	n := &Strategy{
+		Block:  s.Block.copy(),
+		Attest: s.Attest.copy(),
+		Attack: s.Attack,
+	}
+	n.Validators = append(n.Validators, s.Validators...)
+	for _, p := range s.Timeline {
+		n.Timeline = append(n.Timeline, p.copy())
+	}
+	for _, c := range s.Commands {
+		c.Slots = copyRange(c.Slots)
+		c.Validators = copyInts(c.Validators)
+		n.Commands = append(n.Commands, c)
+	}
+	n.SetSlotsPerEpoch(int(s.getSlotsPerEpoch()))
+	return n
+}
+
+func (b BlockStrategy) copy() BlockStrategy {
+	if e := b.Equivocate; e != nil {
+		b.Equivocate = &EquivocateStrategy{
+			Graffiti:   e.Graffiti,
+			Node:       e.Node,
+			Targets:    copyStrings(e.Targets),
+			AltTargets: copyStrings(e.AltTargets),
+		}
+	}
+	if p := b.Private; p != nil {
+		private := *p
+		b.Private = &private
+	}
+	if p := b.Parent; p != nil {
+		parent := *p
+		b.Parent = &parent
+	}
+	if inc := b.Inclusion; inc != nil {
+		b.Inclusion = &InclusionStrategy{
+			ExcludeValidators: copyInts(inc.ExcludeValidators),
+			ExcludeCommittees: append([]uint64(nil), inc.ExcludeCommittees...),
+			ExcludeHeads:      copyStrings(inc.ExcludeHeads),
+			MinDelay:          inc.MinDelay,
+		}
+	}
+	return b
+}
+
+func (a AttestStrategy) copy() AttestStrategy {
+	votes := a.Votes
+	a.Votes = nil
+	for _, v := range votes {
+		v.Validators = copyInts(v.Validators)
+		v.Slots = copyRange(v.Slots)
+		v.Source = v.Source.copy()
+		v.Target = v.Target.copy()
+		a.Votes = append(a.Votes, v)
+	}
+	a.Release = append([]ReleaseRule(nil), a.Release...)
+	return a
+}
+
+func (p Phase) copy() Phase {
+	p.Epochs = copyRange(p.Epochs)
+	p.Slots = copyRange(p.Slots)
+	p.Attackers = copyInts(p.Attackers)
+	if p.Block != nil {
+		b := p.Block.copy()
+		p.Block = &b
+	}
+	if p.Attest != nil {
+		a := p.Attest.copy()
+		p.Attest = &a
+	}
+	return p
+}
+
+func (c *Checkpoint) copy() *Checkpoint {
+	if c == nil {
+		return nil
+	}
+	n := *c
+	if c.Epoch != nil {
+		epoch := *c.Epoch
+		n.Epoch = &epoch
+	}
+	return &n
+}
+
+func copyRange(r *Range) *Range {
+	if r == nil {
+		return nil
+	}
+	n := *r
+	return &n
+}
+
+// copyInts keeps a nil list nil, nil and empty lists differ in the strategy.
+func copyInts(list []int) []int {
+	if list == nil {
+		return nil
+	}
+	return append([]int{}, list...)
+}
+
+func copyStrings(list []string) []string {
+	if list == nil {
+		return nil
+	}
+	return append([]string{}, list...)
+}
+
+// Diff returns the changed fields from old to new, one line per field, like
+// "block.broad_cast_delay: 3000 -> 4000".
+func Diff(old, new *Strategy) []string {
+	before, after := flatten(old), flatten(new)
+	keys := make(map[string]struct{})
+	for k := range before {
+		keys[k] = struct{}{}
+	}
+	for k := range after {
+		keys[k] = struct{}{}
+	}
+	changes := make([]string, 0)
+	for k := range keys {
+		b, inBefore := before[k]
+		a, inAfter := after[k]
+		switch {
+		case !inBefore:
+			changes = append(changes, fmt.Sprintf("%s: added %s", k, a))
+		case !inAfter:
+			changes = append(changes, fmt.Sprintf("%s: removed %s", k, b))
+		case a != b:
+			changes = append(changes, fmt.Sprintf("%s: %s -> %s", k, b, a))
+		}
+	}
+	sort.Strings(changes)
+	return changes
+}
+
+func flatten(s *Strategy) map[string]string {
+	res := make(map[string]string)
+	if s == nil {
+		return res
+	}
+	d, err := json.Marshal(s)
+	if err != nil {
+		return res
+	}
+	var v interface{}
+	if err := json.Unmarshal(d, &v); err != nil {
+		return res
+	}
+	flattenValue("", v, res)
+	return res
+}
+
+func flattenValue(path string, v interface{}, res map[string]string) {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, item := range val {
+			p := k
+			if path != "" {
+				p = path + "." + k
+			}
+			flattenValue(p, item, res)
+		}
+	case []interface{}:
+		for i, item := range val {
+			flattenValue(fmt.Sprintf("%s[%d]", path, i), item, res)
+		}
+	default:
+		d, _ := json.Marshal(val)
+		res[path] = string(d)
+	}
+}
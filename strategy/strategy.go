@@ -13,30 +13,120 @@ type ValidatorStrategy struct {
 	AttackerEndSlot   int `json:"attacker_end_slot"`
 }
 
+// which attestations are packed into attacker blocks.
+const (
+	IncludeAttacker = "attacker" // add the attestations withheld by attackers
+	IncludeNone     = "none"     // keep the attestations of the block
+)
+
 type BlockStrategy struct {
-	DelayEnable    bool  `json:"delay_enable"`
-	BroadCastDelay int64 `json:"broad_cast_delay"` // unit millisecond
-	ModifyEnable   bool  `json:"modify_enable"`
+	DelayEnable    bool   `json:"delay_enable"`
+	BroadCastDelay int64  `json:"broad_cast_delay"` // unit millisecond
+	ModifyEnable   bool   `json:"modify_enable"`
+	Withhold       bool   `json:"withhold"` // attackers don't propose block
+	AttestInclude  string `json:"attest_include"`
+	// attackers sign and broadcast a second, conflicting block, nil disables it.
+	Equivocate *EquivocateStrategy `json:"equivocate,omitempty"`
+	// attackers withhold their blocks on a private chain, nil disables it.
+	Private *PrivateChainStrategy `json:"private,omitempty"`
+	// attackers build their blocks on another ancestor, nil keeps the head.
+	Parent *ParentStrategy `json:"parent,omitempty"`
+	// attestations censored in attacker blocks, nil includes all.
+	Inclusion *InclusionStrategy `json:"inclusion,omitempty"`
+}
+
+// InclusionStrategy removes attestations from attacker blocks. An aggregate
+// with a vote of an excluded validator is removed as a whole.
+type InclusionStrategy struct {
+	ExcludeValidators []int    `json:"exclude_validators"` // attestations with a vote of these validators
+	ExcludeCommittees []uint64 `json:"exclude_committees"` // attestations of these committee indices
+	ExcludeHeads      []string `json:"exclude_heads"`      // votes for these head roots, hex
+	MinDelay          uint64   `json:"min_delay"`          // keep attestations at least min_delay slots old, 2 keeps the late ones only
+}
+
+// which ancestor attacker blocks build on.
+const (
+	ParentLastAttacker = "last_attacker" // the last attacker block
+	// not supported: the ancestor is never the head of a node, the beacon
+	// API can't produce a block with a new payload on it.
+	ParentSlotsBack    = "slots_back"
+	ParentBeforeHonest = "before_honest"
+)
+
+// ParentStrategy rebuilds attacker blocks on an ancestor of the head. The
+// beacon API only produces blocks on the head of a node, so Node must be a
+// beacon node the experiment keeps on the attacker branch: the block is
+// produced there when its head is the ancestor.
+type ParentStrategy struct {
+	Ancestor string `json:"ancestor"` // last_attacker
+	Node     string `json:"node"`     // beacon node producing the block, endpoint or index of beacon_rpc
+}
+
+// when the private chain of attacker blocks is broadcast.
+const (
+	ReleaseCatchUp = "catch_up" // the public chain is at most lead blocks behind
+	ReleaseAtSlot  = "slot"     // at interval of slot
+	ReleaseNever   = "never"    // the blocks are never broadcast
+)
+
+// PrivateChainStrategy makes attacker proposers withhold their blocks, each
+// one building on the previous withheld block, and release them together.
+type PrivateChainStrategy struct {
+	Release  string `json:"release"`  // catch_up, slot or never
+	Lead     int    `json:"lead"`     // catch_up: private blocks still ahead when the chain is released
+	Slot     int64  `json:"slot"`     // slot: slot of the release
+	Interval int    `json:"interval"` // slot: 0 at the slot start, 1 at 1/3 and 2 at 2/3 of the slot
+}
+
+// EquivocateStrategy makes attacker proposers sign two blocks for their slot.
+// The second block is produced by a beacon node with another graffiti, a
+// node seeing another head gives it another parent and other attestations.
+type EquivocateStrategy struct {
+	Graffiti   string   `json:"graffiti"`    // graffiti of the second block, "equivocation" if empty
+	Node       string   `json:"node"`        // beacon node producing the second block, endpoint or index of beacon_rpc, the preferred node if empty
+	Targets    []string `json:"targets"`     // peers or beacon nodes the first block is sent to, the usual ones if empty
+	AltTargets []string `json:"alt_targets"` // peers or beacon nodes the second block is sent to, the usual ones if empty
 }
 
 type AttestStrategy struct {
-	DelayEnable    bool  `json:"delay_enable"`
-	BroadCastDelay int64 `json:"broad_cast_delay"` // unit millisecond
-	ModifyEnable   bool  `json:"modify_enable"`
+	DelayEnable    bool       `json:"delay_enable"`
+	BroadCastDelay int64      `json:"broad_cast_delay"` // unit millisecond
+	ModifyEnable   bool       `json:"modify_enable"`
+	Withhold       bool       `json:"withhold"` // attackers don't broadcast attestation
+	Votes          []VoteRule `json:"votes"`    // rewrite the attestation data before sign
+	// the service broadcasts the withheld attestations when a rule triggers.
+	Release []ReleaseRule `json:"release"`
 	//lua scripts  => modify attest
 }
 
+// triggers of ReleaseRule.
+const (
+	TriggerTime  = "time"  // ms into the slot
+	TriggerBlock = "block" // the honest block of the slot arrives
+	TriggerHead  = "head"  // the head changes after the attestation was withheld
+)
+
+// ReleaseRule broadcasts the attestations withheld by attackers. The slot of
+// the rule is relative to the slot of the attestation.
+type ReleaseRule struct {
+	Trigger string `json:"trigger"` // time, block or head
+	Slot    uint64 `json:"slot"`    // time and block: slots after the attestation slot
+	Ms      int64  `json:"ms"`      // time: milliseconds into the slot
+}
+
 var (
 	defaultValidators    = []ValidatorStrategy{}
 	defaultBlockStrategy = BlockStrategy{
 		DelayEnable:    false,
 		BroadCastDelay: 3000, // 3s
 		ModifyEnable:   false,
+		AttestInclude:  IncludeAttacker,
 	}
 	defaultAttestStrategy = AttestStrategy{
 		DelayEnable:    false,
 		BroadCastDelay: 3000, // 3s
 		ModifyEnable:   false,
+		Withhold:       true,
 	}
 )
 
@@ -44,9 +134,20 @@ type Strategy struct {
 	Validators []ValidatorStrategy `json:"validator"`
 	Block      BlockStrategy       `json:"block"`
 	Attest     AttestStrategy      `json:"attest"`
+	Timeline   []Phase             `json:"timeline"`
+	Attack     string              `json:"attack"` // name of the attack, empty for the default one
+	Commands   []CommandRule       `json:"commands"`
+
+	slotsPerEpoch int64
 }
 
 func (s *Strategy) GetValidatorRole(valIdx int, slot int64) types.RoleType {
+	if p := s.PhaseAt(slot); p != nil && p.Attackers != nil {
+		if p.isAttacker(valIdx) {
+			return types.AttackerRole
+		}
+		return types.NormalRole
+	}
 	for _, v := range s.Validators {
 		if v.ValidatorIndex == valIdx {
 			if slot >= int64(v.AttackerStartSlot) && slot <= int64(v.AttackerEndSlot) {
@@ -62,15 +163,79 @@ func ParseStrategy(file string) *Strategy {
 		Block:  defaultBlockStrategy,
 		Attest: defaultAttestStrategy,
 	}
-	var s Strategy
 	d, err := os.ReadFile(file)
 	if err != nil {
 		log.WithError(err).Error("read strategy failed, use default config")
 		return defautConfig
 	}
-	if err = json.Unmarshal(d, &s); err != nil {
+	s, err := decodeStrategy(d)
+	if err != nil {
 		log.WithError(err).Error("unmarshal strategy failed, use default config")
 		return defautConfig
 	}
-	return &s
+	return s
+}
+
+// LoadStrategy reads the strategy file and checks it strictly. In lenient
+// mode unknown fields are ignored, other problems are still rejected.
+func LoadStrategy(file string, lenient bool) (*Strategy, error) {
+	d, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	issues := make([]Issue, 0)
+	for _, issue := range Check(d) {
+		if lenient && issue.unknownField {
+			continue
+		}
+		if issue.Severity == SeverityWarning {
+			log.WithField("file", file).Warn("strategy ", issue)
+		}
+		issues = append(issues, issue)
+	}
+	if hasError(issues) {
+		return nil, issuesError(issues)
+	}
+	return decodeStrategy(d)
+}
+
+func decodeStrategy(data []byte) (*Strategy, error) {
+	var s = Strategy{
+		Block:  defaultBlockStrategy,
+		Attest: defaultAttestStrategy,
+	}
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	// the block and attest sections of a phase are kept as they are written,
+	// they are decoded over the global sections when they are looked up.
+	var raw struct {
+		Timeline []struct {
+			Block  json.RawMessage `json:"block"`
+			Attest json.RawMessage `json:"attest"`
+		} `json:"timeline"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	for i, p := range raw.Timeline {
+		phase := &s.Timeline[i]
+		if phase.Block != nil {
+			phase.block = p.Block
+			block, err := phase.blockOver(s.Block)
+			if err != nil {
+				return nil, err
+			}
+			phase.Block = &block
+		}
+		if phase.Attest != nil {
+			phase.attest = p.Attest
+			attest, err := phase.attestOver(s.Attest)
+			if err != nil {
+				return nil, err
+			}
+			phase.Attest = &attest
+		}
+	}
+	return &s, nil
 }
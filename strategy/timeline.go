@@ -0,0 +1,151 @@
+package strategy
+
+import (
+	"encoding/json"
+	"sync/atomic"
+)
+
+const defaultSlotsPerEpoch = 32
+
+// Range is an inclusive range of slots or epochs.
+type Range struct {
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+}
+
+func (r *Range) contains(n int64) bool {
+	return n >= r.Start && n <= r.End
+}
+
+// Phase is one step of the attack timeline, it is bound to an epoch range or
+// a slot range and its settings replace the global ones while it is active.
+// Its block and attest sections are decoded over the global sections when
+// they are looked up, so a later update of a global section also applies to
+// the fields the phase doesn't set.
+type Phase struct {
+	Name   string `json:"name"`
+	Epochs *Range `json:"epochs,omitempty"`
+	Slots  *Range `json:"slots,omitempty"`
+	// Attackers lists the attacker validators of the phase, nil means the
+	// roles come from the validator section, an empty list means no attacker.
+	Attackers []int           `json:"attackers"`
+	Block     *BlockStrategy  `json:"block,omitempty"`
+	Attest    *AttestStrategy `json:"attest,omitempty"`
+	Attack    string          `json:"attack,omitempty"`
+
+	// block and attest are the sections as written in the strategy file,
+	// nil for a phase that isn't decoded from json.
+	block  json.RawMessage
+	attest json.RawMessage
+}
+
+func (p *Phase) contains(slot int64, slotsPerEpoch int64) bool {
+	if p.Slots != nil && p.Slots.contains(slot) {
+		return true
+	}
+	if p.Epochs != nil && p.Epochs.contains(slot/slotsPerEpoch) {
+		return true
+	}
+	return false
+}
+
+// blockOver returns the block section of the phase decoded over global.
+func (p *Phase) blockOver(global BlockStrategy) (BlockStrategy, error) {
+	if p.block == nil {
+		return *p.Block, nil
+	}
+	block := global.copy()
+	if err := json.Unmarshal(p.block, &block); err != nil {
+		return BlockStrategy{}, err
+	}
+	return block, nil
+}
+
+// attestOver returns the attest section of the phase decoded over global.
+func (p *Phase) attestOver(global AttestStrategy) (AttestStrategy, error) {
+	if p.attest == nil {
+		return *p.Attest, nil
+	}
+	attest := global.copy()
+	// the rules of a phase replace the global rules, json would decode them
+	// over the global rules of the same index.
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(p.attest, &fields); err != nil {
+		return AttestStrategy{}, err
+	}
+	if _, exist := fields["votes"]; exist {
+		attest.Votes = nil
+	}
+	if _, exist := fields["release"]; exist {
+		attest.Release = nil
+	}
+	if err := json.Unmarshal(p.attest, &attest); err != nil {
+		return AttestStrategy{}, err
+	}
+	return attest, nil
+}
+
+func (p *Phase) isAttacker(valIdx int) bool {
+	for _, idx := range p.Attackers {
+		if idx == valIdx {
+			return true
+		}
+	}
+	return false
+}
+
+// SetSlotsPerEpoch sets the value used to map epoch ranges of the timeline
+// to slots.
+func (s *Strategy) SetSlotsPerEpoch(n int) {
+	atomic.StoreInt64(&s.slotsPerEpoch, int64(n))
+}
+
+func (s *Strategy) getSlotsPerEpoch() int64 {
+	if n := atomic.LoadInt64(&s.slotsPerEpoch); n > 0 {
+		return n
+	}
+	return defaultSlotsPerEpoch
+}
+
+// PhaseAt returns the first phase of the timeline that contains slot, or nil.
+func (s *Strategy) PhaseAt(slot int64) *Phase {
+	slotsPerEpoch := s.getSlotsPerEpoch()
+	for i := range s.Timeline {
+		if s.Timeline[i].contains(slot, slotsPerEpoch) {
+			return &s.Timeline[i]
+		}
+	}
+	return nil
+}
+
+// BlockStrategyAt returns the block strategy active at slot.
+func (s *Strategy) BlockStrategyAt(slot int64) BlockStrategy {
+	if p := s.PhaseAt(slot); p != nil && p.Block != nil {
+		// the phase was checked over the same global section when the
+		// strategy was set.
+		if block, err := p.blockOver(s.Block); err == nil {
+			return block
+		}
+		return *p.Block
+	}
+	return s.Block
+}
+
+// AttestStrategyAt returns the attest strategy active at slot.
+func (s *Strategy) AttestStrategyAt(slot int64) AttestStrategy {
+	if p := s.PhaseAt(slot); p != nil && p.Attest != nil {
+		if attest, err := p.attestOver(s.Attest); err == nil {
+			return attest
+		}
+		return *p.Attest
+	}
+	return s.Attest
+}
+
+// AttackAt returns the name of the attack active at slot.
+func (s *Strategy) AttackAt(slot int64) string {
+	if p := s.PhaseAt(slot); p != nil && p.Attack != "" {
+		return p.Attack
+	}
+	return s.Attack
+}
@@ -0,0 +1,121 @@
+package strategy
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/tsinghua-cel/attacker-service/types"
+)
+
+const timelineStrategy = `{
+  "validator": [
+    {"validator_index": 1, "attacker_start_slot": 0, "attacker_end_slot": 1000}
+  ],
+  "timeline": [
+    {"name": "warm-up", "epochs": {"start": 0, "end": 1}, "attackers": []},
+    {"name": "attack", "epochs": {"start": 2, "end": 3}, "attackers": [1, 2],
+     "block": {"delay_enable": true, "broad_cast_delay": 1000},
+     "attest": {"withhold": true}},
+    {"name": "recovery", "slots": {"start": 128, "end": 200}, "attest": {"withhold": false}}
+  ]
+}`
+
+func TestTimeline(t *testing.T) {
+	s, err := decodeStrategy([]byte(timelineStrategy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.SetSlotsPerEpoch(32)
+
+	if role := s.GetValidatorRole(1, 10); role != types.NormalRole {
+		t.Fatalf("warm-up role = %s, want normal", role)
+	}
+	if role := s.GetValidatorRole(2, 70); role != types.AttackerRole {
+		t.Fatalf("attack role = %s, want attacker", role)
+	}
+	if bs := s.BlockStrategyAt(70); !bs.DelayEnable || bs.BroadCastDelay != 1000 {
+		t.Fatalf("attack block strategy = %v", bs)
+	}
+	// recovery phase keeps the roles of the validator section.
+	if role := s.GetValidatorRole(1, 130); role != types.AttackerRole {
+		t.Fatalf("recovery role = %s, want attacker", role)
+	}
+	if as := s.AttestStrategyAt(130); as.Withhold {
+		t.Fatal("recovery phase should not withhold attestation")
+	}
+	// out of the timeline use the global strategy.
+	if as := s.AttestStrategyAt(500); !as.Withhold {
+		t.Fatal("global attest strategy should withhold attestation")
+	}
+}
+
+func TestPhaseDefaults(t *testing.T) {
+	s, err := decodeStrategy([]byte(`{
+  "block": {"broad_cast_delay": 500},
+  "attest": {"delay_enable": true, "votes": [{"head": "parent", "slots": {"start": 0, "end": 10}}]},
+  "timeline": [
+    {"name": "delay", "epochs": {"start": 0, "end": 1}, "block": {"delay_enable": true}},
+    {"name": "vote", "epochs": {"start": 2, "end": 3}, "attest": {"broad_cast_delay": 200}},
+    {"name": "own votes", "epochs": {"start": 4, "end": 5}, "attest": {"votes": [{"head": "attacker"}]}}
+  ]
+}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.SetSlotsPerEpoch(32)
+
+	// a phase setting one field keeps the defaults and the global values.
+	bs := s.BlockStrategyAt(10)
+	if !bs.DelayEnable || bs.BroadCastDelay != 500 || bs.AttestInclude != IncludeAttacker {
+		t.Fatalf("delay phase block strategy = %+v", bs)
+	}
+	as := s.AttestStrategyAt(70)
+	if !as.DelayEnable || as.BroadCastDelay != 200 || !as.Withhold {
+		t.Fatalf("vote phase attest strategy = %+v", as)
+	}
+	if votes := as.Votes; len(votes) != 1 || votes[0].Head != VoteHeadParent {
+		t.Fatalf("vote phase rules = %+v, want the global rules", votes)
+	}
+	// the rules of a phase replace the global ones, not merge with them.
+	if votes := s.AttestStrategyAt(130).Votes; len(votes) != 1 || votes[0].Head != VoteHeadAttacker || votes[0].Slots != nil {
+		t.Fatalf("own votes phase rules = %+v", votes)
+	}
+	// the phases don't change the global sections.
+	if s.Block.DelayEnable || s.Attest.BroadCastDelay != 3000 {
+		t.Fatalf("global strategy changed: %+v %+v", s.Block, s.Attest)
+	}
+}
+
+func TestPhaseFollowsGlobalUpdate(t *testing.T) {
+	s, err := decodeStrategy([]byte(`{
+  "timeline": [
+    {"name": "delay", "epochs": {"start": 0, "end": 1}, "block": {"delay_enable": true}, "attest": {"withhold": false}}
+  ]
+}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.SetSlotsPerEpoch(32)
+
+	updated := s.Copy()
+	if err := json.Unmarshal([]byte(`{"broad_cast_delay": 700}`), &updated.Block); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(`{"delay_enable": true, "withhold": true}`), &updated.Attest); err != nil {
+		t.Fatal(err)
+	}
+	if err := updated.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	// the fields the phase doesn't set follow the global update, the ones
+	// it sets keep the phase value.
+	if bs := updated.BlockStrategyAt(10); !bs.DelayEnable || bs.BroadCastDelay != 700 {
+		t.Fatalf("phase block strategy after update = %+v", bs)
+	}
+	if as := updated.AttestStrategyAt(10); !as.DelayEnable || as.Withhold {
+		t.Fatalf("phase attest strategy after update = %+v", as)
+	}
+	if bs := s.BlockStrategyAt(10); bs.BroadCastDelay != 3000 {
+		t.Fatalf("phase of the old strategy changed: %+v", bs)
+	}
+}
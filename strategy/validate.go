@@ -0,0 +1,289 @@
+package strategy
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrInvalidStrategy   = errors.New("invalid strategy")
+	errInvalidRootLength = errors.New("root must be 32 bytes")
+
+	attackNames = make(map[string]bool)
+)
+
+// RegisterAttackName makes name a valid value of the attack fields.
+func RegisterAttackName(name string) {
+	attackNames[name] = true
+}
+
+func attackIssues(path string, name string) []Issue {
+	if name == "" || len(attackNames) == 0 || attackNames[name] {
+		return nil
+	}
+	return []Issue{newError(path, "unknown attack %q", name)}
+}
+
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Issue is a problem found in a strategy, Path is the json path of the value
+// like "validator[1].attacker_start_slot" and Line is the line in the file,
+// 0 if unknown.
+type Issue struct {
+	Path     string
+	Line     int
+	Severity Severity
+	Message  string
+
+	unknownField bool
+}
+
+func (i Issue) String() string {
+	msg := i.Message
+	if i.Path != "" {
+		msg = i.Path + ": " + msg
+	}
+	if i.Line > 0 {
+		return fmt.Sprintf("line %d: %s: %s", i.Line, i.Severity, msg)
+	}
+	return fmt.Sprintf("%s: %s", i.Severity, msg)
+}
+
+func hasError(issues []Issue) bool {
+	for _, i := range issues {
+		if i.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+func issuesError(issues []Issue) error {
+	problems := make([]string, 0, len(issues))
+	for _, i := range issues {
+		if i.Severity == SeverityError {
+			problems = append(problems, i.String())
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrInvalidStrategy, strings.Join(problems, "; "))
+}
+
+// Validate checks the values of the strategy.
+func (s *Strategy) Validate() error {
+	if issues := s.issues(); hasError(issues) {
+		return issuesError(issues)
+	}
+	return nil
+}
+
+// issues returns the problems of the values in the strategy.
+func (s *Strategy) issues() []Issue {
+	issues := make([]Issue, 0)
+	seen := make(map[int]int)
+	for i, v := range s.Validators {
+		path := fmt.Sprintf("validator[%d]", i)
+		if v.AttackerStartSlot > v.AttackerEndSlot {
+			issues = append(issues, newError(path+".attacker_start_slot", "start slot %d is after end slot %d", v.AttackerStartSlot, v.AttackerEndSlot))
+		}
+		if v.AttackerStartSlot < 0 {
+			issues = append(issues, newError(path+".attacker_start_slot", "negative slot %d", v.AttackerStartSlot))
+		}
+		if first, exist := seen[v.ValidatorIndex]; exist {
+			issues = append(issues, newWarning(path+".validator_index", "validator %d is already listed at validator[%d]", v.ValidatorIndex, first))
+		} else {
+			seen[v.ValidatorIndex] = i
+		}
+	}
+	issues = append(issues, s.Block.issues("block")...)
+	issues = append(issues, s.Attest.issues("attest")...)
+	issues = append(issues, attackIssues("attack", s.Attack)...)
+	for i, c := range s.Commands {
+		issues = append(issues, c.issues(fmt.Sprintf("commands[%d]", i))...)
+	}
+	for i, p := range s.Timeline {
+		path := fmt.Sprintf("timeline[%d]", i)
+		if p.Epochs == nil && p.Slots == nil {
+			issues = append(issues, newError(path, "phase has neither epochs nor slots"))
+		}
+		if p.Epochs != nil {
+			issues = append(issues, p.Epochs.issues(path+".epochs")...)
+		}
+		if p.Slots != nil {
+			issues = append(issues, p.Slots.issues(path+".slots")...)
+		}
+		// the phases are checked as they resolve over the current global
+		// sections.
+		if p.Block != nil {
+			if block, err := p.blockOver(s.Block); err != nil {
+				issues = append(issues, newError(path+".block", "%v", err))
+			} else {
+				issues = append(issues, block.issues(path+".block")...)
+			}
+		}
+		if p.Attest != nil {
+			if attest, err := p.attestOver(s.Attest); err != nil {
+				issues = append(issues, newError(path+".attest", "%v", err))
+			} else {
+				issues = append(issues, attest.issues(path+".attest")...)
+			}
+		}
+		issues = append(issues, attackIssues(path+".attack", p.Attack)...)
+	}
+	return issues
+}
+
+func (r *Range) issues(path string) []Issue {
+	issues := make([]Issue, 0)
+	if r.Start > r.End {
+		issues = append(issues, newError(path+".start", "start %d is after end %d", r.Start, r.End))
+	}
+	if r.Start < 0 {
+		issues = append(issues, newError(path+".start", "negative start %d", r.Start))
+	}
+	return issues
+}
+
+func (b BlockStrategy) issues(path string) []Issue {
+	issues := make([]Issue, 0)
+	if b.BroadCastDelay < 0 {
+		issues = append(issues, newError(path+".broad_cast_delay", "negative delay %d", b.BroadCastDelay))
+	}
+	switch b.AttestInclude {
+	case "", IncludeAttacker, IncludeNone:
+	default:
+		issues = append(issues, newError(path+".attest_include", "unknown value %q", b.AttestInclude))
+	}
+	if e := b.Equivocate; e != nil {
+		if len(e.Graffiti) > 32 {
+			issues = append(issues, newError(path+".equivocate.graffiti", "graffiti is longer than 32 bytes"))
+		}
+		if b.Withhold {
+			issues = append(issues, newWarning(path+".equivocate", "attackers withhold their blocks, no block to equivocate"))
+		}
+	}
+	if p := b.Private; p != nil {
+		switch p.Release {
+		case ReleaseCatchUp, ReleaseNever:
+		case ReleaseAtSlot:
+			if p.Slot < 0 {
+				issues = append(issues, newError(path+".private.slot", "negative slot %d", p.Slot))
+			}
+			if p.Interval < 0 || p.Interval > 2 {
+				issues = append(issues, newError(path+".private.interval", "interval %d is not 0, 1 or 2", p.Interval))
+			}
+		default:
+			issues = append(issues, newError(path+".private.release", "unknown value %q", p.Release))
+		}
+		if p.Lead < 0 {
+			issues = append(issues, newError(path+".private.lead", "negative lead %d", p.Lead))
+		}
+		if b.Withhold {
+			issues = append(issues, newWarning(path+".private", "attackers withhold their blocks, the private chain stays empty"))
+		}
+		if b.Parent == nil {
+			issues = append(issues, newWarning(path+".private", "no parent rule, the attacker blocks after the first don't build on the private chain"))
+		}
+	}
+	if p := b.Parent; p != nil {
+		switch p.Ancestor {
+		case ParentLastAttacker:
+		case ParentSlotsBack, ParentBeforeHonest:
+			issues = append(issues, newError(path+".parent.ancestor", "%q is not supported, no beacon node has the ancestor as head", p.Ancestor))
+		default:
+			issues = append(issues, newError(path+".parent.ancestor", "unknown value %q", p.Ancestor))
+		}
+		if p.Node == "" {
+			issues = append(issues, newError(path+".parent.node", "no beacon node to produce the block on the ancestor"))
+		}
+	}
+	if inc := b.Inclusion; inc != nil {
+		for i, v := range inc.ExcludeValidators {
+			if v < 0 {
+				issues = append(issues, newError(fmt.Sprintf("%s.inclusion.exclude_validators[%d]", path, i), "negative validator index %d", v))
+			}
+		}
+		for i, head := range inc.ExcludeHeads {
+			if _, err := ParseRoot(head); err != nil {
+				issues = append(issues, newError(fmt.Sprintf("%s.inclusion.exclude_heads[%d]", path, i), "invalid root %q: %v", head, err))
+			}
+		}
+	}
+	return issues
+}
+
+func (a AttestStrategy) issues(path string) []Issue {
+	issues := make([]Issue, 0)
+	if a.BroadCastDelay < 0 {
+		issues = append(issues, newError(path+".broad_cast_delay", "negative delay %d", a.BroadCastDelay))
+	}
+	for i, v := range a.Votes {
+		issues = append(issues, v.issues(fmt.Sprintf("%s.votes[%d]", path, i))...)
+	}
+	for i, r := range a.Release {
+		rulePath := fmt.Sprintf("%s.release[%d]", path, i)
+		switch r.Trigger {
+		case TriggerTime:
+			if r.Ms < 0 {
+				issues = append(issues, newError(rulePath+".ms", "negative delay %d", r.Ms))
+			}
+		case TriggerBlock, TriggerHead:
+		default:
+			issues = append(issues, newError(rulePath+".trigger", "unknown value %q", r.Trigger))
+		}
+	}
+	if len(a.Release) > 0 && !a.Withhold {
+		issues = append(issues, newWarning(path+".release", "attackers don't withhold their attestations, nothing to release"))
+	}
+	return issues
+}
+
+func (v VoteRule) issues(path string) []Issue {
+	issues := make([]Issue, 0)
+	if v.Slots != nil {
+		issues = append(issues, v.Slots.issues(path+".slots")...)
+	}
+	switch v.Head {
+	case "", VoteHeadParent, VoteHeadAttacker:
+	default:
+		if _, err := ParseRoot(v.Head); err != nil {
+			issues = append(issues, newError(path+".head", "invalid head %q: %v", v.Head, err))
+		}
+	}
+	if v.Source != nil {
+		issues = append(issues, v.Source.issues(path+".source")...)
+	}
+	if v.Target != nil {
+		issues = append(issues, v.Target.issues(path+".target")...)
+	}
+	if v.Head == "" && v.Source == nil && v.Target == nil {
+		issues = append(issues, newWarning(path, "rule doesn't change the vote"))
+	}
+	return issues
+}
+
+func (c Checkpoint) issues(path string) []Issue {
+	issues := make([]Issue, 0)
+	if c.Epoch != nil && *c.Epoch < 0 {
+		issues = append(issues, newError(path+".epoch", "negative epoch %d", *c.Epoch))
+	}
+	if c.Root != "" {
+		if _, err := ParseRoot(c.Root); err != nil {
+			issues = append(issues, newError(path+".root", "invalid root %q: %v", c.Root, err))
+		}
+	}
+	return issues
+}
+
+func newError(path string, format string, args ...interface{}) Issue {
+	return Issue{Path: path, Severity: SeverityError, Message: fmt.Sprintf(format, args...)}
+}
+
+func newWarning(path string, format string, args ...interface{}) Issue {
+	return Issue{Path: path, Severity: SeverityWarning, Message: fmt.Sprintf(format, args...)}
+}
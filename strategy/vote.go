@@ -0,0 +1,73 @@
+package strategy
+
+import (
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/tsinghua-cel/attacker-service/types"
+)
+
+// head values of VoteRule, besides a hex block root.
+const (
+	VoteHeadParent   = "parent"   // the parent of the block the validator votes for
+	VoteHeadAttacker = "attacker" // the latest block proposed by attackers
+)
+
+// Checkpoint overrides a checkpoint of the vote, unset fields keep the
+// original values.
+type Checkpoint struct {
+	Epoch *int64 `json:"epoch,omitempty"`
+	Root  string `json:"root,omitempty"` // hex block root
+}
+
+// VoteRule rewrites the attestation data before the validators sign it.
+type VoteRule struct {
+	// Validators lists the validators the rule applies to, nil means all
+	// attackers.
+	Validators []int       `json:"validators"`
+	Slots      *Range      `json:"slots,omitempty"` // nil means every slot
+	Head       string      `json:"head,omitempty"`  // VoteHeadParent, VoteHeadAttacker or a hex root
+	Source     *Checkpoint `json:"source,omitempty"`
+	Target     *Checkpoint `json:"target,omitempty"`
+}
+
+func (r *VoteRule) matches(valIdx int, slot int64, role types.RoleType) bool {
+	if r.Slots != nil && !r.Slots.contains(slot) {
+		return false
+	}
+	if r.Validators == nil {
+		return role == types.AttackerRole
+	}
+	for _, idx := range r.Validators {
+		if idx == valIdx {
+			return true
+		}
+	}
+	return false
+}
+
+// VoteRuleAt returns the first vote rule of the attest strategy active at
+// slot that applies to the validator, or nil.
+func (s *Strategy) VoteRuleAt(valIdx int, slot int64) *VoteRule {
+	votes := s.AttestStrategyAt(slot).Votes
+	if len(votes) == 0 {
+		return nil
+	}
+	role := s.GetValidatorRole(valIdx, slot)
+	for i := range votes {
+		if votes[i].matches(valIdx, slot, role) {
+			return &votes[i]
+		}
+	}
+	return nil
+}
+
+// ParseRoot decodes a hex block root.
+func ParseRoot(root string) ([]byte, error) {
+	d, err := hexutil.Decode(root)
+	if err != nil {
+		return nil, err
+	}
+	if len(d) != 32 {
+		return nil, errInvalidRootLength
+	}
+	return d, nil
+}
@@ -0,0 +1,48 @@
+package strategy
+
+import (
+	"testing"
+)
+
+const voteStrategy = `{
+  "validator": [
+    {"validator_index": 1, "attacker_start_slot": 0, "attacker_end_slot": 1000}
+  ],
+  "attest": {
+    "votes": [
+      {"validators": [2], "slots": {"start": 10, "end": 20}, "head": "attacker"},
+      {"head": "parent", "target": {"epoch": 1}}
+    ]
+  },
+  "timeline": [
+    {"name": "honest", "slots": {"start": 100, "end": 200}, "attest": {"withhold": false, "votes": []}}
+  ]
+}`
+
+func TestVoteRuleAt(t *testing.T) {
+	s, err := decodeStrategy([]byte(voteStrategy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if r := s.VoteRuleAt(2, 15); r == nil || r.Head != VoteHeadAttacker {
+		t.Fatalf("validator 2 at slot 15: got %v, want attacker head", r)
+	}
+	if r := s.VoteRuleAt(2, 30); r != nil {
+		t.Fatalf("validator 2 at slot 30: got %v, want no rule", r)
+	}
+	if r := s.VoteRuleAt(1, 30); r == nil || r.Head != VoteHeadParent || *r.Target.Epoch != 1 {
+		t.Fatalf("attacker 1 at slot 30: got %v, want parent head", r)
+	}
+	if r := s.VoteRuleAt(1, 150); r != nil {
+		t.Fatalf("attacker 1 at slot 150: got %v, want no rule in honest phase", r)
+	}
+
+	bad := s.Copy()
+	bad.Attest.Votes = []VoteRule{{Head: "0x1234"}}
+	if err := bad.Validate(); err == nil {
+		t.Fatalf("short head root should be invalid")
+	}
+}
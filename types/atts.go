@@ -1,6 +1,7 @@
 package types
 
 import (
+	"bytes"
 	"context"
 	"github.com/prysmaticlabs/go-bitfield"
 	"github.com/prysmaticlabs/prysm/v4/beacon-chain/core/blocks"
@@ -161,3 +162,61 @@ func (a ProposerAtts) Dedup() (ProposerAtts, error) {
 
 	return uniqAtts, nil
 }
+
+// filter returns the attestations for which keep is true.
+func (a ProposerAtts) filter(keep func(att *ethpb.Attestation) bool) ProposerAtts {
+	kept := make([]*ethpb.Attestation, 0, len(a))
+	for _, att := range a {
+		if keep(att) {
+			kept = append(kept, att)
+		}
+	}
+	return kept
+}
+
+// ExcludeCommittees removes the attestations of the committees with the
+// given indices.
+func (a ProposerAtts) ExcludeCommittees(indices map[uint64]bool) ProposerAtts {
+	return a.filter(func(att *ethpb.Attestation) bool {
+		return !indices[uint64(att.Data.CommitteeIndex)]
+	})
+}
+
+// ExcludeHeads removes the attestations voting for one of the head roots.
+func (a ProposerAtts) ExcludeHeads(roots [][]byte) ProposerAtts {
+	return a.filter(func(att *ethpb.Attestation) bool {
+		for _, root := range roots {
+			if bytes.Equal(att.Data.BeaconBlockRoot, root) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// MinInclusionDelay keeps the attestations at least delay slots older than
+// slot, a delay of 2 keeps the late attestations only.
+func (a ProposerAtts) MinInclusionDelay(slot uint64, delay uint64) ProposerAtts {
+	return a.filter(func(att *ethpb.Attestation) bool {
+		return uint64(att.Data.Slot)+delay <= slot
+	})
+}
+
+// ExcludeValidators removes the attestations with a vote of one of the
+// validators, the aggregate is removed as a whole since its signature covers
+// all the votes. committee returns the validators of a committee, the
+// attestations whose committee is unknown are kept.
+func (a ProposerAtts) ExcludeValidators(committee func(slot uint64, index uint64) ([]uint64, error), validators map[uint64]bool) ProposerAtts {
+	return a.filter(func(att *ethpb.Attestation) bool {
+		members, err := committee(uint64(att.Data.Slot), uint64(att.Data.CommitteeIndex))
+		if err != nil {
+			return true
+		}
+		for _, i := range att.AggregationBits.BitIndices() {
+			if i < len(members) && validators[members[i]] {
+				return false
+			}
+		}
+		return true
+	})
+}
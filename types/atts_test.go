@@ -0,0 +1,55 @@
+package types
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/prysmaticlabs/go-bitfield"
+	"github.com/prysmaticlabs/prysm/v4/consensus-types/primitives"
+	ethpb "github.com/prysmaticlabs/prysm/v4/proto/prysm/v1alpha1"
+)
+
+func testAtt(slot uint64, index uint64, head byte, bits ...uint64) *ethpb.Attestation {
+	agg := bitfield.NewBitlist(4)
+	for _, b := range bits {
+		agg.SetBitAt(b, true)
+	}
+	return &ethpb.Attestation{
+		AggregationBits: agg,
+		Data: &ethpb.AttestationData{
+			Slot:            primitives.Slot(slot),
+			CommitteeIndex:  primitives.CommitteeIndex(index),
+			BeaconBlockRoot: []byte{head},
+		},
+	}
+}
+
+func TestInclusionPolicies(t *testing.T) {
+	atts := ProposerAtts{
+		testAtt(8, 0, 0xaa, 0, 1),
+		testAtt(8, 1, 0xbb, 2),
+		testAtt(9, 0, 0xaa, 3),
+		testAtt(6, 2, 0xcc, 1),
+	}
+	if got := atts.ExcludeCommittees(map[uint64]bool{0: true}); len(got) != 2 {
+		t.Fatalf("exclude committee 0: got %d attestations, want 2", len(got))
+	}
+	if got := atts.ExcludeHeads([][]byte{{0xaa}, {0xcc}}); len(got) != 1 || got[0].Data.BeaconBlockRoot[0] != 0xbb {
+		t.Fatalf("exclude heads: got %v", got)
+	}
+	if got := atts.MinInclusionDelay(10, 2); len(got) != 3 {
+		t.Fatalf("late attestations at slot 10: got %d, want 3", len(got))
+	}
+
+	committee := func(slot uint64, index uint64) ([]uint64, error) {
+		if index == 2 {
+			return nil, errors.New("unknown committee")
+		}
+		return []uint64{index*10 + 0, index*10 + 1, index*10 + 2, index*10 + 3}, nil
+	}
+	// validator 1 votes in the first aggregate, 12 in the second.
+	got := atts.ExcludeValidators(committee, map[uint64]bool{1: true, 12: true})
+	if len(got) != 2 || got[0].Data.Slot != 9 || got[1].Data.CommitteeIndex != 2 {
+		t.Fatalf("exclude validators: got %v", got)
+	}
+}
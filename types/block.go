@@ -0,0 +1,240 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/prysmaticlabs/prysm/v4/consensus-types/blocks"
+	"github.com/prysmaticlabs/prysm/v4/consensus-types/interfaces"
+	ethpb "github.com/prysmaticlabs/prysm/v4/proto/prysm/v1alpha1"
+	"github.com/prysmaticlabs/prysm/v4/runtime/version"
+)
+
+var ErrNilBlock = errors.New("nil block")
+
+// SignedBlock is a fork agnostic view of a GenericSignedBeaconBlock, it works
+// for every fork and for the blinded blocks of the builder. The setters change
+// the block returned by Generic.
+type SignedBlock struct {
+	block interfaces.SignedBeaconBlock
+
+	// deneb block contents carry the blobs beside the block.
+	kzgProofs [][]byte
+	blobs     [][]byte
+}
+
+// NewSignedBlock wraps generic, fields that don't exist in the fork of the
+// block return an error from their getter and setter.
+func NewSignedBlock(generic *ethpb.GenericSignedBeaconBlock) (*SignedBlock, error) {
+	if generic == nil || generic.Block == nil {
+		return nil, ErrNilBlock
+	}
+	b := &SignedBlock{}
+	if deneb, ok := generic.Block.(*ethpb.GenericSignedBeaconBlock_Deneb); ok {
+		if deneb.Deneb == nil {
+			return nil, ErrNilBlock
+		}
+		b.kzgProofs = deneb.Deneb.KzgProofs
+		b.blobs = deneb.Deneb.Blobs
+	}
+	block, err := blocks.NewSignedBeaconBlock(generic.Block)
+	if err != nil {
+		return nil, err
+	}
+	b.block = block
+	return b, nil
+}
+
+// NewBlockFromSSZ decodes an unsigned block of fork, like "capella", as
+// produced by the beacon node, the block contents since deneb. The block has
+// an empty signature, like the blocks of block_beforeSign.
+func NewBlockFromSSZ(fork string, data []byte) (*SignedBlock, error) {
+	v, err := version.FromString(fork)
+	if err != nil {
+		return nil, err
+	}
+	var pb interface{ UnmarshalSSZ([]byte) error }
+	var contents *ethpb.BeaconBlockContentsDeneb
+	switch v {
+	case version.Phase0:
+		pb = &ethpb.BeaconBlock{}
+	case version.Altair:
+		pb = &ethpb.BeaconBlockAltair{}
+	case version.Bellatrix:
+		pb = &ethpb.BeaconBlockBellatrix{}
+	case version.Capella:
+		pb = &ethpb.BeaconBlockCapella{}
+	case version.Deneb:
+		contents = &ethpb.BeaconBlockContentsDeneb{}
+		pb = contents
+	default:
+		return nil, fmt.Errorf("unsupported fork %s", fork)
+	}
+	if err := pb.UnmarshalSSZ(data); err != nil {
+		return nil, err
+	}
+	var raw interface{} = pb
+	if contents != nil {
+		raw = contents.Block
+	}
+	block, err := blocks.NewBeaconBlock(raw)
+	if err != nil {
+		return nil, err
+	}
+	signed, err := blocks.BuildSignedBeaconBlock(block, make([]byte, 96))
+	if err != nil {
+		return nil, err
+	}
+	b := &SignedBlock{block: signed}
+	if contents != nil {
+		b.kzgProofs = contents.KzgProofs
+		b.blobs = contents.Blobs
+	}
+	return b, nil
+}
+
+// Generic returns the block as a GenericSignedBeaconBlock of its fork.
+func (b *SignedBlock) Generic() (*ethpb.GenericSignedBeaconBlock, error) {
+	if b.block.Version() == version.Deneb && !b.block.IsBlinded() {
+		// PbGenericBlock can't build the deneb block contents.
+		pb, err := b.block.PbDenebBlock()
+		if err != nil {
+			return nil, err
+		}
+		return &ethpb.GenericSignedBeaconBlock{
+			Block: &ethpb.GenericSignedBeaconBlock_Deneb{Deneb: &ethpb.SignedBeaconBlockContentsDeneb{
+				Block:     pb,
+				KzgProofs: b.kzgProofs,
+				Blobs:     b.blobs,
+			}},
+		}, nil
+	}
+	return b.block.PbGenericBlock()
+}
+
+// MarshalSSZ returns the ssz encoding of the signed block as published to a
+// beacon node, the signed block contents since deneb.
+func (b *SignedBlock) MarshalSSZ() ([]byte, error) {
+	if b.block.Version() == version.Deneb && !b.block.IsBlinded() {
+		generic, err := b.Generic()
+		if err != nil {
+			return nil, err
+		}
+		return generic.GetDeneb().MarshalSSZ()
+	}
+	return b.block.MarshalSSZ()
+}
+
+// Fork returns the name of the fork of the block, like "capella".
+func (b *SignedBlock) Fork() string {
+	return version.String(b.block.Version())
+}
+
+func (b *SignedBlock) Version() int {
+	return b.block.Version()
+}
+
+func (b *SignedBlock) IsBlinded() bool {
+	return b.block.IsBlinded()
+}
+
+func (b *SignedBlock) Slot() uint64 {
+	return uint64(b.block.Block().Slot())
+}
+
+func (b *SignedBlock) ProposerIndex() uint64 {
+	return uint64(b.block.Block().ProposerIndex())
+}
+
+// Root returns the hash tree root of the block.
+func (b *SignedBlock) Root() ([]byte, error) {
+	root, err := b.block.Block().HashTreeRoot()
+	if err != nil {
+		return nil, err
+	}
+	return root[:], nil
+}
+
+func (b *SignedBlock) ParentRoot() []byte {
+	root := b.block.Block().ParentRoot()
+	return root[:]
+}
+
+func (b *SignedBlock) SetParentRoot(root []byte) {
+	b.block.SetParentRoot(root)
+}
+
+func (b *SignedBlock) StateRoot() []byte {
+	root := b.block.Block().StateRoot()
+	return root[:]
+}
+
+func (b *SignedBlock) SetStateRoot(root []byte) {
+	b.block.SetStateRoot(root)
+}
+
+func (b *SignedBlock) RandaoReveal() []byte {
+	reveal := b.block.Block().Body().RandaoReveal()
+	return reveal[:]
+}
+
+func (b *SignedBlock) Graffiti() []byte {
+	graffiti := b.block.Block().Body().Graffiti()
+	return graffiti[:]
+}
+
+func (b *SignedBlock) SetGraffiti(graffiti []byte) {
+	b.block.SetGraffiti(graffiti)
+}
+
+func (b *SignedBlock) Attestations() []*ethpb.Attestation {
+	return b.block.Block().Body().Attestations()
+}
+
+func (b *SignedBlock) SetAttestations(atts []*ethpb.Attestation) {
+	b.block.SetAttestations(atts)
+}
+
+func (b *SignedBlock) ProposerSlashings() []*ethpb.ProposerSlashing {
+	return b.block.Block().Body().ProposerSlashings()
+}
+
+func (b *SignedBlock) SetProposerSlashings(slashings []*ethpb.ProposerSlashing) {
+	b.block.SetProposerSlashings(slashings)
+}
+
+func (b *SignedBlock) AttesterSlashings() []*ethpb.AttesterSlashing {
+	return b.block.Block().Body().AttesterSlashings()
+}
+
+func (b *SignedBlock) SetAttesterSlashings(slashings []*ethpb.AttesterSlashing) {
+	b.block.SetAttesterSlashings(slashings)
+}
+
+// SyncAggregate is available since altair.
+func (b *SignedBlock) SyncAggregate() (*ethpb.SyncAggregate, error) {
+	return b.block.Block().Body().SyncAggregate()
+}
+
+func (b *SignedBlock) SetSyncAggregate(agg *ethpb.SyncAggregate) error {
+	return b.block.SetSyncAggregate(agg)
+}
+
+// Execution returns the execution payload since bellatrix, it is the payload
+// header for blinded blocks.
+func (b *SignedBlock) Execution() (interfaces.ExecutionData, error) {
+	return b.block.Block().Body().Execution()
+}
+
+func (b *SignedBlock) SetExecution(e interfaces.ExecutionData) error {
+	return b.block.SetExecution(e)
+}
+
+// BlobKzgCommitments is available since deneb.
+func (b *SignedBlock) BlobKzgCommitments() ([][]byte, error) {
+	return b.block.Block().Body().BlobKzgCommitments()
+}
+
+func (b *SignedBlock) SetBlobKzgCommitments(commitments [][]byte) error {
+	return b.block.SetBlobKzgCommitments(commitments)
+}
@@ -0,0 +1,98 @@
+package types
+
+import (
+	"bytes"
+	"testing"
+
+	enginev1 "github.com/prysmaticlabs/prysm/v4/proto/engine/v1"
+	ethpb "github.com/prysmaticlabs/prysm/v4/proto/prysm/v1alpha1"
+)
+
+func TestSignedBlockForks(t *testing.T) {
+	deneb := &ethpb.SignedBeaconBlockContentsDeneb{
+		Block:     &ethpb.SignedBeaconBlockDeneb{Block: &ethpb.BeaconBlockDeneb{Body: &ethpb.BeaconBlockBodyDeneb{ExecutionPayload: &enginev1.ExecutionPayloadDeneb{}}}},
+		KzgProofs: [][]byte{bytes.Repeat([]byte{1}, 48)},
+		Blobs:     [][]byte{bytes.Repeat([]byte{2}, 131072)},
+	}
+	generics := map[string]*ethpb.GenericSignedBeaconBlock{
+		"phase0":          {Block: &ethpb.GenericSignedBeaconBlock_Phase0{Phase0: &ethpb.SignedBeaconBlock{Block: &ethpb.BeaconBlock{Body: &ethpb.BeaconBlockBody{}}}}},
+		"altair":          {Block: &ethpb.GenericSignedBeaconBlock_Altair{Altair: &ethpb.SignedBeaconBlockAltair{Block: &ethpb.BeaconBlockAltair{Body: &ethpb.BeaconBlockBodyAltair{}}}}},
+		"bellatrix":       {Block: &ethpb.GenericSignedBeaconBlock_Bellatrix{Bellatrix: &ethpb.SignedBeaconBlockBellatrix{Block: &ethpb.BeaconBlockBellatrix{Body: &ethpb.BeaconBlockBodyBellatrix{ExecutionPayload: &enginev1.ExecutionPayload{}}}}}},
+		"capella":         {Block: &ethpb.GenericSignedBeaconBlock_Capella{Capella: &ethpb.SignedBeaconBlockCapella{Block: &ethpb.BeaconBlockCapella{Body: &ethpb.BeaconBlockBodyCapella{ExecutionPayload: &enginev1.ExecutionPayloadCapella{}}}}}},
+		"blinded_capella": {Block: &ethpb.GenericSignedBeaconBlock_BlindedCapella{BlindedCapella: &ethpb.SignedBlindedBeaconBlockCapella{Block: &ethpb.BlindedBeaconBlockCapella{Body: &ethpb.BlindedBeaconBlockBodyCapella{ExecutionPayloadHeader: &enginev1.ExecutionPayloadHeaderCapella{}}}}}},
+		"deneb":           {Block: &ethpb.GenericSignedBeaconBlock_Deneb{Deneb: deneb}},
+	}
+	graffiti := bytes.Repeat([]byte{7}, 32)
+	att := &ethpb.Attestation{AggregationBits: []byte{0x03}, Data: &ethpb.AttestationData{}}
+	for name, generic := range generics {
+		block, err := NewSignedBlock(generic)
+		if err != nil {
+			t.Fatalf("%s: new block failed: %v", name, err)
+		}
+		block.SetGraffiti(graffiti)
+		block.SetAttestations([]*ethpb.Attestation{att})
+
+		res, err := block.Generic()
+		if err != nil {
+			t.Fatalf("%s: generic failed: %v", name, err)
+		}
+		block, err = NewSignedBlock(res)
+		if err != nil {
+			t.Fatalf("%s: new block from result failed: %v", name, err)
+		}
+		if !bytes.Equal(block.Graffiti(), graffiti) {
+			t.Fatalf("%s: graffiti not set", name)
+		}
+		if len(block.Attestations()) != 1 {
+			t.Fatalf("%s: got %d attestations, want 1", name, len(block.Attestations()))
+		}
+		if name == "deneb" {
+			contents := res.GetDeneb()
+			if len(contents.Blobs) != 1 || len(contents.KzgProofs) != 1 {
+				t.Fatalf("deneb: blobs are lost")
+			}
+		}
+	}
+}
+
+func TestNewBlockFromSSZ(t *testing.T) {
+	root := make([]byte, 32)
+	pb := &ethpb.BeaconBlockCapella{
+		Slot:       9,
+		ParentRoot: root,
+		StateRoot:  root,
+		Body: &ethpb.BeaconBlockBodyCapella{
+			RandaoReveal:  bytes.Repeat([]byte{5}, 96),
+			Eth1Data:      &ethpb.Eth1Data{DepositRoot: root, BlockHash: root},
+			Graffiti:      root,
+			SyncAggregate: &ethpb.SyncAggregate{SyncCommitteeBits: make([]byte, 64), SyncCommitteeSignature: make([]byte, 96)},
+			ExecutionPayload: &enginev1.ExecutionPayloadCapella{
+				ParentHash:    root,
+				FeeRecipient:  make([]byte, 20),
+				StateRoot:     root,
+				ReceiptsRoot:  root,
+				LogsBloom:     make([]byte, 256),
+				PrevRandao:    root,
+				BaseFeePerGas: root,
+				BlockHash:     root,
+			},
+		},
+	}
+	data, err := pb.MarshalSSZ()
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := NewBlockFromSSZ("capella", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if block.Slot() != 9 || !bytes.Equal(block.RandaoReveal(), pb.Body.RandaoReveal) || block.Fork() != "capella" {
+		t.Fatalf("decoded block: slot %d, fork %s", block.Slot(), block.Fork())
+	}
+	if _, err := block.Generic(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewBlockFromSSZ("capella", data[:10]); err == nil {
+		t.Fatalf("truncated block decoded")
+	}
+}
@@ -0,0 +1,103 @@
+package types
+
+import "fmt"
+
+// CommandSchemaVersion is bumped whenever a command is added or the meaning
+// of a command changes, validator clients check it to know which commands
+// the service may send.
+//
+//	1: initial commands
+//	2: responses may carry a delay, the client waits for it before acting on
+//	   the command instead of the service sleeping in the call
+//	3: block_beforeSign responses may carry a second, conflicting block to
+//	   sign and broadcast
+//	4: CMD_EXIT is no longer advisory, the client submits the exit
+const CommandSchemaVersion = 4
+
+// CommandInfo documents an AttackerCommand.
+type CommandInfo struct {
+	Cmd         AttackerCommand `json:"cmd"`
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	// NeedValidator is true if the command applies to the validator of the
+	// call, it is only sent by hooks that carry a pubkey.
+	NeedValidator bool `json:"need_validator"`
+}
+
+// CommandSchema is the versioned list of the commands.
+type CommandSchema struct {
+	Version  int           `json:"version"`
+	Commands []CommandInfo `json:"commands"`
+}
+
+var commandInfos = []CommandInfo{
+	{CMD_NULL, "CMD_NULL", "go on with the duty as usual", false},
+	{CMD_CONTINUE, "CMD_CONTINUE", "go on with the duty without calling the remaining hooks of the duty", false},
+	{CMD_RETURN, "CMD_RETURN", "stop the duty at this hook without error, eg. don't propose the block", false},
+	{CMD_ABORT, "CMD_ABORT", "stop the duty at this hook and report an error", false},
+	{CMD_SKIP, "CMD_SKIP", "skip the step of this hook (sign, broadcast...) and go on with the duty", false},
+	{CMD_ROLE_TO_NORMAL, "CMD_ROLE_TO_NORMAL", "the validator is honest from this slot, the service updates its role table", true},
+	{CMD_ROLE_TO_ATTACKER, "CMD_ROLE_TO_ATTACKER", "the validator is an attacker from this slot, the service updates its role table", true},
+	{CMD_EXIT, "CMD_EXIT", "the client submits a voluntary exit of the validator, signed by the exit handler of attackclient, then goes on with the duty", true},
+	{CMD_UPDATE_STATE, "CMD_UPDATE_STATE", "the client refreshes its duties and head state before going on", false},
+}
+
+// commandsByCmd indexes commandInfos by command, the list is ordered for the
+// schema and doesn't follow the command values.
+var commandsByCmd = func() map[AttackerCommand]CommandInfo {
+	m := make(map[AttackerCommand]CommandInfo, len(commandInfos))
+	for _, info := range commandInfos {
+		m[info.Cmd] = info
+	}
+	return m
+}()
+
+// Commands returns the schema of all commands.
+func Commands() CommandSchema {
+	return CommandSchema{
+		Version:  CommandSchemaVersion,
+		Commands: append([]CommandInfo(nil), commandInfos...),
+	}
+}
+
+func (c AttackerCommand) String() string {
+	if info, exist := commandsByCmd[c]; exist {
+		return info.Name
+	}
+	return fmt.Sprintf("CMD_UNKNOWN(%d)", int(c))
+}
+
+// NeedValidator returns true if the command applies to the validator of the
+// call.
+func (c AttackerCommand) NeedValidator() bool {
+	return commandsByCmd[c].NeedValidator
+}
+
+// ParseCommand returns the command with name, like "CMD_EXIT".
+func ParseCommand(name string) (AttackerCommand, error) {
+	for _, info := range commandInfos {
+		if info.Name == name {
+			return info.Cmd, nil
+		}
+	}
+	return CMD_NULL, fmt.Errorf("unknown command %q", name)
+}
+
+// Hooks are the rpc methods called by the validator clients, the value tells
+// if the call carries the pubkey of a validator.
+var Hooks = map[string]bool{
+	"block_delayForReceiveBlock": false,
+	"block_beforeBroadCast":      false,
+	"block_afterBroadCast":       false,
+	"block_beforeMakeBlock":      true,
+	"block_beforeSign":           true,
+	"block_afterSign":            true,
+	"block_beforePropose":        true,
+	"block_afterPropose":         true,
+	"attest_beforeBroadCast":     false,
+	"attest_afterBroadCast":      false,
+	"attest_beforeSign":          true,
+	"attest_afterSign":           true,
+	"attest_beforePropose":       true,
+	"attest_afterPropose":        true,
+}
@@ -0,0 +1,20 @@
+package types
+
+import "testing"
+
+func TestCommands(t *testing.T) {
+	for _, info := range Commands().Commands {
+		if got := info.Cmd.String(); got != info.Name {
+			t.Errorf("command %d: got name %s, want %s", int(info.Cmd), got, info.Name)
+		}
+		if got := info.Cmd.NeedValidator(); got != info.NeedValidator {
+			t.Errorf("%s: got need validator %v", info.Name, got)
+		}
+		if cmd, err := ParseCommand(info.Name); err != nil || cmd != info.Cmd {
+			t.Errorf("parse %s: got %v, %v", info.Name, cmd, err)
+		}
+	}
+	if name := AttackerCommand(-1).String(); name != "CMD_UNKNOWN(-1)" {
+		t.Errorf("unknown command: got %s", name)
+	}
+}
@@ -1,6 +1,9 @@
 package types
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"fmt"
+)
 
 type AttackerCommand int
 
@@ -23,11 +26,73 @@ const (
 	AttackerRole
 )
 
+func (r RoleType) String() string {
+	switch r {
+	case AttackerRole:
+		return "attacker"
+	default:
+		return "normal"
+	}
+}
+
+func (r RoleType) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+func (r *RoleType) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "attacker":
+		*r = AttackerRole
+	case "normal":
+		*r = NormalRole
+	default:
+		return fmt.Errorf("unknown role %q", text)
+	}
+	return nil
+}
+
 type AttackerResponse struct {
 	Cmd    AttackerCommand `json:"cmd"`
 	Result string          `json:"result"`
+	Delay  *DelayInfo      `json:"delay,omitempty"` // the client waits for it before acting on Cmd
+	// Equivocation is a second block for block_beforeSign, the client signs
+	// both and calls block_afterSign for each.
+	Equivocation *EquivocationInfo `json:"equivocation,omitempty"`
 }
 
+// EquivocationInfo is a second block to sign for the slot, base64 of a
+// GenericSignedBeaconBlock like the block of block_beforeSign. The blocks
+// are sent to Targets and Block to AltTargets, to the usual peers if empty.
+type EquivocationInfo struct {
+	Block      string   `json:"block"`
+	Targets    []string `json:"targets,omitempty"`
+	AltTargets []string `json:"alt_targets,omitempty"`
+}
+
+// DelayInfo is the deadline of a delayed hook, clients wait until Release or
+// call delay_wait with ID, which also returns when the delay is cancelled.
+type DelayInfo struct {
+	ID       string `json:"id"`
+	Release  int64  `json:"release"`            // unix milliseconds
+	Released bool   `json:"released,omitempty"` // set by delay_wait, the release time is reached or the delay is cancelled
+}
+
+// SlotClockInfo is the current time of the slot clock, the interval times
+// are the starts of the slot, of its 1/3 and 2/3.
+type SlotClockInfo struct {
+	Genesis        int64    `json:"genesis"` // unix seconds
+	SecondsPerSlot int      `json:"seconds_per_slot"`
+	SlotsPerEpoch  int      `json:"slots_per_epoch"`
+	Slot           uint64   `json:"slot"`
+	Epoch          uint64   `json:"epoch"`
+	Offset         int64    `json:"offset"`    // milliseconds since the slot start
+	Intervals      [3]int64 `json:"intervals"` // unix milliseconds
+}
+
+// ClientInfoHeader is the http header carrying the ClientInfo json of the
+// validator client on every call.
+const ClientInfoHeader = "X-Attacker-Client"
+
 type ClientInfo struct {
 	UUID           string `json:"uuid"`
 	ValidatorIndex int    `json:"validatorIndex"`
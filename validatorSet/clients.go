@@ -0,0 +1,113 @@
+package validatorSet
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/tsinghua-cel/attacker-service/types"
+)
+
+// ClientSession is what the service knows about a validator client, keyed by
+// the uuid the client sends with every call.
+type ClientSession struct {
+	UUID       string            `json:"uuid"`
+	Validators []int             `json:"validators"` // validators the client called for
+	RemoteAddr string            `json:"remote_addr"`
+	FirstSeen  time.Time         `json:"first_seen"`
+	LastSeen   time.Time         `json:"last_seen"`
+	LastSlot   uint64            `json:"last_slot"`
+	Calls      map[string]uint64 `json:"calls"` // method -> count
+	Stale      bool              `json:"stale"`
+}
+
+// ClientSet is the registry of the validator clients calling the service.
+type ClientSet struct {
+	clients map[string]*ClientSession
+	lock    sync.RWMutex
+}
+
+func NewClientSet() *ClientSet {
+	return &ClientSet{
+		clients: make(map[string]*ClientSession),
+	}
+}
+
+// Seen records a call of method by the client at slot, valIdx is the
+// validator of the call, -1 if unknown.
+func (cs *ClientSet) Seen(info types.ClientInfo, remoteAddr string, method string, slot uint64, valIdx int) {
+	now := time.Now()
+	cs.lock.Lock()
+	defer cs.lock.Unlock()
+
+	c, exist := cs.clients[info.UUID]
+	if !exist {
+		c = &ClientSession{
+			UUID:       info.UUID,
+			Validators: make([]int, 0),
+			FirstSeen:  now,
+			Calls:      make(map[string]uint64),
+		}
+		cs.clients[info.UUID] = c
+		c.addValidator(info.ValidatorIndex)
+	}
+	c.addValidator(valIdx)
+	c.RemoteAddr = remoteAddr
+	c.LastSeen = now
+	if slot > c.LastSlot {
+		c.LastSlot = slot
+	}
+	c.Calls[method]++
+}
+
+func (c *ClientSession) addValidator(valIdx int) {
+	if valIdx < 0 {
+		return
+	}
+	i := sort.SearchInts(c.Validators, valIdx)
+	if i < len(c.Validators) && c.Validators[i] == valIdx {
+		return
+	}
+	c.Validators = append(c.Validators, 0)
+	copy(c.Validators[i+1:], c.Validators[i:])
+	c.Validators[i] = valIdx
+}
+
+// Get returns a copy of the session of the client, or nil.
+func (cs *ClientSet) Get(uuid string) *ClientSession {
+	cs.lock.RLock()
+	defer cs.lock.RUnlock()
+	c, exist := cs.clients[uuid]
+	if !exist {
+		return nil
+	}
+	return c.copy()
+}
+
+// List returns copies of all sessions ordered by first seen, clients that
+// haven't called for staleAfter are marked stale.
+func (cs *ClientSet) List(staleAfter time.Duration) []*ClientSession {
+	now := time.Now()
+	cs.lock.RLock()
+	defer cs.lock.RUnlock()
+	list := make([]*ClientSession, 0, len(cs.clients))
+	for _, c := range cs.clients {
+		n := c.copy()
+		n.Stale = now.Sub(c.LastSeen) > staleAfter
+		list = append(list, n)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].FirstSeen.Before(list[j].FirstSeen)
+	})
+	return list
+}
+
+func (c *ClientSession) copy() *ClientSession {
+	n := *c
+	n.Validators = append([]int(nil), c.Validators...)
+	n.Calls = make(map[string]uint64, len(c.Calls))
+	for k, v := range c.Calls {
+		n.Calls[k] = v
+	}
+	return &n
+}
@@ -0,0 +1,34 @@
+package validatorSet
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tsinghua-cel/attacker-service/types"
+)
+
+func TestClientSet(t *testing.T) {
+	cs := NewClientSet()
+	info := types.ClientInfo{UUID: "a", ValidatorIndex: 7}
+	cs.Seen(info, "127.0.0.1:1000", "block_beforeSign", 10, 3)
+	cs.Seen(info, "127.0.0.1:1001", "attest_beforeSign", 12, 3)
+	cs.Seen(info, "127.0.0.1:1001", "attest_beforeSign", 11, -1)
+
+	c := cs.Get("a")
+	if c == nil {
+		t.Fatal("client a not found")
+	}
+	if c.LastSlot != 12 || c.Calls["attest_beforeSign"] != 2 || c.RemoteAddr != "127.0.0.1:1001" {
+		t.Fatalf("unexpected session %+v", c)
+	}
+	if len(c.Validators) != 2 || c.Validators[0] != 3 || c.Validators[1] != 7 {
+		t.Fatalf("got validators %v, want [3 7]", c.Validators)
+	}
+	if list := cs.List(time.Hour); len(list) != 1 || list[0].Stale {
+		t.Fatalf("client should be active: %+v", list)
+	}
+	time.Sleep(time.Millisecond)
+	if list := cs.List(0); !list[0].Stale {
+		t.Fatalf("client should be stale")
+	}
+}
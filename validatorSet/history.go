@@ -0,0 +1,431 @@
+package validatorSet
+
+import (
+	"bytes"
+	"encoding/hex"
+	"sort"
+
+	ethpb "github.com/prysmaticlabs/prysm/v4/proto/prysm/v1alpha1"
+	log "github.com/sirupsen/logrus"
+	"github.com/tsinghua-cel/attacker-service/types"
+)
+
+// SignedAttestation is an attestation signed by a validator client. The
+// attestation is shared, callers must not modify it.
+type SignedAttestation struct {
+	Slot           uint64             `json:"slot"`
+	Epoch          uint64             `json:"epoch"` // epoch of Slot
+	Pubkey         string             `json:"pubkey"`
+	ValidatorIndex int                `json:"validator_index"` // -1 if unknown when recorded
+	Attestation    *ethpb.Attestation `json:"attestation"`
+}
+
+// SignedBlock is a block signed by a validator client. The block is shared,
+// callers must not modify it.
+type SignedBlock struct {
+	Slot           uint64                          `json:"slot"`
+	Epoch          uint64                          `json:"epoch"`
+	Pubkey         string                          `json:"pubkey"`
+	ValidatorIndex int                             `json:"validator_index"`
+	Root           string                          `json:"root"` // hex, empty if the block has no root
+	Block          *ethpb.GenericSignedBeaconBlock `json:"block"`
+	// Equivocation is true for a second block of the validator for the slot
+	// with another root, it is kept beside the first one.
+	Equivocation bool `json:"equivocation,omitempty"`
+}
+
+// Equivocation is a validator that signed two blocks for the same slot.
+type Equivocation struct {
+	First  SignedBlock `json:"first"`
+	Second SignedBlock `json:"second"`
+}
+
+func blockRoot(block *ethpb.GenericSignedBeaconBlock) string {
+	b, err := types.NewSignedBlock(block)
+	if err != nil {
+		return ""
+	}
+	root, err := b.Root()
+	if err != nil {
+		return ""
+	}
+	return "0x" + hex.EncodeToString(root)
+}
+
+// HistoryStore saves the signed blocks and attestations so that they survive
+// a restart of the service, see store.Store.
+type HistoryStore interface {
+	SaveAttestation(a SignedAttestation) error
+	SaveBlock(b SignedBlock) error
+	PruneBefore(slot uint64) error
+}
+
+type recordKey struct {
+	slot   uint64
+	pubkey string
+}
+
+// index keeps records by slot, by epoch and by validator pubkey. A record
+// added again for the same slot and validator replaces the previous one.
+type index[T any] struct {
+	bySlot      map[uint64]map[string]*T
+	byEpoch     map[uint64]map[recordKey]*T
+	byValidator map[string]map[uint64]*T
+	epochOf     map[uint64]uint64 // slot -> epoch the records of slot are indexed at
+}
+
+func newIndex[T any]() *index[T] {
+	return &index[T]{
+		bySlot:      make(map[uint64]map[string]*T),
+		byEpoch:     make(map[uint64]map[recordKey]*T),
+		byValidator: make(map[string]map[uint64]*T),
+		epochOf:     make(map[uint64]uint64),
+	}
+}
+
+// add indexes r and returns the record it replaced, or nil.
+func (ix *index[T]) add(slot uint64, epoch uint64, pubkey string, r *T) *T {
+	old := ix.remove(slot, pubkey)
+	if _, exist := ix.bySlot[slot]; !exist {
+		ix.bySlot[slot] = make(map[string]*T)
+		ix.epochOf[slot] = epoch
+	}
+	epoch = ix.epochOf[slot]
+	ix.bySlot[slot][pubkey] = r
+	if _, exist := ix.byEpoch[epoch]; !exist {
+		ix.byEpoch[epoch] = make(map[recordKey]*T)
+	}
+	ix.byEpoch[epoch][recordKey{slot, pubkey}] = r
+	if _, exist := ix.byValidator[pubkey]; !exist {
+		ix.byValidator[pubkey] = make(map[uint64]*T)
+	}
+	ix.byValidator[pubkey][slot] = r
+	return old
+}
+
+func (ix *index[T]) remove(slot uint64, pubkey string) *T {
+	r, exist := ix.bySlot[slot][pubkey]
+	if !exist {
+		return nil
+	}
+	epoch := ix.epochOf[slot]
+	delete(ix.bySlot[slot], pubkey)
+	if len(ix.bySlot[slot]) == 0 {
+		delete(ix.bySlot, slot)
+		delete(ix.epochOf, slot)
+	}
+	delete(ix.byEpoch[epoch], recordKey{slot, pubkey})
+	if len(ix.byEpoch[epoch]) == 0 {
+		delete(ix.byEpoch, epoch)
+	}
+	delete(ix.byValidator[pubkey], slot)
+	if len(ix.byValidator[pubkey]) == 0 {
+		delete(ix.byValidator, pubkey)
+	}
+	return r
+}
+
+// pruneBefore removes the records of the slots before slot and returns them.
+func (ix *index[T]) pruneBefore(slot uint64) []*T {
+	removed := make([]*T, 0)
+	for s, records := range ix.bySlot {
+		if s >= slot {
+			continue
+		}
+		for pubkey := range records {
+			removed = append(removed, ix.remove(s, pubkey))
+		}
+	}
+	return removed
+}
+
+func (ix *index[T]) slot(slot uint64) []*T {
+	list := make([]*T, 0, len(ix.bySlot[slot]))
+	for _, r := range ix.bySlot[slot] {
+		list = append(list, r)
+	}
+	return list
+}
+
+func (ix *index[T]) epoch(epoch uint64) []*T {
+	list := make([]*T, 0, len(ix.byEpoch[epoch]))
+	for _, r := range ix.byEpoch[epoch] {
+		list = append(list, r)
+	}
+	return list
+}
+
+func (ix *index[T]) validator(pubkey string) []*T {
+	list := make([]*T, 0, len(ix.byValidator[pubkey]))
+	for _, r := range ix.byValidator[pubkey] {
+		list = append(list, r)
+	}
+	return list
+}
+
+func (ix *index[T]) size() int {
+	n := 0
+	for _, records := range ix.bySlot {
+		n += len(records)
+	}
+	return n
+}
+
+// oldestSlot returns the lowest slot with records, ok is false if empty.
+func (ix *index[T]) oldestSlot() (slot uint64, ok bool) {
+	for s := range ix.bySlot {
+		if !ok || s < slot {
+			slot, ok = s, true
+		}
+	}
+	return slot, ok
+}
+
+func sortAttestations(list []*SignedAttestation) []SignedAttestation {
+	res := make([]SignedAttestation, len(list))
+	for i, a := range list {
+		res[i] = *a
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Slot != res[j].Slot {
+			return res[i].Slot < res[j].Slot
+		}
+		return res[i].Pubkey < res[j].Pubkey
+	})
+	return res
+}
+
+func sortBlocks(list []*SignedBlock) []SignedBlock {
+	res := make([]SignedBlock, len(list))
+	for i, b := range list {
+		res[i] = *b
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Slot != res[j].Slot {
+			return res[i].Slot < res[j].Slot
+		}
+		return res[i].Pubkey < res[j].Pubkey
+	})
+	return res
+}
+
+// attestTarget returns the target epoch of the attestation, ok is false if it
+// has no target.
+func attestTarget(att *ethpb.Attestation) (epoch uint64, ok bool) {
+	if att == nil || att.Data == nil || att.Data.Target == nil {
+		return 0, false
+	}
+	return uint64(att.Data.Target.Epoch), true
+}
+
+func (vs *ValidatorDataSet) validatorIndex(pubkey string) int {
+	if v, exist := vs.ValidatorByPubkey.Load(pubkey); exist {
+		return int(v.(*ValidatorInfo).Index)
+	}
+	return -1
+}
+
+// AttestationsAtSlot returns the attestations signed for slot.
+func (vs *ValidatorDataSet) AttestationsAtSlot(slot uint64) []SignedAttestation {
+	vs.lock.RLock()
+	defer vs.lock.RUnlock()
+	return sortAttestations(vs.attests.slot(slot))
+}
+
+// AttestationsInEpoch returns the attestations signed for the slots of epoch.
+func (vs *ValidatorDataSet) AttestationsInEpoch(epoch uint64) []SignedAttestation {
+	vs.lock.RLock()
+	defer vs.lock.RUnlock()
+	return sortAttestations(vs.attests.epoch(epoch))
+}
+
+// AttestationsByValidator returns the attestations signed by the validator.
+func (vs *ValidatorDataSet) AttestationsByValidator(pubkey string) []SignedAttestation {
+	pubkey = padPubkey(pubkey)
+	vs.lock.RLock()
+	defer vs.lock.RUnlock()
+	return sortAttestations(vs.attests.validator(pubkey))
+}
+
+// AttestationsByTarget returns the attestations voting for the target
+// checkpoint (epoch, root), a nil root matches any root of epoch.
+func (vs *ValidatorDataSet) AttestationsByTarget(epoch uint64, root []byte) []SignedAttestation {
+	vs.lock.RLock()
+	defer vs.lock.RUnlock()
+	list := make([]*SignedAttestation, 0, len(vs.attestsByTarget[epoch]))
+	for _, a := range vs.attestsByTarget[epoch] {
+		if root == nil || bytes.Equal(a.Attestation.Data.Target.Root, root) {
+			list = append(list, a)
+		}
+	}
+	return sortAttestations(list)
+}
+
+// BlocksAtSlot returns the blocks signed for slot.
+func (vs *ValidatorDataSet) BlocksAtSlot(slot uint64) []SignedBlock {
+	vs.lock.RLock()
+	defer vs.lock.RUnlock()
+	return sortBlocks(vs.blocks.slot(slot))
+}
+
+// BlocksInEpoch returns the blocks signed for the slots of epoch.
+func (vs *ValidatorDataSet) BlocksInEpoch(epoch uint64) []SignedBlock {
+	vs.lock.RLock()
+	defer vs.lock.RUnlock()
+	return sortBlocks(vs.blocks.epoch(epoch))
+}
+
+// BlocksByValidator returns the blocks signed by the validator.
+func (vs *ValidatorDataSet) BlocksByValidator(pubkey string) []SignedBlock {
+	pubkey = padPubkey(pubkey)
+	vs.lock.RLock()
+	defer vs.lock.RUnlock()
+	return sortBlocks(vs.blocks.validator(pubkey))
+}
+
+// EquivocationsInEpoch returns the validators that signed two blocks for a
+// slot of epoch, with both blocks.
+func (vs *ValidatorDataSet) EquivocationsInEpoch(epoch uint64) []Equivocation {
+	vs.lock.RLock()
+	defer vs.lock.RUnlock()
+	seconds := sortBlocks(vs.equivocations.epoch(epoch))
+	list := make([]Equivocation, 0, len(seconds))
+	for _, second := range seconds {
+		first, exist := vs.blocks.bySlot[second.Slot][second.Pubkey]
+		if !exist {
+			continue
+		}
+		list = append(list, Equivocation{First: *first, Second: second})
+	}
+	return list
+}
+
+// FilterAttestations returns the attestations for which keep is true.
+func FilterAttestations(list []SignedAttestation, keep func(a SignedAttestation) bool) []SignedAttestation {
+	res := make([]SignedAttestation, 0, len(list))
+	for _, a := range list {
+		if keep(a) {
+			res = append(res, a)
+		}
+	}
+	return res
+}
+
+// FilterBlocks returns the blocks for which keep is true.
+func FilterBlocks(list []SignedBlock, keep func(b SignedBlock) bool) []SignedBlock {
+	res := make([]SignedBlock, 0, len(list))
+	for _, b := range list {
+		if keep(b) {
+			res = append(res, b)
+		}
+	}
+	return res
+}
+
+// HistoryStats is the size of the kept signed data.
+type HistoryStats struct {
+	Attestations  int     `json:"attestations"`
+	Blocks        int     `json:"blocks"`
+	Equivocations int     `json:"equivocations"`
+	OldestSlot    *uint64 `json:"oldest_slot"` // nil if nothing is kept
+}
+
+func (vs *ValidatorDataSet) HistoryStats() HistoryStats {
+	vs.lock.RLock()
+	defer vs.lock.RUnlock()
+	stats := HistoryStats{
+		Attestations:  vs.attests.size(),
+		Blocks:        vs.blocks.size(),
+		Equivocations: vs.equivocations.size(),
+	}
+	oldest, ok := vs.attests.oldestSlot()
+	if b, bok := vs.blocks.oldestSlot(); bok && (!ok || b < oldest) {
+		oldest, ok = b, true
+	}
+	if ok {
+		stats.OldestSlot = &oldest
+	}
+	return stats
+}
+
+// PruneBefore drops the blocks and attestations of the slots before slot, it
+// returns the number of removed records.
+func (vs *ValidatorDataSet) PruneBefore(slot uint64) int {
+	vs.lock.Lock()
+	defer vs.lock.Unlock()
+	removed := vs.attests.pruneBefore(slot)
+	for _, a := range removed {
+		vs.removeTarget(a)
+	}
+	if vs.store != nil {
+		if err := vs.store.PruneBefore(slot); err != nil {
+			log.WithError(err).WithField("before", slot).Warn("prune saved history failed")
+		}
+	}
+	return len(removed) + len(vs.blocks.pruneBefore(slot)) + len(vs.equivocations.pruneBefore(slot))
+}
+
+// SetStore saves the signed blocks and attestations added from now on to st.
+func (vs *ValidatorDataSet) SetStore(st HistoryStore) {
+	vs.lock.Lock()
+	defer vs.lock.Unlock()
+	vs.store = st
+}
+
+// Restore adds the blocks and attestations saved before a restart, they keep
+// the epoch they were recorded at.
+func (vs *ValidatorDataSet) Restore(attests []SignedAttestation, blocks []SignedBlock) {
+	vs.lock.Lock()
+	defer vs.lock.Unlock()
+	for i := range attests {
+		a := attests[i]
+		vs.addAttestation(&a)
+	}
+	for i := range blocks {
+		b := blocks[i]
+		if b.Root == "" {
+			b.Root = blockRoot(b.Block)
+		}
+		if b.Equivocation {
+			vs.equivocations.add(b.Slot, b.Epoch, b.Pubkey, &b)
+		} else {
+			vs.blocks.add(b.Slot, b.Epoch, b.Pubkey, &b)
+		}
+	}
+}
+
+func (vs *ValidatorDataSet) removeTarget(a *SignedAttestation) {
+	target, ok := attestTarget(a.Attestation)
+	if !ok {
+		return
+	}
+	delete(vs.attestsByTarget[target], recordKey{a.Slot, a.Pubkey})
+	if len(vs.attestsByTarget[target]) == 0 {
+		delete(vs.attestsByTarget, target)
+	}
+}
+
+// Retention is how long the signed blocks and attestations are kept. With
+// Finalized set, Epochs epochs before the finalized epoch are kept, otherwise
+// the last Epochs epochs. Epochs 0 keeps everything.
+type Retention struct {
+	Epochs    uint64
+	Finalized bool
+}
+
+// OldestSlot returns the first slot to keep given the current and the
+// finalized epoch.
+func (r Retention) OldestSlot(currentEpoch uint64, finalizedEpoch uint64, slotsPerEpoch uint64) uint64 {
+	if r.Epochs == 0 {
+		return 0
+	}
+	var oldest uint64
+	if r.Finalized {
+		if finalizedEpoch > r.Epochs {
+			oldest = finalizedEpoch - r.Epochs
+		}
+	} else if currentEpoch+1 > r.Epochs {
+		oldest = currentEpoch + 1 - r.Epochs
+	}
+	return oldest * slotsPerEpoch
+}
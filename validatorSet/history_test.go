@@ -0,0 +1,129 @@
+package validatorSet
+
+import (
+	"testing"
+
+	"github.com/prysmaticlabs/prysm/v4/consensus-types/primitives"
+	ethpb "github.com/prysmaticlabs/prysm/v4/proto/prysm/v1alpha1"
+)
+
+func testAttestation(slot uint64, target uint64, root byte) *ethpb.Attestation {
+	return &ethpb.Attestation{
+		Data: &ethpb.AttestationData{
+			Slot:   primitives.Slot(slot),
+			Target: &ethpb.Checkpoint{Epoch: primitives.Epoch(target), Root: []byte{root}},
+		},
+	}
+}
+
+func TestHistory(t *testing.T) {
+	vs := NewValidatorSet()
+	vs.SetSlotsPerEpoch(4)
+	vs.AddValidator(1, "0x01")
+	vs.AddValidator(2, "0x02")
+
+	vs.AddSignedAttestation(4, "0x01", testAttestation(4, 1, 0xaa))
+	vs.AddSignedAttestation(5, "0x02", testAttestation(5, 1, 0xbb))
+	vs.AddSignedAttestation(9, "01", testAttestation(9, 2, 0xcc))
+	vs.AddSignedBlock(5, "0x02", &ethpb.GenericSignedBeaconBlock{})
+	vs.AddSignedBlock(9, "0x02", &ethpb.GenericSignedBeaconBlock{})
+
+	if list := vs.AttestationsInEpoch(1); len(list) != 2 || list[0].Slot != 4 || list[1].Slot != 5 {
+		t.Fatalf("epoch 1: got %+v", list)
+	}
+	if list := vs.AttestationsByTarget(1, []byte{0xbb}); len(list) != 1 || list[0].ValidatorIndex != 2 {
+		t.Fatalf("target (1, bb): got %+v", list)
+	}
+	if list := vs.AttestationsByTarget(1, nil); len(list) != 2 {
+		t.Fatalf("target epoch 1: got %d attestations, want 2", len(list))
+	}
+	if list := vs.AttestationsByValidator("01"); len(list) != 2 {
+		t.Fatalf("validator 1: got %d attestations, want 2", len(list))
+	}
+	if list := vs.BlocksByValidator("0x02"); len(list) != 2 || list[1].Epoch != 2 {
+		t.Fatalf("blocks of validator 2: got %+v", list)
+	}
+
+	// signing again for the slot replaces the attestation and its target.
+	vs.AddSignedAttestation(5, "0x02", testAttestation(5, 1, 0xdd))
+	if list := vs.AttestationsByTarget(1, []byte{0xbb}); len(list) != 0 {
+		t.Fatalf("replaced attestation still indexed by target")
+	}
+	if list := vs.AttestationsAtSlot(5); len(list) != 1 {
+		t.Fatalf("slot 5: got %d attestations, want 1", len(list))
+	}
+
+	if n := vs.PruneBefore(8); n != 3 {
+		t.Fatalf("pruned %d records, want 3", n)
+	}
+	stats := vs.HistoryStats()
+	if stats.Attestations != 1 || stats.Blocks != 1 || stats.OldestSlot == nil || *stats.OldestSlot != 9 {
+		t.Fatalf("after prune got %+v", stats)
+	}
+	if list := vs.AttestationsByTarget(1, nil); len(list) != 0 {
+		t.Fatalf("pruned attestations still indexed by target")
+	}
+}
+
+// testBlock returns a phase0 block of slot, graffiti makes blocks of the
+// same slot differ.
+func testBlock(slot uint64, graffiti byte) *ethpb.GenericSignedBeaconBlock {
+	g := make([]byte, 32)
+	g[0] = graffiti
+	return &ethpb.GenericSignedBeaconBlock{
+		Block: &ethpb.GenericSignedBeaconBlock_Phase0{Phase0: &ethpb.SignedBeaconBlock{
+			Block: &ethpb.BeaconBlock{
+				Slot:       primitives.Slot(slot),
+				ParentRoot: make([]byte, 32),
+				StateRoot:  make([]byte, 32),
+				Body: &ethpb.BeaconBlockBody{
+					RandaoReveal: make([]byte, 96),
+					Eth1Data: &ethpb.Eth1Data{
+						DepositRoot: make([]byte, 32),
+						BlockHash:   make([]byte, 32),
+					},
+					Graffiti: g,
+				},
+			},
+			Signature: make([]byte, 96),
+		}},
+	}
+}
+
+func TestEquivocations(t *testing.T) {
+	vs := NewValidatorSet()
+	vs.SetSlotsPerEpoch(4)
+	vs.AddValidator(1, "0x01")
+
+	vs.AddSignedBlock(5, "0x01", testBlock(5, 1))
+	vs.AddSignedBlock(5, "0x01", testBlock(5, 1)) // signed again, same root
+	if list := vs.EquivocationsInEpoch(1); len(list) != 0 {
+		t.Fatalf("same block signed twice: got %+v", list)
+	}
+	vs.AddSignedBlock(5, "0x01", testBlock(5, 2))
+	list := vs.EquivocationsInEpoch(1)
+	if len(list) != 1 || list[0].First.Root == list[0].Second.Root || !list[0].Second.Equivocation {
+		t.Fatalf("equivocation: got %+v", list)
+	}
+	if blocks := vs.BlocksAtSlot(5); len(blocks) != 1 || blocks[0].Root != list[0].First.Root {
+		t.Fatalf("first block replaced: got %+v", blocks)
+	}
+	if stats := vs.HistoryStats(); stats.Blocks != 1 || stats.Equivocations != 1 {
+		t.Fatalf("stats: got %+v", stats)
+	}
+	if n := vs.PruneBefore(8); n != 2 {
+		t.Fatalf("pruned %d records, want 2", n)
+	}
+}
+
+func TestRetention(t *testing.T) {
+	if slot := (Retention{Epochs: 4}).OldestSlot(10, 0, 32); slot != 7*32 {
+		t.Fatalf("last 4 epochs at epoch 10: got slot %d", slot)
+	}
+	if slot := (Retention{Epochs: 2, Finalized: true}).OldestSlot(10, 6, 32); slot != 4*32 {
+		t.Fatalf("2 epochs before finalized 6: got slot %d", slot)
+	}
+	if slot := (Retention{Epochs: 4}).OldestSlot(2, 0, 32); slot != 0 {
+		t.Fatalf("early epochs: got slot %d", slot)
+	}
+}
@@ -0,0 +1,81 @@
+package validatorSet
+
+import (
+	"sort"
+	"strings"
+)
+
+// RegistryChanges counts what a registry sync changed.
+type RegistryChanges struct {
+	Added   int `json:"added"`   // new validators, eg. deposits
+	Exited  int `json:"exited"`  // validators whose exit epoch was set
+	Updated int `json:"updated"` // other changes of status or balance
+}
+
+// SyncValidators updates the validators from the registry of the beacon
+// node. Unchanged validators are kept as is, the changed ones are replaced so
+// that the infos returned before stay consistent.
+func (vs *ValidatorDataSet) SyncValidators(list []ValidatorInfo) RegistryChanges {
+	vs.lock.Lock()
+	defer vs.lock.Unlock()
+	var changes RegistryChanges
+	for i := range list {
+		v := list[i]
+		v.Pubkey = padPubkey(v.Pubkey)
+		if old, exist := vs.ValidatorByIndex.Load(int(v.Index)); exist {
+			old := old.(*ValidatorInfo)
+			if *old == v {
+				continue
+			}
+			if old.ExitEpoch != v.ExitEpoch && old.Status != "" {
+				changes.Exited++
+			} else {
+				changes.Updated++
+			}
+			if old.Pubkey != v.Pubkey {
+				vs.ValidatorByPubkey.Delete(old.Pubkey)
+			}
+		} else {
+			changes.Added++
+		}
+		vs.ValidatorByIndex.Store(int(v.Index), &v)
+		vs.ValidatorByPubkey.Store(v.Pubkey, &v)
+	}
+	return changes
+}
+
+// ListValidators returns the validators ordered by index, the ones whose
+// status starts with one of statuses if any, like "active" or "exited_slashed".
+func (vs *ValidatorDataSet) ListValidators(statuses ...string) []ValidatorInfo {
+	vs.lock.RLock()
+	defer vs.lock.RUnlock()
+	list := make([]ValidatorInfo, 0)
+	vs.ValidatorByIndex.Range(func(_, v any) bool {
+		info := v.(*ValidatorInfo)
+		if len(statuses) == 0 {
+			list = append(list, *info)
+			return true
+		}
+		for _, status := range statuses {
+			if strings.HasPrefix(info.Status, status) {
+				list = append(list, *info)
+				break
+			}
+		}
+		return true
+	})
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Index < list[j].Index
+	})
+	return list
+}
+
+// ValidatorIndices returns the indices of the known validators in order.
+func (vs *ValidatorDataSet) ValidatorIndices() []int {
+	list := vs.ListValidators()
+	indices := make([]int, len(list))
+	for i, v := range list {
+		indices[i] = int(v.Index)
+	}
+	return indices
+}
@@ -0,0 +1,44 @@
+package validatorSet
+
+import "testing"
+
+func TestSyncValidators(t *testing.T) {
+	const farFuture = ^uint64(0)
+	vs := NewValidatorSet()
+	vs.AddValidator(0, "0x00")
+
+	changes := vs.SyncValidators([]ValidatorInfo{
+		{Index: 0, Pubkey: "00", Status: "active_ongoing", Balance: 32e9, ExitEpoch: farFuture},
+		{Index: 64, Pubkey: "0x40", Status: "active_ongoing", Balance: 32e9, ExitEpoch: farFuture},
+	})
+	if changes != (RegistryChanges{Added: 1, Updated: 1}) {
+		t.Fatalf("first sync: got %+v", changes)
+	}
+	if v := vs.GetValidatorByPubkey("40"); v == nil || v.Index != 64 || v.Balance != 32e9 {
+		t.Fatalf("validator above 63 not resolved: %+v", v)
+	}
+	before := vs.GetValidatorByIndex(64)
+
+	changes = vs.SyncValidators([]ValidatorInfo{
+		{Index: 0, Pubkey: "0x00", Status: "active_ongoing", Balance: 32e9, ExitEpoch: farFuture},
+		{Index: 64, Pubkey: "0x40", Status: "active_exiting", Balance: 31e9, ExitEpoch: 10},
+		{Index: 65, Pubkey: "0x41", Status: "pending_queued", ExitEpoch: farFuture},
+	})
+	if changes != (RegistryChanges{Added: 1, Exited: 1}) {
+		t.Fatalf("second sync: got %+v", changes)
+	}
+	if before.Status != "active_ongoing" {
+		t.Fatalf("info returned before the sync was modified: %+v", before)
+	}
+	// registry state is kept when the duties add a known validator.
+	vs.AddValidator(64, "40")
+	if v := vs.GetValidatorByIndex(64); v.ExitEpoch != 10 {
+		t.Fatalf("add known validator reset it: %+v", v)
+	}
+	if list := vs.ListValidators("active"); len(list) != 2 || list[1].Index != 64 {
+		t.Fatalf("active validators: got %+v", list)
+	}
+	if indices := vs.ValidatorIndices(); len(indices) != 3 || indices[2] != 65 {
+		t.Fatalf("indices: got %v", indices)
+	}
+}
@@ -0,0 +1,165 @@
+package validatorSet
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/tsinghua-cel/attacker-service/types"
+)
+
+// RoleOverride sets the role of a validator at runtime, ahead of the strategy.
+// StartSlot and EndSlot bound it inclusively, -1 means unbounded.
+type RoleOverride struct {
+	ValidatorIndex int            `json:"validator_index"`
+	Role           types.RoleType `json:"role"`
+	StartSlot      int64          `json:"start_slot"`
+	EndSlot        int64          `json:"end_slot"`
+}
+
+func (o RoleOverride) contains(slot int64) bool {
+	if o.StartSlot >= 0 && slot < o.StartSlot {
+		return false
+	}
+	if o.EndSlot >= 0 && slot > o.EndSlot {
+		return false
+	}
+	return true
+}
+
+// RoleStore saves the role overrides, see store.Store.
+type RoleStore interface {
+	SaveRoleOverrides(list []RoleOverride) error
+	RoleOverrides() ([]RoleOverride, error)
+}
+
+// RoleOverrides keeps the role overrides by validator and saves them to a
+// file or a store so that they survive restarts.
+type RoleOverrides struct {
+	file      string
+	store     RoleStore
+	overrides map[int]RoleOverride
+	lock      sync.RWMutex
+}
+
+// NewRoleOverrides loads the overrides saved in file, an empty file name
+// keeps them in memory only.
+func NewRoleOverrides(file string) (*RoleOverrides, error) {
+	r := &RoleOverrides{
+		file:      file,
+		overrides: make(map[int]RoleOverride),
+	}
+	if file == "" {
+		return r, nil
+	}
+	data, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	list := make([]RoleOverride, 0)
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, err
+	}
+	for _, o := range list {
+		r.overrides[o.ValidatorIndex] = o
+	}
+	return r, nil
+}
+
+// NewRoleOverridesFromStore loads the overrides saved in st and saves the
+// changes to it.
+func NewRoleOverridesFromStore(st RoleStore) (*RoleOverrides, error) {
+	list, err := st.RoleOverrides()
+	if err != nil {
+		return nil, err
+	}
+	r := &RoleOverrides{
+		store:     st,
+		overrides: make(map[int]RoleOverride),
+	}
+	for _, o := range list {
+		r.overrides[o.ValidatorIndex] = o
+	}
+	return r, nil
+}
+
+// Get returns the role of the validator at slot if it is overridden.
+func (r *RoleOverrides) Get(valIdx int, slot int64) (types.RoleType, bool) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	o, exist := r.overrides[valIdx]
+	if !exist || !o.contains(slot) {
+		return types.NormalRole, false
+	}
+	return o.Role, true
+}
+
+// Set replaces the override of the validator.
+func (r *RoleOverrides) Set(o RoleOverride) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.overrides[o.ValidatorIndex] = o
+	return r.save()
+}
+
+// Remove deletes the override of the validator, the role comes from the
+// strategy again.
+func (r *RoleOverrides) Remove(valIdx int) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	delete(r.overrides, valIdx)
+	return r.save()
+}
+
+// List returns all overrides ordered by validator index.
+func (r *RoleOverrides) List() []RoleOverride {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.list()
+}
+
+func (r *RoleOverrides) list() []RoleOverride {
+	list := make([]RoleOverride, 0, len(r.overrides))
+	for _, o := range r.overrides {
+		list = append(list, o)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].ValidatorIndex < list[j].ValidatorIndex
+	})
+	return list
+}
+
+// save writes the overrides to the store, or to a temporary file renamed
+// afterwards so that a crash never leaves a truncated file.
+func (r *RoleOverrides) save() error {
+	if r.store != nil {
+		return r.store.SaveRoleOverrides(r.list())
+	}
+	if r.file == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(r.list(), "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(r.file), filepath.Base(r.file)+".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), r.file)
+}
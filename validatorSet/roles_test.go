@@ -0,0 +1,47 @@
+package validatorSet
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/tsinghua-cel/attacker-service/types"
+)
+
+func TestRoleOverrides(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "roles.json")
+	r, err := NewRoleOverrides(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Set(RoleOverride{ValidatorIndex: 3, Role: types.AttackerRole, StartSlot: 10, EndSlot: -1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Set(RoleOverride{ValidatorIndex: 5, Role: types.NormalRole, StartSlot: -1, EndSlot: -1}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := r.Get(3, 9); ok {
+		t.Fatalf("validator 3 is overridden before its start slot")
+	}
+	if role, ok := r.Get(3, 1000); !ok || role != types.AttackerRole {
+		t.Fatalf("validator 3 at slot 1000: got %v %v, want attacker", role, ok)
+	}
+
+	// reload from file.
+	r, err = NewRoleOverrides(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list := r.List(); len(list) != 2 || list[0].ValidatorIndex != 3 || list[1].Role != types.NormalRole {
+		t.Fatalf("reloaded overrides: got %v", list)
+	}
+	if err := r.Remove(3); err != nil {
+		t.Fatal(err)
+	}
+	r, err = NewRoleOverrides(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := r.Get(3, 1000); ok {
+		t.Fatalf("removed override of validator 3 is still there")
+	}
+}
@@ -2,6 +2,7 @@ package validatorSet
 
 import (
 	ethpb "github.com/prysmaticlabs/prysm/v4/proto/prysm/v1alpha1"
+	log "github.com/sirupsen/logrus"
 	"strings"
 	"sync"
 )
@@ -9,24 +10,61 @@ import (
 type ValidatorInfo struct {
 	Index  int64  `json:"index"`
 	Pubkey string `json:"pubkey"`
+	// state of the validator in the registry, zero until the registry is
+	// synced, see registry.go.
+	Status           string `json:"status,omitempty"` // like "active_ongoing"
+	Balance          uint64 `json:"balance"`          // gwei
+	EffectiveBalance uint64 `json:"effective_balance"`
+	Slashed          bool   `json:"slashed"`
+	ActivationEpoch  uint64 `json:"activation_epoch"`
+	ExitEpoch        uint64 `json:"exit_epoch"`
 	//Role   types.RoleType `json:"role"`
 	//Attests ValidatorAttestSet `json:"attests"`
 	//Blocks  ValidatorBlockSet  `json:"blocks"`
 }
 
 type ValidatorDataSet struct {
-	ValidatorByIndex  sync.Map                  //map[int]*ValidatorInfo
-	ValidatorByPubkey sync.Map                  //map[string]*ValidatorInfo
-	AttestSet         map[uint64]*SlotAttestSet // epoch -> attestation
-	BlockSet          map[uint64]*SlotBlockSet  // epoch -> block
+	ValidatorByIndex  sync.Map       //map[int]*ValidatorInfo
+	ValidatorByPubkey sync.Map       //map[string]*ValidatorInfo
+	ExitRequests      map[int]uint64 // validator -> slot the exit was requested
 	lock              sync.RWMutex
+
+	// signed data of the validator clients, see history.go.
+	attests         *index[SignedAttestation]
+	attestsByTarget map[uint64]map[recordKey]*SignedAttestation // target epoch -> attestations
+	blocks          *index[SignedBlock]
+	equivocations   *index[SignedBlock] // second blocks of a validator for a slot
+	slotsPerEpoch   uint64
+	store           HistoryStore // nil keeps the signed data in memory only
 }
 
 func NewValidatorSet() *ValidatorDataSet {
 	return &ValidatorDataSet{
-		AttestSet: make(map[uint64]*SlotAttestSet),
-		BlockSet:  make(map[uint64]*SlotBlockSet),
+		ExitRequests:    make(map[int]uint64),
+		attests:         newIndex[SignedAttestation](),
+		attestsByTarget: make(map[uint64]map[recordKey]*SignedAttestation),
+		blocks:          newIndex[SignedBlock](),
+		equivocations:   newIndex[SignedBlock](),
+		slotsPerEpoch:   32,
+	}
+}
+
+// SetSlotsPerEpoch sets the epoch length used to index the records added
+// from now on.
+func (vs *ValidatorDataSet) SetSlotsPerEpoch(n int) {
+	if n <= 0 {
+		return
 	}
+	vs.lock.Lock()
+	defer vs.lock.Unlock()
+	vs.slotsPerEpoch = uint64(n)
+}
+
+// SlotsPerEpoch returns the epoch length of the records.
+func (vs *ValidatorDataSet) SlotsPerEpoch() uint64 {
+	vs.lock.RLock()
+	defer vs.lock.RUnlock()
+	return vs.slotsPerEpoch
 }
 
 func padPubkey(p string) string {
@@ -36,10 +74,15 @@ func padPubkey(p string) string {
 	return "0x" + p
 }
 
+// AddValidator adds the validator if it isn't known yet, the registry state
+// of a known validator is kept.
 func (vs *ValidatorDataSet) AddValidator(index int, pubkey string) {
 	pubkey = padPubkey(pubkey)
 	vs.lock.Lock()
 	defer vs.lock.Unlock()
+	if v, exist := vs.ValidatorByIndex.Load(index); exist && v.(*ValidatorInfo).Pubkey == pubkey {
+		return
+	}
 	v := &ValidatorInfo{
 		Index:  int64(index),
 		Pubkey: pubkey,
@@ -69,64 +112,99 @@ func (vs *ValidatorDataSet) GetValidatorByPubkey(pubkey string) *ValidatorInfo {
 	}
 }
 
-func (vs *ValidatorDataSet) GetAttestSet(slot uint64) *SlotAttestSet {
-	vs.lock.RLock()
-	defer vs.lock.RUnlock()
-	if v, exist := vs.AttestSet[slot]; !exist {
-		return nil
-	} else {
-		return v
-	}
-}
-
-func (vs *ValidatorDataSet) GetBlockSet(slot uint64) *SlotBlockSet {
-	vs.lock.RLock()
-	defer vs.lock.RUnlock()
-	if v, exist := vs.BlockSet[slot]; !exist {
-		return nil
-	} else {
-		return v
-	}
-}
-
+// AddSignedAttestation records the attestation signed by the validator for
+// slot, it replaces a previous attestation of the validator for slot.
 func (vs *ValidatorDataSet) AddSignedAttestation(slot uint64, pubkey string, attestation *ethpb.Attestation) {
 	pubkey = padPubkey(pubkey)
 	vs.lock.Lock()
-	defer vs.lock.Unlock()
+	a := &SignedAttestation{
+		Slot:           slot,
+		Epoch:          slot / vs.slotsPerEpoch,
+		Pubkey:         pubkey,
+		ValidatorIndex: vs.validatorIndex(pubkey),
+		Attestation:    attestation,
+	}
+	vs.addAttestation(a)
+	st := vs.store
+	vs.lock.Unlock()
+
+	// don't hold the lock while writing to disk.
+	if st != nil {
+		if err := st.SaveAttestation(*a); err != nil {
+			log.WithError(err).WithField("slot", slot).Warn("save signed attestation failed")
+		}
+	}
+}
 
-	if _, exist := vs.AttestSet[slot]; !exist {
-		vs.AttestSet[slot] = &SlotAttestSet{
-			Attestations: make(map[string]*ethpb.Attestation),
+func (vs *ValidatorDataSet) addAttestation(a *SignedAttestation) {
+	if old := vs.attests.add(a.Slot, a.Epoch, a.Pubkey, a); old != nil {
+		vs.removeTarget(old)
+	}
+	if target, ok := attestTarget(a.Attestation); ok {
+		if _, exist := vs.attestsByTarget[target]; !exist {
+			vs.attestsByTarget[target] = make(map[recordKey]*SignedAttestation)
 		}
+		vs.attestsByTarget[target][recordKey{a.Slot, a.Pubkey}] = a
 	}
-	vs.AttestSet[slot].Attestations[pubkey] = attestation
 }
 
+// AddSignedBlock records the block signed by the validator for slot. A block
+// with another root than the first block of the validator for slot is kept
+// as an equivocation beside it.
 func (vs *ValidatorDataSet) AddSignedBlock(slot uint64, pubkey string, block *ethpb.GenericSignedBeaconBlock) {
 	pubkey = padPubkey(pubkey)
+	root := blockRoot(block)
 	vs.lock.Lock()
-	defer vs.lock.Unlock()
+	b := &SignedBlock{
+		Slot:           slot,
+		Epoch:          slot / vs.slotsPerEpoch,
+		Pubkey:         pubkey,
+		ValidatorIndex: vs.validatorIndex(pubkey),
+		Root:           root,
+		Block:          block,
+	}
+	if first, exist := vs.blocks.bySlot[slot][pubkey]; exist && root != "" && first.Root != "" && first.Root != root {
+		b.Equivocation = true
+		vs.equivocations.add(slot, b.Epoch, pubkey, b)
+		log.WithFields(log.Fields{
+			"slot":   slot,
+			"pubkey": pubkey,
+			"first":  first.Root,
+			"second": root,
+		}).Info("validator signed a second block for the slot")
+	} else {
+		vs.blocks.add(slot, b.Epoch, pubkey, b)
+	}
+	st := vs.store
+	vs.lock.Unlock()
 
-	if _, exist := vs.BlockSet[slot]; !exist {
-		vs.BlockSet[slot] = &SlotBlockSet{
-			Blocks: make(map[string]*ethpb.GenericSignedBeaconBlock),
+	if st != nil {
+		if err := st.SaveBlock(*b); err != nil {
+			log.WithError(err).WithField("slot", slot).Warn("save signed block failed")
 		}
 	}
-	vs.BlockSet[slot].Blocks[pubkey] = block
-}
-
-type SlotAttestSet struct {
-	Attestations map[string]*ethpb.Attestation
 }
 
-type SlotBlockSet struct {
-	Blocks map[string]*ethpb.GenericSignedBeaconBlock
-}
-
-type ValidatorAttestSet struct {
-	Attestations map[uint64]*ethpb.Attestation
+// RequestExit records that the validator was asked to exit at slot, it
+// returns false if it was already asked.
+func (vs *ValidatorDataSet) RequestExit(index int, slot uint64) bool {
+	vs.lock.Lock()
+	defer vs.lock.Unlock()
+	if _, exist := vs.ExitRequests[index]; exist {
+		return false
+	}
+	vs.ExitRequests[index] = slot
+	return true
 }
 
-type ValidatorBlockSet struct {
-	Blocks map[uint64]ethpb.GenericBeaconBlock
+// GetExitRequests returns the validators asked to exit and the slot they
+// were asked at.
+func (vs *ValidatorDataSet) GetExitRequests() map[int]uint64 {
+	vs.lock.RLock()
+	defer vs.lock.RUnlock()
+	res := make(map[int]uint64, len(vs.ExitRequests))
+	for k, v := range vs.ExitRequests {
+		res[k] = v
+	}
+	return res
 }
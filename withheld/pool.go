@@ -0,0 +1,154 @@
+package withheld
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	ethpb "github.com/prysmaticlabs/prysm/v4/proto/prysm/v1alpha1"
+	log "github.com/sirupsen/logrus"
+)
+
+// Attestation is a signed attestation an attacker didn't broadcast.
+type Attestation struct {
+	Slot        uint64             `json:"slot"`
+	Pubkey      string             `json:"pubkey"`
+	Withheld    time.Time          `json:"withheld"`
+	Attestation *ethpb.Attestation `json:"attestation"`
+}
+
+// Store saves the withheld attestations so that they survive a restart of
+// the service, see store.Store.
+type Store interface {
+	SaveWithheld(a Attestation) error
+	DeleteWithheld(slot uint64, pubkey string) error
+}
+
+// Pool keeps the withheld attestations until they are released, one per
+// validator and slot.
+type Pool struct {
+	bySlot map[uint64]map[string]*Attestation
+	store  Store // nil keeps the attestations in memory only
+	lock   sync.Mutex
+}
+
+func New() *Pool {
+	return &Pool{bySlot: make(map[uint64]map[string]*Attestation)}
+}
+
+// SetStore saves the withheld attestations to st from now on.
+func (p *Pool) SetStore(st Store) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.store = st
+}
+
+// Add withholds the attestation of the validator for slot, replacing the one
+// withheld before for the same slot.
+func (p *Pool) Add(att Attestation) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	atts, exist := p.bySlot[att.Slot]
+	if !exist {
+		atts = make(map[string]*Attestation)
+		p.bySlot[att.Slot] = atts
+	}
+	atts[att.Pubkey] = &att
+	p.save(att)
+}
+
+// Take removes and returns the attestations for which release is true,
+// ordered by slot.
+func (p *Pool) Take(release func(a Attestation) bool) []Attestation {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	taken := make([]Attestation, 0)
+	for slot, atts := range p.bySlot {
+		for key, a := range atts {
+			if release(*a) {
+				taken = append(taken, *a)
+				delete(atts, key)
+				p.delete(a.Slot, a.Pubkey)
+			}
+		}
+		if len(atts) == 0 {
+			delete(p.bySlot, slot)
+		}
+	}
+	sort.Slice(taken, func(i, j int) bool {
+		return taken[i].Slot < taken[j].Slot
+	})
+	return taken
+}
+
+// Restore puts back attestations taken for a release that failed, or saved
+// before a restart. An attestation withheld since for the same slot and
+// validator is kept.
+func (p *Pool) Restore(atts []Attestation) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	for i := range atts {
+		att := atts[i]
+		slotAtts, exist := p.bySlot[att.Slot]
+		if !exist {
+			slotAtts = make(map[string]*Attestation)
+			p.bySlot[att.Slot] = slotAtts
+		}
+		if _, exist := slotAtts[att.Pubkey]; !exist {
+			slotAtts[att.Pubkey] = &att
+			p.save(att)
+		}
+	}
+}
+
+// List returns the withheld attestations ordered by slot.
+func (p *Pool) List() []Attestation {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	list := make([]Attestation, 0)
+	for _, atts := range p.bySlot {
+		for _, a := range atts {
+			list = append(list, *a)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Slot < list[j].Slot
+	})
+	return list
+}
+
+// PruneBefore drops the attestations of the slots before slot, they can't be
+// included anymore. It returns the number of dropped attestations.
+func (p *Pool) PruneBefore(slot uint64) int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	n := 0
+	for s, atts := range p.bySlot {
+		if s < slot {
+			n += len(atts)
+			for pubkey := range atts {
+				p.delete(s, pubkey)
+			}
+			delete(p.bySlot, s)
+		}
+	}
+	return n
+}
+
+func (p *Pool) save(att Attestation) {
+	if p.store == nil {
+		return
+	}
+	if err := p.store.SaveWithheld(att); err != nil {
+		log.WithError(err).WithField("slot", att.Slot).Warn("save withheld attestation failed")
+	}
+}
+
+func (p *Pool) delete(slot uint64, pubkey string) {
+	if p.store == nil {
+		return
+	}
+	if err := p.store.DeleteWithheld(slot, pubkey); err != nil {
+		log.WithError(err).WithField("slot", slot).Warn("delete withheld attestation failed")
+	}
+}
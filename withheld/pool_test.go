@@ -0,0 +1,46 @@
+package withheld
+
+import (
+	"testing"
+
+	ethpb "github.com/prysmaticlabs/prysm/v4/proto/prysm/v1alpha1"
+)
+
+func TestPool(t *testing.T) {
+	p := New()
+	p.Add(Attestation{Slot: 5, Pubkey: "0x01", Attestation: &ethpb.Attestation{}})
+	p.Add(Attestation{Slot: 5, Pubkey: "0x01", Attestation: &ethpb.Attestation{Signature: []byte{1}}})
+	p.Add(Attestation{Slot: 5, Pubkey: "0x02", Attestation: &ethpb.Attestation{}})
+	p.Add(Attestation{Slot: 3, Pubkey: "0x01", Attestation: &ethpb.Attestation{}})
+	if list := p.List(); len(list) != 3 || list[0].Slot != 3 {
+		t.Fatalf("withheld: got %+v", list)
+	}
+
+	taken := p.Take(func(a Attestation) bool { return a.Pubkey == "0x01" })
+	if len(taken) != 2 || taken[0].Slot != 3 || len(taken[1].Attestation.Signature) != 1 {
+		t.Fatalf("taken: got %+v", taken)
+	}
+	if taken := p.Take(func(a Attestation) bool { return a.Pubkey == "0x01" }); len(taken) != 0 {
+		t.Fatalf("taken twice: got %+v", taken)
+	}
+
+	// a newer attestation of the same validator and slot is kept.
+	p.Add(Attestation{Slot: 5, Pubkey: "0x01", Attestation: &ethpb.Attestation{Signature: []byte{2}}})
+	p.Restore(taken)
+	if list := p.List(); len(list) != 3 || list[0].Slot != 3 {
+		t.Fatalf("restored: got %+v", list)
+	}
+	for _, a := range p.List() {
+		if a.Slot == 5 && a.Pubkey == "0x01" && a.Attestation.Signature[0] != 2 {
+			t.Fatalf("restore replaced the newer attestation: %+v", a)
+		}
+	}
+
+	p.Add(Attestation{Slot: 9, Pubkey: "0x01", Attestation: &ethpb.Attestation{}})
+	if n := p.PruneBefore(8); n != 3 {
+		t.Fatalf("pruned %d attestations, want 3", n)
+	}
+	if list := p.List(); len(list) != 1 || list[0].Slot != 9 {
+		t.Fatalf("after prune: got %+v", list)
+	}
+}